@@ -150,23 +150,45 @@ function formatRelativeTime(dateString) {
     return 'Just now';
 }
 
+// API key, kept in the browser and sent as a bearer token
+const API_KEY_STORAGE = 'urlShortenerApiKey';
+
+function getApiKey() {
+    let key = localStorage.getItem(API_KEY_STORAGE);
+    if (!key) {
+        key = window.prompt('Enter your API key');
+        if (key) {
+            localStorage.setItem(API_KEY_STORAGE, key.trim());
+        }
+    }
+    return key;
+}
+
 // API functions
-async function apiRequest(url, options = {}) {
+async function apiRequest(url, options = {}, retried = false) {
     try {
         console.log('Making API request to:', url);
         console.log('Full URL resolved to:', new URL(url, window.location.href).href);
         
+        const apiKey = getApiKey();
         const response = await fetch(url, {
+            ...options,
             headers: {
                 'Content-Type': 'application/json',
+                ...(apiKey ? { 'Authorization': `Bearer ${apiKey}` } : {}),
                 ...options.headers
-            },
-            ...options
+            }
         });
         
         console.log('Response status:', response.status);
         console.log('Response URL:', response.url);
         
+        // Forget a rejected key and ask again once
+        if (response.status === 401 && !retried) {
+            localStorage.removeItem(API_KEY_STORAGE);
+            return apiRequest(url, options, true);
+        }
+        
         if (!response.ok) {
             throw new Error(`HTTP error! status: ${response.status}`);
         }
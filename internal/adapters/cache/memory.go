@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+)
+
+// pruneInterval is how often idle buckets and expired counters are
+// dropped from a MemoryLimiter.
+const pruneInterval = time.Minute
+
+// MemoryLimiter keeps token buckets and counters in process. Limits are
+// per replica, so it serves as a fallback for when redis is unreachable.
+type MemoryLimiter struct {
+	mu       sync.Mutex
+	buckets  map[string]*bucket
+	counters map[string]*counter
+	pruned   time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	idle    time.Time
+}
+
+type counter struct {
+	count   int64
+	expires time.Time
+}
+
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{
+		buckets:  map[string]*bucket{},
+		counters: map[string]*counter{},
+	}
+}
+
+func (m *MemoryLimiter) Take(ctx context.Context, key string, limit domain.RateLimit) (domain.RateDecision, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.prune(now)
+
+	capacity := float64(limit.Requests)
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, updated: now}
+		m.buckets[key] = b
+	}
+	elapsed := max(0, now.Sub(b.updated))
+	b.tokens = math.Min(capacity, b.tokens+float64(elapsed)*capacity/float64(limit.Window))
+	b.updated = now
+	b.idle = now.Add(limit.Window)
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	return limit.Decide(allowed, b.tokens), nil
+}
+
+func (m *MemoryLimiter) Increment(ctx context.Context, key string, expireAt time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.prune(now)
+
+	c, ok := m.counters[key]
+	if !ok || !now.Before(c.expires) {
+		c = &counter{}
+		m.counters[key] = c
+	}
+	c.count++
+	c.expires = expireAt
+	return c.count, nil
+}
+
+// prune drops buckets that have refilled and counters that expired.
+func (m *MemoryLimiter) prune(now time.Time) {
+	if now.Sub(m.pruned) < pruneInterval {
+		return
+	}
+	m.pruned = now
+	for key, b := range m.buckets {
+		if now.After(b.idle) {
+			delete(m.buckets, key)
+		}
+	}
+	for key, c := range m.counters {
+		if !now.Before(c.expires) {
+			delete(m.counters, key)
+		}
+	}
+}
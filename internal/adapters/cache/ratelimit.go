@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+)
+
+// takeScript refills and takes from a token bucket stored as a hash of
+// its tokens and the time it was last updated. It uses the clock of the
+// redis server so replicas with skewed clocks share one bucket fairly.
+var takeScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or capacity
+local ts = tonumber(state[2]) or now
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * capacity / window)
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], window)
+return {allowed, tostring(tokens)}
+`)
+
+// Take removes a token from the bucket key, shared by every replica.
+func (r *RedisCache) Take(ctx context.Context, key string, limit domain.RateLimit) (domain.RateDecision, error) {
+	result, err := takeScript.Run(ctx, r.client, []string{"ratelimit:" + key},
+		limit.Requests, limit.Window.Milliseconds()).Slice()
+	if err != nil {
+		return domain.RateDecision{}, fmt.Errorf("failed to take rate limit token: %w: %w", domain.ErrUnavailable, err)
+	}
+	if len(result) != 2 {
+		return domain.RateDecision{}, fmt.Errorf("failed to take rate limit token: %w: unexpected reply %v", domain.ErrUnavailable, result)
+	}
+	allowed, _ := result[0].(int64)
+	reply, _ := result[1].(string)
+	tokens, err := strconv.ParseFloat(reply, 64)
+	if err != nil {
+		return domain.RateDecision{}, fmt.Errorf("failed to take rate limit token: %w: %w", domain.ErrUnavailable, err)
+	}
+	return limit.Decide(allowed == 1, tokens), nil
+}
+
+// Increment adds one to the counter key, shared by every replica.
+func (r *RedisCache) Increment(ctx context.Context, key string, expireAt time.Time) (int64, error) {
+	var incr *redis.IntCmd
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(ctx, "counter:"+key)
+		pipe.ExpireAt(ctx, "counter:"+key, expireAt)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment counter: %w: %w", domain.ErrUnavailable, err)
+	}
+	return incr.Val(), nil
+}
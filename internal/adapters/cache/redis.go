@@ -2,9 +2,11 @@ package cache
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
 )
 
 type RedisCache struct {
@@ -17,12 +19,16 @@ func NewRedisCache(address string, password string, db int) *RedisCache {
 		Password: password,
 		DB:       db,
 	})
+	client.AddHook(tracingHook{})
 
 	return &RedisCache{client: client}
 }
 
 func (r *RedisCache) Set(ctx context.Context, key string, val string) error {
-	return r.client.Set(ctx, key, val, time.Minute).Err()
+	if err := r.client.Set(ctx, key, val, time.Minute).Err(); err != nil {
+		return fmt.Errorf("failed to set cache key: %w: %w", domain.ErrUnavailable, err)
+	}
+	return nil
 }
 
 func (r *RedisCache) Get(ctx context.Context, key string) (string, error) {
@@ -30,9 +36,23 @@ func (r *RedisCache) Get(ctx context.Context, key string) (string, error) {
 	if err == redis.Nil {
 		return "", nil
 	}
-	return val, err
+	if err != nil {
+		return "", fmt.Errorf("failed to get cache key: %w: %w", domain.ErrUnavailable, err)
+	}
+	return val, nil
 }
 
 func (r *RedisCache) Delete(ctx context.Context, key string) error {
-	return r.client.Del(ctx, key).Err()
+	if err := r.client.Del(ctx, key).Err(); err != nil {
+		return fmt.Errorf("failed to delete cache key: %w: %w", domain.ErrUnavailable, err)
+	}
+	return nil
+}
+
+func (r *RedisCache) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
+func (r *RedisCache) Close() error {
+	return r.client.Close()
 }
@@ -0,0 +1,66 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"time"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+)
+
+// Resolver looks up TXT and address records through the system resolver, or through
+// the DNS server at a fixed address when one is configured.
+type Resolver struct {
+	resolver *net.Resolver
+	timeout  time.Duration
+}
+
+// NewResolver returns a resolver querying server, a host:port address, or
+// the system resolver when server is empty. Each lookup is bounded by
+// timeout.
+func NewResolver(server string, timeout time.Duration) *Resolver {
+	resolver := net.DefaultResolver
+	if server != "" {
+		resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, network, server)
+			},
+		}
+	}
+	return &Resolver{resolver: resolver, timeout: timeout}
+}
+
+func (r *Resolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	records, err := r.resolver.LookupTXT(ctx, name)
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return nil, fmt.Errorf("TXT records of %q: %w", name, domain.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up TXT records: %w: %w", domain.ErrUnavailable, err)
+	}
+	return records, nil
+}
+
+func (r *Resolver) LookupIP(ctx context.Context, host string) ([]netip.Addr, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	addrs, err := r.resolver.LookupNetIP(ctx, "ip", host)
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return nil, fmt.Errorf("addresses of %q: %w", host, domain.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up addresses: %w: %w", domain.ErrUnavailable, err)
+	}
+	return addrs, nil
+}
@@ -5,25 +5,40 @@ import (
 
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/itsbaivab/url-shortener/internal/adapters/cache"
+	"github.com/itsbaivab/url-shortener/internal/adapters/dns"
 	"github.com/itsbaivab/url-shortener/internal/adapters/handlers"
 	"github.com/itsbaivab/url-shortener/internal/adapters/repository"
 	"github.com/itsbaivab/url-shortener/internal/config"
 	"github.com/itsbaivab/url-shortener/internal/core/services"
+	"github.com/itsbaivab/url-shortener/internal/logging"
 )
 
 func main() {
-	appConfig := config.NewConfig()
+	appConfig := config.NewConfig(config.DynamoDB, config.Redis)
 	redisAddress, redisPassword, redisDB := appConfig.GetRedisParams()
-	cache := cache.NewRedisCache(redisAddress, redisPassword, redisDB)
+	redisCache := cache.NewRedisCache(redisAddress, redisPassword, redisDB)
 	linkTableName := appConfig.GetLinkTableName()
 	statsTableName := appConfig.GetStatsTableName()
 
+	// Lambdas have no managed rules; file rules are read once per instance.
+	blocklist := services.NewBlocklistService(nil).WithFiles(appConfig.Blocklist.Files...)
+	if err := blocklist.Reload(context.TODO()); err != nil {
+		logging.Fatal("failed to load blocklist", "error", err)
+	}
+
 	linkRepo := repository.NewLinkRepository(context.TODO(), linkTableName)
-	linkService := services.NewLinkService(linkRepo, cache)
+	linkService := services.NewLinkService(linkRepo, redisCache).WithBlocklist(blocklist)
 
 	statsRepo := repository.NewStatsRepository(context.TODO(), statsTableName)
-	statsService := services.NewStatsService(statsRepo, cache)
+	statsService := services.NewStatsService(statsRepo, redisCache)
+
+	destinations := services.NewDestinationValidator(dns.NewResolver(appConfig.DNS.Server, appConfig.DNS.Timeout)).
+		WithMaxLength(appConfig.Destinations.MaxLength).
+		WithOwnHosts(appConfig.Destinations.OwnHosts...).
+		WithBlocklist(blocklist)
 
-	handler := handlers.NewGenerateLinkFunctionHandler(linkService, statsService)
-	lambda.Start(handler.CreateShortLink)
+	handler := handlers.NewGenerateLinkFunctionHandler(linkService, statsService, destinations)
+	limits := services.NewRateLimitService(redisCache, cache.NewMemoryLimiter()).
+		WithLimits(appConfig.RateLimit.PerIPLimit(), appConfig.RateLimit.PerKeyLimit())
+	lambda.Start(handlers.RateLimited(limits, handler.CreateShortLink))
 }
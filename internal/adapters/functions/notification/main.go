@@ -1,13 +1,15 @@
 package main
 
 import (
-	"log"
+	"log/slog"
 
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/itsbaivab/url-shortener/internal/adapters/handlers"
+	"github.com/itsbaivab/url-shortener/internal/config"
 )
 
 func main() {
-	log.Print("Starting Lambda")
+	config.NewConfig(config.Slack)
+	slog.Info("starting Lambda")
 	lambda.Start(handlers.SlackHandler)
 }
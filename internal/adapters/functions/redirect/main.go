@@ -5,26 +5,46 @@ import (
 
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/itsbaivab/url-shortener/internal/adapters/cache"
+	"github.com/itsbaivab/url-shortener/internal/adapters/geoip"
 	"github.com/itsbaivab/url-shortener/internal/adapters/handlers"
 	"github.com/itsbaivab/url-shortener/internal/adapters/repository"
 	"github.com/itsbaivab/url-shortener/internal/config"
 	"github.com/itsbaivab/url-shortener/internal/core/services"
+	"github.com/itsbaivab/url-shortener/internal/logging"
 )
 
 func main() {
-	appConfig := config.NewConfig()
+	appConfig := config.NewConfig(config.DynamoDB, config.Redis)
 	redisAddress, redisPassword, redisDB := appConfig.GetRedisParams()
-	cache := cache.NewRedisCache(redisAddress, redisPassword, redisDB)
+	redisCache := cache.NewRedisCache(redisAddress, redisPassword, redisDB)
 	linkTableName := appConfig.GetLinkTableName()
 	statsTableName := appConfig.GetStatsTableName()
 
+	// Lambdas have no managed rules; file rules are read once per instance.
+	blocklist := services.NewBlocklistService(nil).WithFiles(appConfig.Blocklist.Files...)
+	if err := blocklist.Reload(context.TODO()); err != nil {
+		logging.Fatal("failed to load blocklist", "error", err)
+	}
+
 	linkRepo := repository.NewLinkRepository(context.TODO(), linkTableName)
-	linkService := services.NewLinkService(linkRepo, cache)
+	linkService := services.NewLinkService(linkRepo, redisCache).
+		WithBlocklist(blocklist).
+		WithSigning(services.NewSigningService(appConfig.Signing.SigningKeys()...))
 
 	statsRepo := repository.NewStatsRepository(context.TODO(), statsTableName)
-	statsService := services.NewStatsService(statsRepo, cache)
+	statsService := services.NewStatsService(statsRepo, redisCache)
 
 	handler := handlers.NewRedirectFunctionHandler(linkService, statsService)
+	if path := appConfig.GeoIP.Database; path != "" {
+		// Bundled with the function and read once per instance.
+		geo, err := geoip.Open(path)
+		if err != nil {
+			logging.Fatal("failed to load GeoIP database", "error", err)
+		}
+		handler.WithGeoIP(geo)
+	}
 
-	lambda.Start(handler.Redirect)
+	limits := services.NewRateLimitService(redisCache, cache.NewMemoryLimiter()).
+		WithLimits(appConfig.RateLimit.PerIPLimit(), appConfig.RateLimit.PerKeyLimit())
+	lambda.Start(handlers.RateLimited(limits, handler.Redirect))
 }
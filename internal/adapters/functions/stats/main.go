@@ -12,20 +12,22 @@ import (
 )
 
 func main() {
-	appConfig := config.NewConfig()
+	appConfig := config.NewConfig(config.DynamoDB, config.Redis)
 	redisAddress, redisPassword, redisDB := appConfig.GetRedisParams()
 	linkTableName := appConfig.GetLinkTableName()
 	statsTableName := appConfig.GetStatsTableName()
 
-	cache := cache.NewRedisCache(redisAddress, redisPassword, redisDB)
+	redisCache := cache.NewRedisCache(redisAddress, redisPassword, redisDB)
 
 	linkRepo := repository.NewLinkRepository(context.TODO(), linkTableName)
 	statsRepo := repository.NewStatsRepository(context.TODO(), statsTableName)
 
-	linkService := services.NewLinkService(linkRepo, cache)
-	statsService := services.NewStatsService(statsRepo, cache)
+	linkService := services.NewLinkService(linkRepo, redisCache)
+	statsService := services.NewStatsService(statsRepo, redisCache)
 
 	handler := handlers.NewStatsFunctionHandler(linkService, statsService)
 
-	lambda.Start(handler.Stats)
+	limits := services.NewRateLimitService(redisCache, cache.NewMemoryLimiter()).
+		WithLimits(appConfig.RateLimit.PerIPLimit(), appConfig.RateLimit.PerKeyLimit())
+	lambda.Start(handlers.RateLimited(limits, handler.Stats))
 }
@@ -0,0 +1,131 @@
+// Package geoip looks up the country of IP addresses in an offline
+// database of address ranges in CSV, one range per line as
+// start,end,country. Both the DB-IP "IP to Country Lite" format, with
+// addresses, and the IP2Location LITE DB1 format, with quoted decimal
+// addresses and a country name after the code, are read.
+package geoip
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+)
+
+type ipRange struct {
+	start, end netip.Addr
+	country    string
+}
+
+// Database is a loaded GeoIP database. The zero Database knows no
+// addresses.
+type Database struct {
+	ranges []ipRange
+}
+
+// Open loads the database at path.
+func Open(path string) (*Database, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open GeoIP database: %w", err)
+	}
+	defer f.Close()
+
+	db, err := Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GeoIP database %s: %w", path, err)
+	}
+	return db, nil
+}
+
+// Read loads a database from r.
+func Read(r io.Reader) (*Database, error) {
+	reader := csv.NewReader(bufio.NewReader(r))
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	db := &Database{}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		if len(record) < 3 {
+			return nil, fmt.Errorf("line %d: want start,end,country", line)
+		}
+		country := strings.ToUpper(strings.TrimSpace(record[2]))
+		if len(country) != 2 || country == "ZZ" {
+			// "-" and "ZZ" mark unassigned ranges.
+			continue
+		}
+		start, err := parseAddr(record[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		end, err := parseAddr(record[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if start.Is4() != end.Is4() || end.Less(start) {
+			return nil, fmt.Errorf("line %d: invalid range %s-%s", line, start, end)
+		}
+		db.ranges = append(db.ranges, ipRange{start: start, end: end, country: country})
+	}
+
+	sort.Slice(db.ranges, func(i, j int) bool {
+		return db.ranges[i].start.Less(db.ranges[j].start)
+	})
+	return db, nil
+}
+
+// parseAddr parses an address, or its decimal value. IPv4-mapped IPv6
+// addresses are IPv4 addresses.
+func parseAddr(s string) (netip.Addr, error) {
+	s = strings.TrimSpace(s)
+	if addr, err := netip.ParseAddr(s); err == nil {
+		return addr.Unmap(), nil
+	}
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok || n.Sign() < 0 || n.BitLen() > 128 {
+		return netip.Addr{}, fmt.Errorf("invalid address %q", s)
+	}
+	if n.BitLen() <= 32 {
+		var b [4]byte
+		return netip.AddrFrom4([4]byte(n.FillBytes(b[:]))), nil
+	}
+	var b [16]byte
+	return netip.AddrFrom16([16]byte(n.FillBytes(b[:]))).Unmap(), nil
+}
+
+// Len returns how many ranges the database holds.
+func (db *Database) Len() int {
+	return len(db.ranges)
+}
+
+// Country returns the country code of ip, or an empty string.
+func (db *Database) Country(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ""
+	}
+	addr = addr.Unmap()
+	// The last range starting at or before addr is the only one that can
+	// hold it.
+	i := sort.Search(len(db.ranges), func(i int) bool {
+		return addr.Less(db.ranges[i].start)
+	}) - 1
+	if i < 0 || db.ranges[i].end.Less(addr) {
+		return ""
+	}
+	return db.ranges[i].country
+}
@@ -4,18 +4,18 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/json"
-	"fmt"
-	"log"
+	"log/slog"
 	"math/big"
 	"net/http"
-	"net/url"
-	"os"
 	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/google/uuid"
+	"github.com/itsbaivab/url-shortener/internal/adapters/problem"
+	appconfig "github.com/itsbaivab/url-shortener/internal/config"
 	"github.com/itsbaivab/url-shortener/internal/core/domain"
 	"github.com/itsbaivab/url-shortener/internal/core/services"
+	"github.com/itsbaivab/url-shortener/internal/logging"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -28,61 +28,40 @@ type RequestBody struct {
 type GenerateLinkFunctionHandler struct {
 	linkService  *services.LinkService
 	statsService *services.StatsService
+	destinations *services.DestinationValidator
 }
 
-func NewGenerateLinkFunctionHandler(l *services.LinkService, s *services.StatsService) *GenerateLinkFunctionHandler {
-	return &GenerateLinkFunctionHandler{linkService: l, statsService: s}
+func NewGenerateLinkFunctionHandler(l *services.LinkService, s *services.StatsService, d *services.DestinationValidator) *GenerateLinkFunctionHandler {
+	return &GenerateLinkFunctionHandler{linkService: l, statsService: s, destinations: d}
 }
 
 func (h *GenerateLinkFunctionHandler) CreateShortLink(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayProxyResponse, error) {
 	var requestBody RequestBody
 	err := json.Unmarshal([]byte(req.Body), &requestBody)
 	if err != nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: http.StatusBadRequest,
-			Body:       `{"error": "Invalid JSON"}`,
-		}, nil
+		return problem.Response(&domain.ValidationError{Field: "body", Reason: "Invalid JSON"}), nil
 	}
 
-	if requestBody.Long == "" {
-		return events.APIGatewayProxyResponse{
-			StatusCode: http.StatusBadRequest,
-			Body:       `{"error": "URL cannot be empty"}`,
-		}, nil
-	}
-	if len(requestBody.Long) < 15 {
-		return events.APIGatewayProxyResponse{
-			StatusCode: http.StatusBadRequest,
-			Body:       `{"error": "URL must be at least 15 characters long"}`,
-		}, nil
-	}
-	if !IsValidLink(requestBody.Long) {
-		return events.APIGatewayProxyResponse{
-			StatusCode: http.StatusBadRequest,
-			Body:       `{"error": "Invalid URL format"}`,
-		}, nil
+	long, err := h.destinations.Validate(ctx, requestBody.Long)
+	if err != nil {
+		return problem.Response(err), nil
 	}
 
 	link := domain.Link{
 		Id:          GenerateShortURLID(8),
-		OriginalURL: requestBody.Long,
+		OriginalURL: long,
 		CreatedAt:   time.Now(),
 	}
+	ctx = logging.With(ctx, "link_id", link.Id)
 
 	err = h.linkService.Create(ctx, link)
 	if err != nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: http.StatusInternalServerError,
-			Body:       `{"error": "Failed to create link"}`,
-		}, err
+		return problem.Response(err), nil
 	}
 
 	js, err := json.Marshal(link)
 	if err != nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: http.StatusInternalServerError,
-			Body:       `{"error": "Failed to marshal response"}`,
-		}, err
+		return problem.Response(err), nil
 	}
 
 	err = h.statsService.Create(ctx, domain.Stats{
@@ -92,7 +71,7 @@ func (h *GenerateLinkFunctionHandler) CreateShortLink(ctx context.Context, req e
 		CreatedAt: time.Now(),
 	})
 	if err != nil {
-		log.Println("failed to create stats: ", err)
+		slog.WarnContext(ctx, "failed to create stats", "error", err)
 	}
 
 	sendMessageToQueue(ctx, link)
@@ -106,15 +85,21 @@ func (h *GenerateLinkFunctionHandler) CreateShortLink(ctx context.Context, req e
 func sendMessageToQueue(ctx context.Context, link domain.Link) {
 	cfg, err := config.LoadDefaultConfig(ctx)
 	if err != nil {
-		log.Fatalf("unable to load SDK config, %v", err.Error())
+		slog.ErrorContext(ctx, "unable to load SDK config", "error", err)
+		return
+	}
+
+	appConfig, err := appconfig.Load()
+	if err != nil {
+		slog.ErrorContext(ctx, "unable to load config", "error", err)
 		return
 	}
 
 	sqsClient := sqs.NewFromConfig(cfg)
-	queueUrl := os.Getenv("QueueUrl")
+	queueUrl := appConfig.QueueURL
 
 	if queueUrl == "" {
-		log.Println("QueueUrl is not set")
+		slog.WarnContext(ctx, "queue URL is not set; skipping notification")
 		return
 	}
 
@@ -124,7 +109,7 @@ func sendMessageToQueue(ctx context.Context, link domain.Link) {
 	})
 
 	if err != nil {
-		fmt.Printf("Failed to send message to SQS, %v", err.Error())
+		slog.ErrorContext(ctx, "failed to send message to SQS", "error", err)
 	}
 }
 
@@ -137,8 +122,3 @@ func GenerateShortURLID(length int) string {
 	}
 	return string(result)
 }
-
-func IsValidLink(link string) bool {
-	_, err := url.Parse(link)
-	return err == nil && (len(link) > 7) && (link[:7] == "http://" || link[:8] == "https://")
-}
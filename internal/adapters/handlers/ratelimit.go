@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/itsbaivab/url-shortener/internal/adapters/problem"
+	"github.com/itsbaivab/url-shortener/internal/core/services"
+	"github.com/itsbaivab/url-shortener/internal/ratelimit"
+)
+
+// FunctionHandler is the signature of the API Gateway handlers.
+type FunctionHandler func(context.Context, events.APIGatewayV2HTTPRequest) (events.APIGatewayProxyResponse, error)
+
+// RateLimited limits next per client IP, answering with the same
+// RateLimit-* headers and 429 problems as the gin services.
+func RateLimited(limits *services.RateLimitService, next FunctionHandler) FunctionHandler {
+	return func(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayProxyResponse, error) {
+		decision, ok, err := limits.Check(ctx, req.RequestContext.HTTP.SourceIP)
+		var resp events.APIGatewayProxyResponse
+		if err != nil {
+			resp = problem.Response(err)
+		} else if resp, err = next(ctx, req); err != nil {
+			return resp, err
+		}
+
+		if ok {
+			if resp.Headers == nil {
+				resp.Headers = map[string]string{}
+			}
+			for name, value := range ratelimit.Headers(decision) {
+				resp.Headers[name] = value
+			}
+		}
+		return resp, nil
+	}
+}
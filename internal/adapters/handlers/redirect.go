@@ -2,49 +2,92 @@ package handlers
 
 import (
 	"context"
-	"log"
+	"errors"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/google/uuid"
+	"github.com/itsbaivab/url-shortener/internal/adapters/geoip"
+	"github.com/itsbaivab/url-shortener/internal/adapters/problem"
+	"github.com/itsbaivab/url-shortener/internal/adapters/redirectpage"
+	"github.com/itsbaivab/url-shortener/internal/adapters/takedown"
 	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/itsbaivab/url-shortener/internal/core/ports"
 	"github.com/itsbaivab/url-shortener/internal/core/services"
 )
 
 type RedirectFunctionHandler struct {
 	linkService  *services.LinkService
 	statsService *services.StatsService
+	geo          ports.GeoIP
 }
 
 func NewRedirectFunctionHandler(l *services.LinkService, s *services.StatsService) *RedirectFunctionHandler {
-	return &RedirectFunctionHandler{linkService: l, statsService: s}
+	return &RedirectFunctionHandler{linkService: l, statsService: s, geo: &geoip.Database{}}
+}
+
+// WithGeoIP locates visitors with g for the geo rules of links.
+func (h *RedirectFunctionHandler) WithGeoIP(g ports.GeoIP) *RedirectFunctionHandler {
+	h.geo = g
+	return h
+}
+
+// linkPath splits the path of a short URL, /<id> or /r/<id>, into the
+// link ID and the path after it, which links may forward. The suffix is
+// decoded like the gin services decode theirs, unless it is malformed.
+func linkPath(rawPath string) (id, suffix string) {
+	path := strings.TrimPrefix(rawPath, "/")
+	if rest, ok := strings.CutPrefix(path, "r/"); ok {
+		path = rest
+	}
+	id, suffix, _ = strings.Cut(path, "/")
+	if decoded, err := url.PathUnescape(suffix); err == nil {
+		suffix = decoded
+	}
+	return id, suffix
 }
 
 func (h *RedirectFunctionHandler) Redirect(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayProxyResponse, error) {
-	pathSegments := strings.Split(req.RawPath, "/")
-	if len(pathSegments) < 2 {
-		return events.APIGatewayProxyResponse{
-			StatusCode: http.StatusBadRequest,
-			Body:       `{"error": "Invalid URL path"}`,
-		}, nil
+	shortLinkKey, suffix := linkPath(req.RawPath)
+	if shortLinkKey == "" {
+		return problem.Response(&domain.ValidationError{Field: "path", Reason: "Invalid URL path"}), nil
 	}
 
-	shortLinkKey := pathSegments[len(pathSegments)-1]
-	longLink, err := h.linkService.GetOriginalURL(ctx, shortLinkKey)
-	if err != nil || *longLink == "" {
-		return events.APIGatewayProxyResponse{
-			StatusCode: http.StatusNotFound,
-			Body:       `{"error": "Link not found"}`,
-		}, nil
+	signature := domain.ParseURLSignature(req.QueryStringParameters["exp"], req.QueryStringParameters["sig"])
+	target, err := h.linkService.Resolve(domain.WithURLSignature(ctx, signature), shortLinkKey, nil)
+	var disabled *domain.DisabledError
+	if errors.As(err, &disabled) && takedown.Accepts(req.Headers["accept"]) {
+		return takedown.Response(disabled), nil
+	}
+	if err != nil {
+		return problem.Response(err), nil
+	}
+	if target.URL == "" {
+		return problem.New(http.StatusNotFound, "Link not found").Response(), nil
+	}
+	// Without cookies, visitors keep their variant through the hash of
+	// their IP and user agent.
+	ip := req.RequestContext.HTTP.SourceIP
+	visitor := domain.NewVisitor(shortLinkKey, ip, h.geo.Country(ip), req.Headers["user-agent"], req.Headers["referer"])
+	choice := target.Choose(visitor)
+	query, _ := url.ParseQuery(req.RawQueryString)
+	location, err := choice.Target.Location(suffix, query, visitor.Platform)
+	if err != nil {
+		return problem.Response(err), nil
 	}
 
 	if err := h.statsService.Create(ctx, domain.Stats{
-		Id:        uuid.NewString(),
-		LinkID:    shortLinkKey,
-		CreatedAt: time.Now(),
-		Platform:  domain.PlatformTwitter, // * TODO: Get platform from request
+		Id:         uuid.NewString(),
+		LinkID:     shortLinkKey,
+		CreatedAt:  time.Now(),
+		Platform:   visitor.Platform,
+		CampaignID: target.CampaignID,
+		Variant:    choice.Variant,
+		Rule:       choice.Rule,
 	}); err != nil {
 		// don't fail the redirect when stats service is down; log and continue
 		// this decouples the critical redirect path from analytics availability
@@ -52,14 +95,17 @@ func (h *RedirectFunctionHandler) Redirect(ctx context.Context, req events.APIGa
 		// keep the redirect fast and reliable.
 		// Note: stats will be best-effort; failures are non-fatal.
 		// Log the error for observability.
-		// Using standard log to keep parity with other handlers.
-		log.Printf("failed to record stats for link '%s': %v", shortLinkKey, err)
+		slog.WarnContext(ctx, "failed to record stats", "link_id", shortLinkKey, "error", err)
 	}
 
-	return events.APIGatewayProxyResponse{
-		StatusCode: http.StatusMovedPermanently,
-		Headers: map[string]string{
-			"Location": *longLink,
-		},
-	}, nil
+	// Signed URLs expire, so browsers must not cache their redirects.
+	response := redirectpage.Response(target.Type, location, signature.Signature == "")
+	if len(target.Rules) > 0 {
+		response.Headers["Vary"] = "User-Agent"
+	}
+	if _, set := response.Headers["Cache-Control"]; !set && len(target.GeoRules) > 0 {
+		// Shared caches cannot tell visitors from different countries apart.
+		response.Headers["Cache-Control"] = "private"
+	}
+	return response, nil
 }
@@ -4,7 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/itsbaivab/url-shortener/internal/config"
@@ -12,7 +12,10 @@ import (
 )
 
 func PostMessageToSlack(ctx context.Context, message string) error {
-	appConfig := config.NewConfig()
+	appConfig, err := config.Load()
+	if err != nil {
+		return err
+	}
 	slackToken, slackChannelID := appConfig.GetSlackParams()
 
 	api := slack.New(slackToken)
@@ -21,10 +24,10 @@ func PostMessageToSlack(ctx context.Context, message string) error {
 		slack.MsgOptionText(message, false),
 	)
 	if err != nil {
-		log.Printf("Error posting to Slack: %s", err)
+		slog.ErrorContext(ctx, "failed to post to Slack", "error", err)
 		return err
 	}
-	log.Printf("Message successfully sent to Slack channel %s at %s", channelID, timestamp)
+	slog.InfoContext(ctx, "message sent to Slack", "channel", channelID, "timestamp", timestamp)
 	return nil
 }
 
@@ -49,15 +52,15 @@ func SlackHandler(ctx context.Context, event json.RawMessage) error {
 		for _, message := range sqsEvent.Records {
 			err := PostMessageToSlack(ctx, message.Body)
 			if err != nil {
-				log.Printf("Error handling SQS message (ID: %s): %v", message.MessageId, err)
+				slog.ErrorContext(ctx, "failed to handle SQS message", "message_id", message.MessageId, "error", err)
 			}
 		}
 		return nil
 	}
 
 	var apiEvent events.APIGatewayV2HTTPRequest
-	log.Print("apiEvent: ", apiEvent)
 	if err := json.Unmarshal(event, &apiEvent); err == nil && apiEvent.RequestContext.HTTP.Method != "" {
+		slog.DebugContext(ctx, "handling API Gateway event", "request_id", apiEvent.RequestContext.RequestID)
 		_, err := HandleAPIGatewayRequest(ctx, apiEvent)
 		return err
 	}
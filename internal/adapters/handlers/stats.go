@@ -3,9 +3,10 @@ package handlers
 import (
 	"context"
 	"encoding/json"
-	"log"
+	"log/slog"
 
 	"github.com/aws/aws-lambda-go/events"
+	"github.com/itsbaivab/url-shortener/internal/adapters/problem"
 	"github.com/itsbaivab/url-shortener/internal/core/services"
 )
 
@@ -21,16 +22,13 @@ func NewStatsFunctionHandler(l *services.LinkService, s *services.StatsService)
 func (s *StatsFunctionHandler) Stats(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayProxyResponse, error) {
 	links, err := s.linkService.GetAll(ctx)
 	if err != nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 500,
-			Body:       `{"error": "Failed to get links"}`,
-		}, err
+		return problem.Response(err), nil
 	}
 
 	for i, link := range links {
 		stats, err := s.statsService.GetStatsByLinkID(ctx, link.Id)
 		if err != nil {
-			log.Printf("Error getting stats for link '%s': %v", link.Id, err)
+			slog.WarnContext(ctx, "failed to get stats", "link_id", link.Id, "error", err)
 			continue
 		}
 		links[i].Stats = stats
@@ -38,10 +36,7 @@ func (s *StatsFunctionHandler) Stats(ctx context.Context, req events.APIGatewayV
 
 	jsonResponse, err := json.Marshal(links)
 	if err != nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 500,
-			Body:       `{"error": "Failed to marshal response"}`,
-		}, err
+		return problem.Response(err), nil
 	}
 
 	return events.APIGatewayProxyResponse{
@@ -1,7 +1,11 @@
 package rabbitmq
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
 )
 
 // RabbitMQ represents a RabbitMQ connection
@@ -17,13 +21,32 @@ func NewRabbitMQ(url string) *RabbitMQ {
 // Publish publishes a message to RabbitMQ
 func (r *RabbitMQ) Publish(message string) error {
 	// For now, just log the message since we don't have RabbitMQ setup
-	log.Printf("Would publish to RabbitMQ: %s", message)
+	slog.Debug("would publish to RabbitMQ", "message", message)
 	return nil
 }
 
 // Subscribe subscribes to messages from RabbitMQ
 func (r *RabbitMQ) Subscribe(callback func(string)) error {
 	// For now, just log that we would subscribe
-	log.Println("Would subscribe to RabbitMQ messages")
+	slog.Debug("would subscribe to RabbitMQ messages")
 	return nil
 }
+
+// Ping checks that the broker accepts TCP connections
+func (r *RabbitMQ) Ping(ctx context.Context) error {
+	u, err := url.Parse(r.URL)
+	if err != nil {
+		return fmt.Errorf("invalid RabbitMQ URL: %w", err)
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "5672")
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
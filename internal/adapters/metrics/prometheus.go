@@ -0,0 +1,78 @@
+// Package metrics exposes business and HTTP metrics to Prometheus.
+package metrics
+
+import (
+	"github.com/itsbaivab/url-shortener/internal/core/ports"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "urlshortener"
+
+// Prometheus implements ports.Metrics with Prometheus collectors.
+type Prometheus struct {
+	linksCreated     prometheus.Counter
+	redirects        *prometheus.CounterVec
+	cacheLookups     *prometheus.CounterVec
+	statsWriteErrors prometheus.Counter
+	clicks           *TopN
+}
+
+// NewPrometheus registers the business collectors with reg. Per-link
+// clicks are only exported for the topLinks most clicked links so the
+// link_id label stays bounded.
+func NewPrometheus(reg prometheus.Registerer, topLinks int) *Prometheus {
+	p := &Prometheus{
+		linksCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "links_created_total",
+			Help:      "Short links created.",
+		}),
+		redirects: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "redirects_total",
+			Help:      "Short link resolutions by result (hit, miss, not_found, expired).",
+		}, []string{"result"}),
+		cacheLookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_lookups_total",
+			Help:      "Link cache lookups by result (hit, miss). The hit ratio is hit / (hit + miss).",
+		}, []string{"result"}),
+		statsWriteErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "stats_write_failures_total",
+			Help:      "Click stats that could not be stored.",
+		}),
+		clicks: NewTopN(prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "link_clicks"),
+			"Clicks of the most clicked links since the process started.",
+			[]string{"link_id"}, nil,
+		), topLinks),
+	}
+
+	reg.MustRegister(p.linksCreated, p.redirects, p.cacheLookups, p.statsWriteErrors, p.clicks)
+	return p
+}
+
+func (p *Prometheus) LinkCreated() {
+	p.linksCreated.Inc()
+}
+
+func (p *Prometheus) Redirect(result ports.RedirectResult) {
+	p.redirects.WithLabelValues(string(result)).Inc()
+}
+
+func (p *Prometheus) LinkClicked(linkID string) {
+	p.clicks.Inc(linkID)
+}
+
+func (p *Prometheus) CacheLookup(hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	p.cacheLookups.WithLabelValues(result).Inc()
+}
+
+func (p *Prometheus) StatsWriteFailed() {
+	p.statsWriteErrors.Inc()
+}
@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TopN counts keys with the Space-Saving algorithm and exports only the n
+// largest counts. It tracks a fixed number of keys, so memory and label
+// cardinality stay bounded no matter how many distinct keys are seen.
+// Counts of keys that were evicted and seen again are overestimates.
+type TopN struct {
+	desc     *prometheus.Desc
+	n        int
+	capacity int
+
+	mu     sync.Mutex
+	counts map[string]uint64
+}
+
+// NewTopN returns a collector exporting the n most frequent keys as desc,
+// which must have exactly one variable label.
+func NewTopN(desc *prometheus.Desc, n int) *TopN {
+	return &TopN{
+		desc:     desc,
+		n:        n,
+		capacity: n * 10,
+		counts:   make(map[string]uint64),
+	}
+}
+
+// Inc counts one occurrence of key.
+func (t *TopN) Inc(key string) {
+	if t.n <= 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.counts[key]; ok || len(t.counts) < t.capacity {
+		t.counts[key]++
+		return
+	}
+
+	// Replace the smallest entry; the newcomer inherits its count.
+	var minKey string
+	var minCount uint64
+	for k, c := range t.counts {
+		if minKey == "" || c < minCount {
+			minKey, minCount = k, c
+		}
+	}
+	delete(t.counts, minKey)
+	t.counts[key] = minCount + 1
+}
+
+// Top returns the n largest keys and their counts, largest first.
+func (t *TopN) Top() []KeyCount {
+	t.mu.Lock()
+	top := make([]KeyCount, 0, len(t.counts))
+	for k, c := range t.counts {
+		top = append(top, KeyCount{Key: k, Count: c})
+	}
+	t.mu.Unlock()
+
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Count != top[j].Count {
+			return top[i].Count > top[j].Count
+		}
+		return top[i].Key < top[j].Key
+	})
+	if len(top) > t.n {
+		top = top[:t.n]
+	}
+	return top
+}
+
+// KeyCount is one entry of TopN.Top.
+type KeyCount struct {
+	Key   string
+	Count uint64
+}
+
+func (t *TopN) Describe(ch chan<- *prometheus.Desc) {
+	ch <- t.desc
+}
+
+func (t *TopN) Collect(ch chan<- prometheus.Metric) {
+	for _, kc := range t.Top() {
+		ch <- prometheus.MustNewConstMetric(t.desc, prometheus.GaugeValue, float64(kc.Count), kc.Key)
+	}
+}
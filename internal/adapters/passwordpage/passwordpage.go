@@ -0,0 +1,65 @@
+// Package passwordpage renders the form browsers get instead of a
+// redirect when a link is password protected. The form posts back to the
+// URL it was served on; API clients keep getting problem responses.
+package passwordpage
+
+import (
+	"bytes"
+	"html/template"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContentType is the media type of the page.
+const ContentType = "text/html; charset=utf-8"
+
+// Field is the name of the form field holding the password.
+const Field = "password"
+
+// Page is what the form shows. Message explains why a previous attempt
+// failed.
+type Page struct {
+	LinkID  string
+	Message string
+}
+
+var page = template.Must(template.New("password").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Password required</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 36rem; margin: 4rem auto; padding: 0 1rem; color: #222; }
+input, button { font: inherit; padding: 0.4rem 0.6rem; }
+.error { color: #b00020; }
+</style>
+</head>
+<body>
+<h1>Password required</h1>
+<p>The short link <code>{{.LinkID}}</code> is password protected. Enter its password to continue.</p>
+{{if .Message}}<p class="error" role="alert">{{.Message}}</p>{{end}}
+<form method="post">
+<input type="password" name="` + Field + `" aria-label="Password" autocomplete="current-password" required autofocus>
+<button type="submit">Continue</button>
+</form>
+</body>
+</html>
+`))
+
+// Render returns the page for p.
+func Render(p Page) []byte {
+	var buf bytes.Buffer
+	// The template only formats strings into a buffer and cannot fail.
+	_ = page.Execute(&buf, p)
+	return buf.Bytes()
+}
+
+// Abort writes the page for p with status and stops the gin handler
+// chain.
+func Abort(c *gin.Context, status int, p Page) {
+	c.Header("Cache-Control", "no-store")
+	c.Data(status, ContentType, Render(p))
+	c.Abort()
+}
@@ -0,0 +1,114 @@
+// Package problem maps domain errors to RFC 7807 problem details so the gin
+// services and the Lambda handlers answer failures the same way.
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/gin-gonic/gin"
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+)
+
+// ContentType is the media type of every problem response.
+const ContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details object.
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	Field  string `json:"field,omitempty"`
+	Code   string `json:"code,omitempty"`
+}
+
+type kind struct {
+	err    error
+	status int
+	typ    string
+}
+
+var kinds = []kind{
+	{domain.ErrValidation, http.StatusBadRequest, "/problems/validation"},
+	{domain.ErrUnauthorized, http.StatusUnauthorized, "/problems/unauthorized"},
+	{domain.ErrPasswordRequired, http.StatusUnauthorized, "/problems/password-required"},
+	{domain.ErrForbidden, http.StatusForbidden, "/problems/forbidden"},
+	{domain.ErrQuotaExceeded, http.StatusTooManyRequests, "/problems/quota-exceeded"},
+	{domain.ErrRateLimited, http.StatusTooManyRequests, "/problems/rate-limited"},
+	{domain.ErrNotFound, http.StatusNotFound, "/problems/not-found"},
+	{domain.ErrConflict, http.StatusConflict, "/problems/conflict"},
+	{domain.ErrExpired, http.StatusGone, "/problems/expired"},
+	{domain.ErrDisabled, http.StatusUnavailableForLegalReasons, "/problems/disabled"},
+	{domain.ErrUnavailable, http.StatusServiceUnavailable, "/problems/unavailable"},
+}
+
+// New builds a problem for status with a caller supplied detail.
+func New(status int, detail string) Problem {
+	return Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// FromError maps err to a problem using the domain sentinel it wraps.
+// Client errors carry the error text as detail; server errors do not, so
+// backend messages never leak to callers.
+func FromError(err error) Problem {
+	for _, k := range kinds {
+		if !errors.Is(err, k.err) {
+			continue
+		}
+		p := Problem{Type: k.typ, Title: http.StatusText(k.status), Status: k.status}
+		if k.status < http.StatusInternalServerError {
+			p.Detail = err.Error()
+		}
+		var validation *domain.ValidationError
+		if errors.As(err, &validation) {
+			p.Detail = validation.Reason
+			p.Field = validation.Field
+			p.Code = validation.Code
+		}
+		return p
+	}
+	return New(http.StatusInternalServerError, "")
+}
+
+// Abort writes err as a problem response and stops the gin handler chain.
+func Abort(c *gin.Context, err error) {
+	p := FromError(err)
+	if p.Status >= http.StatusInternalServerError {
+		slog.ErrorContext(c.Request.Context(), "request failed", "error", err)
+	}
+	AbortWith(c, p)
+}
+
+// AbortWith writes p and stops the gin handler chain.
+func AbortWith(c *gin.Context, p Problem) {
+	c.Header("Content-Type", ContentType)
+	c.AbortWithStatusJSON(p.Status, p)
+}
+
+// Response converts err into an API Gateway problem response.
+func Response(err error) events.APIGatewayProxyResponse {
+	p := FromError(err)
+	if p.Status >= http.StatusInternalServerError {
+		slog.Error("request failed", "error", err)
+	}
+	return p.Response()
+}
+
+// Response renders p as an API Gateway response.
+func (p Problem) Response() events.APIGatewayProxyResponse {
+	body, _ := json.Marshal(p)
+	return events.APIGatewayProxyResponse{
+		StatusCode: p.Status,
+		Headers:    map[string]string{"Content-Type": ContentType},
+		Body:       string(body),
+	}
+}
@@ -0,0 +1,78 @@
+// Package redirectpage sends visitors on to the destination of a link the
+// way the link chose: an HTTP redirect, or a page that redirects with a
+// meta refresh or JavaScript once it has loaded. Pages let the
+// destination see the short link as the referrer and let analytics
+// scripts on them run before the visitor leaves.
+package redirectpage
+
+import (
+	"bytes"
+	"html/template"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/gin-gonic/gin"
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+)
+
+// ContentType is the media type of the pages.
+const ContentType = "text/html; charset=utf-8"
+
+type page struct {
+	Location   string
+	JavaScript bool
+}
+
+var pageTemplate = template.Must(template.New("redirect").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+{{if not .JavaScript}}<meta http-equiv="refresh" content="0; url={{.Location}}">
+{{end}}<title>Redirecting</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 36rem; margin: 4rem auto; padding: 0 1rem; color: #222; }
+</style>
+</head>
+<body>
+<p>Redirecting to <a href="{{.Location}}">{{.Location}}</a>&hellip;</p>
+{{if .JavaScript}}<script>window.location.replace({{.Location}});</script>
+{{end}}</body>
+</html>
+`))
+
+// Render returns the page redirecting to location for t, which must be a
+// page type.
+func Render(t domain.RedirectType, location string) []byte {
+	var buf bytes.Buffer
+	// The template only formats strings into a buffer and cannot fail.
+	_ = pageTemplate.Execute(&buf, page{Location: location, JavaScript: t == domain.RedirectJavaScript})
+	return buf.Bytes()
+}
+
+// Redirect sends c to location the way t says. Responses of links that
+// are not cacheable are marked so browsers ask again every time.
+func Redirect(c *gin.Context, t domain.RedirectType, location string, cacheable bool) {
+	if !cacheable {
+		c.Header("Cache-Control", "no-store")
+	}
+	if t.Page() {
+		c.Data(t.Status(cacheable), ContentType, Render(t, location))
+		return
+	}
+	c.Redirect(t.Status(cacheable), location)
+}
+
+// Response is Redirect as an API Gateway response.
+func Response(t domain.RedirectType, location string, cacheable bool) events.APIGatewayProxyResponse {
+	headers := map[string]string{}
+	if !cacheable {
+		headers["Cache-Control"] = "no-store"
+	}
+	if t.Page() {
+		headers["Content-Type"] = ContentType
+		return events.APIGatewayProxyResponse{StatusCode: t.Status(cacheable), Headers: headers, Body: string(Render(t, location))}
+	}
+	headers["Location"] = location
+	return events.APIGatewayProxyResponse{StatusCode: t.Status(cacheable), Headers: headers}
+}
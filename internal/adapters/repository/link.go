@@ -1,19 +1,25 @@
 package repository
-package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"sort"
+	"strings"
+	"time"
 
-	"github.com/itsbaivab/url-shortener/internal/core/domain"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/itsbaivab/url-shortener/internal/logging"
 )
 
+// allLimit caps how many links All returns, newest first.
+const allLimit = 20
+
 type LinkRepository struct {
 	client    *dynamodb.Client
 	tableName string
@@ -22,7 +28,7 @@ type LinkRepository struct {
 func NewLinkRepository(ctx context.Context, tableName string) *LinkRepository {
 	cfg, err := config.LoadDefaultConfig(ctx)
 	if err != nil {
-		log.Fatalf("unable to load SDK config, %v", err)
+		logging.Fatal("unable to load SDK config", "error", err)
 	}
 
 	client := dynamodb.NewFromConfig(cfg)
@@ -32,47 +38,111 @@ func NewLinkRepository(ctx context.Context, tableName string) *LinkRepository {
 	}
 }
 
-func (d *LinkRepository) All(ctx context.Context) ([]domain.Link, error) {
-	var links []domain.Link
+func (d *LinkRepository) All(ctx context.Context, workspaceID string) ([]domain.Link, error) {
+	links, err := d.scan(ctx, workspaceID, "", nil)
+	if len(links) > allLimit {
+		links = links[:allLimit]
+	}
+	return links, err
+}
+
+func (d *LinkRepository) AllByOwner(ctx context.Context, workspaceID, ownerID string) ([]domain.Link, error) {
+	return d.scan(ctx, workspaceID, "owner_id = :owner", map[string]ddbtypes.AttributeValue{
+		":owner": &ddbtypes.AttributeValueMemberS{Value: ownerID},
+	})
+}
 
+func (d *LinkRepository) Count(ctx context.Context, workspaceID string) (int, error) {
+	filter, values := workspaceFilter(workspaceID)
 	input := &dynamodb.ScanInput{
-		TableName: &d.tableName,
-		Limit:     aws.Int32(20),
+		TableName:                 &d.tableName,
+		Select:                    ddbtypes.SelectCount,
+		FilterExpression:          aws.String(filter),
+		ExpressionAttributeValues: values,
 	}
 
-	result, err := d.client.Scan(ctx, input)
+	count := 0
+	paginator := dynamodb.NewScanPaginator(d.client, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("failed to count items in DynamoDB: %w: %w", domain.ErrUnavailable, err)
+		}
+		count += int(page.Count)
+	}
+	return count, nil
+}
 
-	if err != nil {
-		return links, fmt.Errorf("failed to get items from DynamoDB: %w", err)
+// scan returns the workspace's links matching filter, newest first. It
+// reads the whole table and sets no Limit: that applies before the
+// filter and would drop matches.
+func (d *LinkRepository) scan(ctx context.Context, workspaceID, filter string, values map[string]ddbtypes.AttributeValue) ([]domain.Link, error) {
+	var links []domain.Link
+
+	expr, wsValues := workspaceFilter(workspaceID)
+	if filter != "" {
+		expr = "(" + expr + ") AND " + filter
+	}
+	for k, v := range values {
+		wsValues[k] = v
 	}
 
-	err = attributevalue.UnmarshalListOfMaps(result.Items, &links)
-	if err != nil {
-		return links, fmt.Errorf("failed to unmarshal data from DynamoDB: %w", err)
+	input := &dynamodb.ScanInput{
+		TableName:                 &d.tableName,
+		FilterExpression:          aws.String(expr),
+		ExpressionAttributeValues: wsValues,
+	}
+
+	paginator := dynamodb.NewScanPaginator(d.client, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return links, fmt.Errorf("failed to get items from DynamoDB: %w: %w", domain.ErrUnavailable, err)
+		}
+
+		var items []domain.Link
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+			return links, fmt.Errorf("failed to unmarshal data from DynamoDB: %w", err)
+		}
+		for _, item := range items {
+			links = append(links, fromItem(item))
+		}
 	}
 
+	// Scan order is undefined, so every page is read before sorting; match
+	// the postgres adapter and return the newest links first.
+	sort.SliceStable(links, func(i, j int) bool {
+		return links[i].CreatedAt.After(links[j].CreatedAt)
+	})
+
 	return links, nil
 }
 
-func (d *LinkRepository) Get(ctx context.Context, id string) (domain.Link, error) {
+func (d *LinkRepository) Get(ctx context.Context, workspaceID, host, id string) (domain.Link, error) {
 	link := domain.Link{}
 
 	input := &dynamodb.GetItemInput{
 		TableName: &d.tableName,
 		Key: map[string]ddbtypes.AttributeValue{
-			"id": &ddbtypes.AttributeValueMemberS{Value: id},
+			"id": &ddbtypes.AttributeValueMemberS{Value: itemKey(workspaceID, host, id)},
 		},
 	}
 
 	result, err := d.client.GetItem(ctx, input)
 	if err != nil {
-		return link, fmt.Errorf("failed to get item from DynamoDB: %w", err)
+		return link, fmt.Errorf("failed to get item from DynamoDB: %w: %w", domain.ErrUnavailable, err)
+	}
+	if result.Item == nil {
+		return link, fmt.Errorf("link %q: %w", id, domain.ErrNotFound)
 	}
 
 	err = attributevalue.UnmarshalMap(result.Item, &link)
 	if err != nil {
 		return link, fmt.Errorf("failed to unmarshal data from DynamoDB: %w", err)
 	}
+	if link = fromItem(link); link.WorkspaceID != workspaceID || link.Domain != host {
+		return domain.Link{}, fmt.Errorf("link %q: %w", id, domain.ErrNotFound)
+	}
 
 	return link, nil
 }
@@ -82,31 +152,172 @@ func (d *LinkRepository) Create(ctx context.Context, link domain.Link) error {
 	if err != nil {
 		return fmt.Errorf("failed to marshal data: %w", err)
 	}
+	item["id"] = &ddbtypes.AttributeValueMemberS{Value: itemKey(link.WorkspaceID, link.Domain, link.Id)}
 
 	input := &dynamodb.PutItemInput{
-		TableName: &d.tableName,
-		Item:      item,
+		TableName:           &d.tableName,
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(id)"),
 	}
 
 	_, err = d.client.PutItem(ctx, input)
+	var conditionFailed *ddbtypes.ConditionalCheckFailedException
+	if errors.As(err, &conditionFailed) {
+		return fmt.Errorf("link %q: %w", link.Id, domain.ErrConflict)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to put item to DynamoDB: %w", err)
+		return fmt.Errorf("failed to put item to DynamoDB: %w: %w", domain.ErrUnavailable, err)
 	}
 
 	return nil
 }
 
-func (d *LinkRepository) Delete(ctx context.Context, id string) error {
+func (d *LinkRepository) Delete(ctx context.Context, workspaceID, host, id string) error {
 	input := &dynamodb.DeleteItemInput{
 		TableName: &d.tableName,
 		Key: map[string]ddbtypes.AttributeValue{
-			"id": &ddbtypes.AttributeValueMemberS{Value: id},
+			"id": &ddbtypes.AttributeValueMemberS{Value: itemKey(workspaceID, host, id)},
 		},
+		ConditionExpression: aws.String("attribute_exists(id)"),
 	}
 
 	_, err := d.client.DeleteItem(ctx, input)
+	var conditionFailed *ddbtypes.ConditionalCheckFailedException
+	if errors.As(err, &conditionFailed) {
+		return fmt.Errorf("link %q: %w", id, domain.ErrNotFound)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to delete item from DynamoDB: %w", err)
+		return fmt.Errorf("failed to delete item from DynamoDB: %w: %w", domain.ErrUnavailable, err)
 	}
 	return nil
 }
+
+// Scan pages through the table in its own order. A page may hold fewer
+// than limit links before the end is reached.
+func (d *LinkRepository) Scan(ctx context.Context, after domain.Link, limit int) ([]domain.Link, error) {
+	input := &dynamodb.ScanInput{
+		TableName: &d.tableName,
+		Limit:     aws.Int32(int32(limit)),
+	}
+	if after.Id != "" {
+		input.ExclusiveStartKey = map[string]ddbtypes.AttributeValue{
+			"id": &ddbtypes.AttributeValueMemberS{Value: itemKey(after.WorkspaceID, after.Domain, after.Id)},
+		}
+	}
+
+	result, err := d.client.Scan(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan DynamoDB: %w: %w", domain.ErrUnavailable, err)
+	}
+
+	var links []domain.Link
+	if err := attributevalue.UnmarshalListOfMaps(result.Items, &links); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal data from DynamoDB: %w", err)
+	}
+	for i := range links {
+		links[i] = fromItem(links[i])
+	}
+	return links, nil
+}
+
+func (d *LinkRepository) Disable(ctx context.Context, workspaceID, host, id, reason string, at time.Time) error {
+	disabledAt, err := attributevalue.Marshal(at)
+	if err != nil {
+		return fmt.Errorf("failed to marshal data: %w", err)
+	}
+
+	input := &dynamodb.UpdateItemInput{
+		TableName: &d.tableName,
+		Key: map[string]ddbtypes.AttributeValue{
+			"id": &ddbtypes.AttributeValueMemberS{Value: itemKey(workspaceID, host, id)},
+		},
+		UpdateExpression:    aws.String("SET disabled_at = :at, disabled_reason = :reason"),
+		ConditionExpression: aws.String("attribute_exists(id)"),
+		ExpressionAttributeValues: map[string]ddbtypes.AttributeValue{
+			":at":     disabledAt,
+			":reason": &ddbtypes.AttributeValueMemberS{Value: reason},
+		},
+	}
+
+	_, err = d.client.UpdateItem(ctx, input)
+	var conditionFailed *ddbtypes.ConditionalCheckFailedException
+	if errors.As(err, &conditionFailed) {
+		return fmt.Errorf("link %q: %w", id, domain.ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update item in DynamoDB: %w: %w", domain.ErrUnavailable, err)
+	}
+	return nil
+}
+
+func (d *LinkRepository) Enable(ctx context.Context, workspaceID, host, id string) error {
+	input := &dynamodb.UpdateItemInput{
+		TableName: &d.tableName,
+		Key: map[string]ddbtypes.AttributeValue{
+			"id": &ddbtypes.AttributeValueMemberS{Value: itemKey(workspaceID, host, id)},
+		},
+		UpdateExpression:    aws.String("REMOVE disabled_at, disabled_reason"),
+		ConditionExpression: aws.String("attribute_exists(id)"),
+	}
+
+	_, err := d.client.UpdateItem(ctx, input)
+	var conditionFailed *ddbtypes.ConditionalCheckFailedException
+	if errors.As(err, &conditionFailed) {
+		return fmt.Errorf("link %q: %w", id, domain.ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update item in DynamoDB: %w: %w", domain.ErrUnavailable, err)
+	}
+	return nil
+}
+
+// itemKey is the table key of a link. The table is keyed by id alone, so
+// links on a custom domain are stored as "<workspace>#<domain>#<id>" and
+// other links outside the default workspace as "<workspace>#<id>";
+// default workspace links on the shared domain keep their bare ID as
+// they did before workspaces existed.
+func itemKey(workspaceID, host, id string) string {
+	switch {
+	case host != "":
+		return workspaceID + "#" + host + "#" + id
+	case workspaceID == domain.DefaultWorkspaceID:
+		return id
+	default:
+		return workspaceID + "#" + id
+	}
+}
+
+// fromItem undoes itemKey and places items written before workspaces
+// existed in the default workspace.
+func fromItem(link domain.Link) domain.Link {
+	if link.WorkspaceID == "" {
+		link.WorkspaceID = domain.DefaultWorkspaceID
+	}
+	link.Id = strings.TrimPrefix(link.Id, itemKey(link.WorkspaceID, link.Domain, ""))
+	return link
+}
+
+// workspaceFilter matches items of workspaceID. Items without a
+// workspace_id predate workspaces and belong to the default one.
+func workspaceFilter(workspaceID string) (string, map[string]ddbtypes.AttributeValue) {
+	values := map[string]ddbtypes.AttributeValue{
+		":workspace": &ddbtypes.AttributeValueMemberS{Value: workspaceID},
+	}
+	if workspaceID == domain.DefaultWorkspaceID {
+		return "workspace_id = :workspace OR attribute_not_exists(workspace_id)", values
+	}
+	return "workspace_id = :workspace", values
+}
+
+// domainFilter matches items of custom domain host. Items on the shared
+// domain have no domain attribute. "domain" is a reserved word, so it is
+// referenced through a name placeholder.
+func domainFilter(host string) (string, map[string]ddbtypes.AttributeValue, map[string]string) {
+	names := map[string]string{"#domain": "domain"}
+	if host == "" {
+		return "attribute_not_exists(#domain)", nil, names
+	}
+	return "#domain = :domain", map[string]ddbtypes.AttributeValue{
+		":domain": &ddbtypes.AttributeValueMemberS{Value: host},
+	}, names
+}
@@ -0,0 +1,111 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/lib/pq"
+)
+
+type PostgresAPIKeyRepository struct {
+	db tracedDB
+}
+
+func NewPostgresAPIKeyRepository(db *sql.DB) *PostgresAPIKeyRepository {
+	return &PostgresAPIKeyRepository{db: tracedDB{db}}
+}
+
+func (r *PostgresAPIKeyRepository) All(ctx context.Context) ([]domain.APIKey, error) {
+	query := `SELECT id, workspace_id, owner_id, name, key_hash, scopes, created_at, revoked_at FROM api_keys ORDER BY created_at DESC`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, wrapErr("failed to query api keys", err)
+	}
+	defer rows.Close()
+
+	var keys []domain.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, wrapErr("row iteration error", err)
+	}
+
+	return keys, nil
+}
+
+func (r *PostgresAPIKeyRepository) Get(ctx context.Context, id string) (domain.APIKey, error) {
+	query := `SELECT id, workspace_id, owner_id, name, key_hash, scopes, created_at, revoked_at FROM api_keys WHERE id = $1`
+
+	key, err := scanAPIKey(r.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.APIKey{}, fmt.Errorf("api key %q: %w", id, domain.ErrNotFound)
+	}
+	if err != nil {
+		return domain.APIKey{}, wrapErr("failed to get api key", err)
+	}
+
+	return key, nil
+}
+
+func (r *PostgresAPIKeyRepository) Create(ctx context.Context, key domain.APIKey) error {
+	query := `INSERT INTO api_keys (id, workspace_id, owner_id, name, key_hash, scopes, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err := r.db.ExecContext(ctx, query, key.Id, key.WorkspaceID, key.OwnerID, key.Name, key.Hash, pq.Array(key.Scopes), key.CreatedAt)
+	if err != nil {
+		return wrapErr("failed to create api key", err)
+	}
+
+	return nil
+}
+
+// Revoke marks the key revoked. Revoking a revoked key keeps the original
+// revocation time.
+func (r *PostgresAPIKeyRepository) Revoke(ctx context.Context, id string, at time.Time) error {
+	query := `UPDATE api_keys SET revoked_at = COALESCE(revoked_at, $2) WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, id, at)
+	if err != nil {
+		return wrapErr("failed to revoke api key", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return wrapErr("failed to get rows affected", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("api key %q: %w", id, domain.ErrNotFound)
+	}
+
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAPIKey(row scanner) (domain.APIKey, error) {
+	var key domain.APIKey
+	var revokedAt sql.NullTime
+	err := row.Scan(&key.Id, &key.WorkspaceID, &key.OwnerID, &key.Name, &key.Hash, pq.Array(&key.Scopes), &key.CreatedAt, &revokedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return key, err
+	}
+	if err != nil {
+		return key, fmt.Errorf("failed to scan api key: %w", err)
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	return key, nil
+}
@@ -0,0 +1,159 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+)
+
+const selectAudit = `SELECT seq, action, actor, workspace_id, resource_id, note, request_id, source_ip, before_state, after_state, created_at, prev_hash, hash FROM audit_log`
+
+const insertAudit = `INSERT INTO audit_log (action, actor, workspace_id, resource_id, note, request_id, source_ip, before_state, after_state, created_at, prev_hash, hash) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING seq`
+
+type PostgresAuditRepository struct {
+	db tracedDB
+}
+
+func NewPostgresAuditRepository(db *sql.DB) *PostgresAuditRepository {
+	return &PostgresAuditRepository{db: tracedDB{db}}
+}
+
+// Append inserts entry. Chained appends lock the table against other
+// chained appends, but not readers, while they read the last hash and
+// insert, so two entries never claim the same predecessor.
+func (r *PostgresAuditRepository) Append(ctx context.Context, entry domain.AuditEntry, chain bool) (domain.AuditEntry, error) {
+	if !chain {
+		entry.PrevHash, entry.Hash = "", ""
+		err := r.db.QueryRowContext(ctx, insertAudit, auditArgs(entry)...).Scan(&entry.Seq)
+		if err != nil {
+			return domain.AuditEntry{}, wrapErr("failed to append audit entry", err)
+		}
+		return entry, nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return domain.AuditEntry{}, wrapErr("failed to begin transaction", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `LOCK TABLE audit_log IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return domain.AuditEntry{}, wrapErr("failed to lock audit log", err)
+	}
+	err = tx.QueryRowContext(ctx, `SELECT hash FROM audit_log WHERE hash <> '' ORDER BY seq DESC LIMIT 1`).Scan(&entry.PrevHash)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return domain.AuditEntry{}, wrapErr("failed to read last audit hash", err)
+	}
+	entry.Hash = entry.ComputeHash()
+
+	if err := tx.QueryRowContext(ctx, insertAudit, auditArgs(entry)...).Scan(&entry.Seq); err != nil {
+		return domain.AuditEntry{}, wrapErr("failed to append audit entry", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return domain.AuditEntry{}, wrapErr("failed to commit audit entry", err)
+	}
+	return entry, nil
+}
+
+func (r *PostgresAuditRepository) Find(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEntry, error) {
+	var conditions []string
+	var args []any
+	where := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.WorkspaceID != "" {
+		where("workspace_id = $%d", filter.WorkspaceID)
+	}
+	if filter.Actor != "" {
+		where("actor = $%d", filter.Actor)
+	}
+	if prefix, ok := strings.CutSuffix(filter.Action, "."); ok {
+		where("starts_with(action, $%d)", prefix+".")
+	} else if filter.Action != "" {
+		where("action = $%d", filter.Action)
+	}
+	if filter.ResourceID != "" {
+		where("resource_id = $%d", filter.ResourceID)
+	}
+	if !filter.Since.IsZero() {
+		where("created_at >= $%d", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		where("created_at < $%d", filter.Until)
+	}
+	if filter.Before > 0 {
+		where("seq < $%d", filter.Before)
+	}
+
+	query := selectAudit
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY seq DESC LIMIT $%d", len(args))
+	return r.query(ctx, query, args...)
+}
+
+func (r *PostgresAuditRepository) Scan(ctx context.Context, after int64, limit int) ([]domain.AuditEntry, error) {
+	return r.query(ctx, selectAudit+` WHERE seq > $1 ORDER BY seq LIMIT $2`, after, limit)
+}
+
+func (r *PostgresAuditRepository) query(ctx context.Context, query string, args ...any) ([]domain.AuditEntry, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, wrapErr("failed to query audit log", err)
+	}
+	defer rows.Close()
+
+	var entries []domain.AuditEntry
+	for rows.Next() {
+		entry, err := scanAuditEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, wrapErr("row iteration error", err)
+	}
+
+	return entries, nil
+}
+
+func auditArgs(entry domain.AuditEntry) []any {
+	return []any{entry.Action, entry.Actor, entry.WorkspaceID, entry.ResourceID, entry.Note, entry.RequestID, entry.SourceIP,
+		nullJSON(entry.Before), nullJSON(entry.After), entry.CreatedAt, entry.PrevHash, entry.Hash}
+}
+
+// nullJSON stores empty values as NULL.
+func nullJSON(v json.RawMessage) any {
+	if len(v) == 0 {
+		return nil
+	}
+	return string(v)
+}
+
+func scanAuditEntry(row scanner) (domain.AuditEntry, error) {
+	var entry domain.AuditEntry
+	var before, after sql.NullString
+	err := row.Scan(&entry.Seq, &entry.Action, &entry.Actor, &entry.WorkspaceID, &entry.ResourceID, &entry.Note,
+		&entry.RequestID, &entry.SourceIP, &before, &after, &entry.CreatedAt, &entry.PrevHash, &entry.Hash)
+	if err != nil {
+		return entry, fmt.Errorf("failed to scan audit entry: %w", err)
+	}
+	if before.Valid {
+		entry.Before = json.RawMessage(before.String)
+	}
+	if after.Valid {
+		entry.After = json.RawMessage(after.String)
+	}
+	return entry, nil
+}
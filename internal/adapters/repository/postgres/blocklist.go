@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+)
+
+type PostgresBlocklistRepository struct {
+	db tracedDB
+}
+
+func NewPostgresBlocklistRepository(db *sql.DB) *PostgresBlocklistRepository {
+	return &PostgresBlocklistRepository{db: tracedDB{db}}
+}
+
+func (r *PostgresBlocklistRepository) All(ctx context.Context) ([]domain.BlockRule, error) {
+	query := `SELECT id, kind, pattern, reason, created_at FROM blocklist_rules ORDER BY created_at, id`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, wrapErr("failed to query blocklist rules", err)
+	}
+	defer rows.Close()
+
+	var rules []domain.BlockRule
+	for rows.Next() {
+		var rule domain.BlockRule
+		if err := rows.Scan(&rule.Id, &rule.Kind, &rule.Pattern, &rule.Reason, &rule.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan blocklist rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, wrapErr("row iteration error", err)
+	}
+
+	return rules, nil
+}
+
+func (r *PostgresBlocklistRepository) Create(ctx context.Context, rule domain.BlockRule) error {
+	query := `INSERT INTO blocklist_rules (id, kind, pattern, reason, created_at) VALUES ($1, $2, $3, $4, $5)`
+
+	_, err := r.db.ExecContext(ctx, query, rule.Id, rule.Kind, rule.Pattern, rule.Reason, rule.CreatedAt)
+	if err != nil {
+		return wrapErr("failed to create blocklist rule", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresBlocklistRepository) Delete(ctx context.Context, id string) error {
+	query := `DELETE FROM blocklist_rules WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return wrapErr("failed to delete blocklist rule", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return wrapErr("failed to get rows affected", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("blocklist rule %q: %w", id, domain.ErrNotFound)
+	}
+
+	return nil
+}
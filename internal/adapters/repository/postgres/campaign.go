@@ -0,0 +1,133 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+)
+
+const selectCampaigns = `SELECT workspace_id, id, name, utm, created_at FROM campaigns`
+
+type PostgresCampaignRepository struct {
+	db tracedDB
+}
+
+func NewPostgresCampaignRepository(db *sql.DB) *PostgresCampaignRepository {
+	return &PostgresCampaignRepository{db: tracedDB{db}}
+}
+
+func (r *PostgresCampaignRepository) All(ctx context.Context, workspaceID string) ([]domain.Campaign, error) {
+	query := selectCampaigns + ` WHERE workspace_id = $1 ORDER BY created_at, id`
+	rows, err := r.db.QueryContext(ctx, query, workspaceID)
+	if err != nil {
+		return nil, wrapErr("failed to query campaigns", err)
+	}
+	defer rows.Close()
+
+	var campaigns []domain.Campaign
+	for rows.Next() {
+		campaign, err := scanCampaign(rows)
+		if err != nil {
+			return nil, err
+		}
+		campaigns = append(campaigns, campaign)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, wrapErr("row iteration error", err)
+	}
+
+	return campaigns, nil
+}
+
+func (r *PostgresCampaignRepository) Get(ctx context.Context, workspaceID, id string) (domain.Campaign, error) {
+	query := selectCampaigns + ` WHERE workspace_id = $1 AND id = $2`
+
+	campaign, err := scanCampaign(r.db.QueryRowContext(ctx, query, workspaceID, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Campaign{}, fmt.Errorf("campaign %q: %w", id, domain.ErrNotFound)
+	}
+	if err != nil {
+		return domain.Campaign{}, wrapErr("failed to get campaign", err)
+	}
+
+	return campaign, nil
+}
+
+func (r *PostgresCampaignRepository) Create(ctx context.Context, campaign domain.Campaign) error {
+	utm, err := json.Marshal(campaign.UTM)
+	if err != nil {
+		return fmt.Errorf("failed to encode UTM template: %w", err)
+	}
+	query := `INSERT INTO campaigns (workspace_id, id, name, utm, created_at) VALUES ($1, $2, $3, $4, $5)`
+
+	_, err = r.db.ExecContext(ctx, query, campaign.WorkspaceID, campaign.Id, campaign.Name, string(utm), campaign.CreatedAt)
+	if err != nil {
+		return wrapErr("failed to create campaign", err)
+	}
+
+	return nil
+}
+
+// Update replaces the campaign's name and UTM template.
+func (r *PostgresCampaignRepository) Update(ctx context.Context, campaign domain.Campaign) error {
+	utm, err := json.Marshal(campaign.UTM)
+	if err != nil {
+		return fmt.Errorf("failed to encode UTM template: %w", err)
+	}
+	query := `UPDATE campaigns SET name = $3, utm = $4 WHERE workspace_id = $1 AND id = $2`
+
+	result, err := r.db.ExecContext(ctx, query, campaign.WorkspaceID, campaign.Id, campaign.Name, string(utm))
+	if err != nil {
+		return wrapErr("failed to update campaign", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return wrapErr("failed to get rows affected", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("campaign %q: %w", campaign.Id, domain.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (r *PostgresCampaignRepository) Delete(ctx context.Context, workspaceID, id string) error {
+	query := `DELETE FROM campaigns WHERE workspace_id = $1 AND id = $2`
+
+	result, err := r.db.ExecContext(ctx, query, workspaceID, id)
+	if err != nil {
+		return wrapErr("failed to delete campaign", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return wrapErr("failed to get rows affected", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("campaign %q: %w", id, domain.ErrNotFound)
+	}
+
+	return nil
+}
+
+func scanCampaign(row scanner) (domain.Campaign, error) {
+	var campaign domain.Campaign
+	var utm []byte
+	err := row.Scan(&campaign.WorkspaceID, &campaign.Id, &campaign.Name, &utm, &campaign.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return campaign, err
+	}
+	if err != nil {
+		return campaign, fmt.Errorf("failed to scan campaign: %w", err)
+	}
+	if err := json.Unmarshal(utm, &campaign.UTM); err != nil {
+		return campaign, fmt.Errorf("failed to decode UTM template of campaign %q: %w", campaign.Id, err)
+	}
+	return campaign, nil
+}
@@ -0,0 +1,124 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+)
+
+const selectDomains = `SELECT name, workspace_id, token, created_at, verified_at FROM domains`
+
+type PostgresDomainRepository struct {
+	db tracedDB
+}
+
+func NewPostgresDomainRepository(db *sql.DB) *PostgresDomainRepository {
+	return &PostgresDomainRepository{db: tracedDB{db}}
+}
+
+func (r *PostgresDomainRepository) All(ctx context.Context, workspaceID string) ([]domain.CustomDomain, error) {
+	query := selectDomains + ` WHERE workspace_id = $1 ORDER BY created_at, name`
+	rows, err := r.db.QueryContext(ctx, query, workspaceID)
+	if err != nil {
+		return nil, wrapErr("failed to query domains", err)
+	}
+	defer rows.Close()
+
+	var domains []domain.CustomDomain
+	for rows.Next() {
+		d, err := scanDomain(rows)
+		if err != nil {
+			return nil, err
+		}
+		domains = append(domains, d)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, wrapErr("row iteration error", err)
+	}
+
+	return domains, nil
+}
+
+func (r *PostgresDomainRepository) Get(ctx context.Context, name string) (domain.CustomDomain, error) {
+	d, err := scanDomain(r.db.QueryRowContext(ctx, selectDomains+` WHERE name = $1`, name))
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.CustomDomain{}, fmt.Errorf("domain %q: %w", name, domain.ErrNotFound)
+	}
+	if err != nil {
+		return domain.CustomDomain{}, wrapErr("failed to get domain", err)
+	}
+
+	return d, nil
+}
+
+func (r *PostgresDomainRepository) Create(ctx context.Context, d domain.CustomDomain) error {
+	query := `INSERT INTO domains (name, workspace_id, token, created_at, verified_at) VALUES ($1, $2, $3, $4, $5)`
+
+	_, err := r.db.ExecContext(ctx, query, d.Name, d.WorkspaceID, d.Token, d.CreatedAt, d.VerifiedAt)
+	if err != nil {
+		return wrapErr("failed to create domain", err)
+	}
+
+	return nil
+}
+
+// Verify marks a domain verified at the given time. Domains verified
+// already keep their original time.
+func (r *PostgresDomainRepository) Verify(ctx context.Context, name string, at time.Time) error {
+	query := `UPDATE domains SET verified_at = COALESCE(verified_at, $2) WHERE name = $1`
+
+	result, err := r.db.ExecContext(ctx, query, name, at)
+	if err != nil {
+		return wrapErr("failed to verify domain", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return wrapErr("failed to get rows affected", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("domain %q: %w", name, domain.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (r *PostgresDomainRepository) Delete(ctx context.Context, workspaceID, name string) error {
+	query := `DELETE FROM domains WHERE workspace_id = $1 AND name = $2`
+
+	result, err := r.db.ExecContext(ctx, query, workspaceID, name)
+	if err != nil {
+		return wrapErr("failed to delete domain", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return wrapErr("failed to get rows affected", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("domain %q: %w", name, domain.ErrNotFound)
+	}
+
+	return nil
+}
+
+func scanDomain(row scanner) (domain.CustomDomain, error) {
+	var d domain.CustomDomain
+	var verifiedAt sql.NullTime
+	err := row.Scan(&d.Name, &d.WorkspaceID, &d.Token, &d.CreatedAt, &verifiedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return d, err
+	}
+	if err != nil {
+		return d, fmt.Errorf("failed to scan domain: %w", err)
+	}
+	if verifiedAt.Valid {
+		d.VerifiedAt = &verifiedAt.Time
+	}
+	return d, nil
+}
@@ -0,0 +1,30 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/lib/pq"
+)
+
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
+// wrapErr prefixes err with msg and attaches the domain error that matches
+// the driver failure. Anything that is not a constraint violation is
+// treated as the database being unavailable.
+func wrapErr(msg string, err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case uniqueViolation:
+			return fmt.Errorf("%s: %w: %w", msg, domain.ErrConflict, err)
+		case foreignKeyViolation:
+			return fmt.Errorf("%s: %w: %w", msg, domain.ErrNotFound, err)
+		}
+	}
+	return fmt.Errorf("%s: %w: %w", msg, domain.ErrUnavailable, err)
+}
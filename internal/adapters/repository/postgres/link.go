@@ -3,92 +3,210 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/itsbaivab/url-shortener/internal/core/domain"
 	_ "github.com/lib/pq"
 )
 
+const selectLinks = `SELECT id, original_url, created_at, owner_id, workspace_id, domain, disabled_at, disabled_reason, password_hash, require_signature, redirect_type, forward_query, forward_path, campaign_id, variants, rules, geo_rules FROM links`
+
 type PostgresLinkRepository struct {
-	db *sql.DB
+	db tracedDB
 }
 
 func NewPostgresLinkRepository(db *sql.DB) *PostgresLinkRepository {
-	return &PostgresLinkRepository{db: db}
+	return &PostgresLinkRepository{db: tracedDB{db}}
+}
+
+func (r *PostgresLinkRepository) All(ctx context.Context, workspaceID string) ([]domain.Link, error) {
+	query := selectLinks + ` WHERE workspace_id = $1 ORDER BY created_at DESC LIMIT 100`
+	return r.query(ctx, query, workspaceID)
+}
+
+func (r *PostgresLinkRepository) AllByOwner(ctx context.Context, workspaceID, ownerID string) ([]domain.Link, error) {
+	query := selectLinks + ` WHERE workspace_id = $1 AND owner_id = $2 ORDER BY created_at DESC LIMIT 100`
+	return r.query(ctx, query, workspaceID, ownerID)
 }
 
-func (r *PostgresLinkRepository) All(ctx context.Context) ([]domain.Link, error) {
-	query := `SELECT id, original_url, created_at FROM links ORDER BY created_at DESC LIMIT 100`
-	rows, err := r.db.QueryContext(ctx, query)
+func (r *PostgresLinkRepository) query(ctx context.Context, query string, args ...any) ([]domain.Link, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query links: %w", err)
+		return nil, wrapErr("failed to query links", err)
 	}
 	defer rows.Close()
 
 	var links []domain.Link
 	for rows.Next() {
-		var link domain.Link
-		err := rows.Scan(&link.Id, &link.OriginalURL, &link.CreatedAt)
+		link, err := scanLink(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan link: %w", err)
+			return nil, err
 		}
 		links = append(links, link)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("row iteration error: %w", err)
+		return nil, wrapErr("row iteration error", err)
 	}
 
 	return links, nil
 }
 
-func (r *PostgresLinkRepository) Get(ctx context.Context, id string) (domain.Link, error) {
-	var link domain.Link
-	query := `SELECT id, original_url, created_at FROM links WHERE id = $1`
+func (r *PostgresLinkRepository) Count(ctx context.Context, workspaceID string) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM links WHERE workspace_id = $1`
+
+	if err := r.db.QueryRowContext(ctx, query, workspaceID).Scan(&count); err != nil {
+		return 0, wrapErr("failed to count links", err)
+	}
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&link.Id,
-		&link.OriginalURL,
-		&link.CreatedAt,
-	)
+	return count, nil
+}
+
+func (r *PostgresLinkRepository) Get(ctx context.Context, workspaceID, host, id string) (domain.Link, error) {
+	query := selectLinks + ` WHERE workspace_id = $1 AND domain = $2 AND id = $3`
 
-	if err == sql.ErrNoRows {
-		return domain.Link{}, fmt.Errorf("link not found")
+	link, err := scanLink(r.db.QueryRowContext(ctx, query, workspaceID, host, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Link{}, fmt.Errorf("link %q: %w", id, domain.ErrNotFound)
 	}
 	if err != nil {
-		return domain.Link{}, fmt.Errorf("failed to get link: %w", err)
+		return domain.Link{}, wrapErr("failed to get link", err)
 	}
 
 	return link, nil
 }
 
 func (r *PostgresLinkRepository) Create(ctx context.Context, link domain.Link) error {
-	query := `INSERT INTO links (id, original_url, created_at) VALUES ($1, $2, $3)`
+	variants, err := encodeList(link.Variants)
+	if err != nil {
+		return fmt.Errorf("failed to encode variants: %w", err)
+	}
+	rules, err := encodeList(link.Rules)
+	if err != nil {
+		return fmt.Errorf("failed to encode routing rules: %w", err)
+	}
+	geoRules, err := encodeList(link.GeoRules)
+	if err != nil {
+		return fmt.Errorf("failed to encode geo rules: %w", err)
+	}
+	query := `INSERT INTO links (id, original_url, created_at, owner_id, workspace_id, domain, password_hash, require_signature, redirect_type, forward_query, forward_path, campaign_id, variants, rules, geo_rules) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`
 
-	_, err := r.db.ExecContext(ctx, query, link.Id, link.OriginalURL, link.CreatedAt)
+	_, err = r.db.ExecContext(ctx, query, link.Id, link.OriginalURL, link.CreatedAt, link.OwnerID, link.WorkspaceID, link.Domain, link.PasswordHash, link.RequireSignature, link.RedirectType, link.ForwardQuery, link.ForwardPath, link.CampaignID, variants, rules, geoRules)
 	if err != nil {
-		return fmt.Errorf("failed to create link: %w", err)
+		return wrapErr("failed to create link", err)
 	}
 
 	return nil
 }
 
-func (r *PostgresLinkRepository) Delete(ctx context.Context, id string) error {
-	query := `DELETE FROM links WHERE id = $1`
+// Scan pages through the links of every workspace in key order.
+func (r *PostgresLinkRepository) Scan(ctx context.Context, after domain.Link, limit int) ([]domain.Link, error) {
+	query := selectLinks + ` WHERE (workspace_id, domain, id) > ($1, $2, $3) ORDER BY workspace_id, domain, id LIMIT $4`
+	return r.query(ctx, query, after.WorkspaceID, after.Domain, after.Id, limit)
+}
+
+func (r *PostgresLinkRepository) Disable(ctx context.Context, workspaceID, host, id, reason string, at time.Time) error {
+	query := `UPDATE links SET disabled_at = $4, disabled_reason = $5 WHERE workspace_id = $1 AND domain = $2 AND id = $3`
 
-	result, err := r.db.ExecContext(ctx, query, id)
+	result, err := r.db.ExecContext(ctx, query, workspaceID, host, id, at, reason)
 	if err != nil {
-		return fmt.Errorf("failed to delete link: %w", err)
+		return wrapErr("failed to disable link", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return wrapErr("failed to get rows affected", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("link %q: %w", id, domain.ErrNotFound)
 	}
 
+	return nil
+}
+
+func (r *PostgresLinkRepository) Enable(ctx context.Context, workspaceID, host, id string) error {
+	query := `UPDATE links SET disabled_at = NULL, disabled_reason = '' WHERE workspace_id = $1 AND domain = $2 AND id = $3`
+
+	result, err := r.db.ExecContext(ctx, query, workspaceID, host, id)
+	if err != nil {
+		return wrapErr("failed to enable link", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return wrapErr("failed to get rows affected", err)
+	}
 	if rowsAffected == 0 {
-		return fmt.Errorf("link not found")
+		return fmt.Errorf("link %q: %w", id, domain.ErrNotFound)
 	}
 
 	return nil
 }
+
+func (r *PostgresLinkRepository) Delete(ctx context.Context, workspaceID, host, id string) error {
+	query := `DELETE FROM links WHERE workspace_id = $1 AND domain = $2 AND id = $3`
+
+	result, err := r.db.ExecContext(ctx, query, workspaceID, host, id)
+	if err != nil {
+		return wrapErr("failed to delete link", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return wrapErr("failed to get rows affected", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("link %q: %w", id, domain.ErrNotFound)
+	}
+
+	return nil
+}
+
+func scanLink(row scanner) (domain.Link, error) {
+	var link domain.Link
+	var disabledAt sql.NullTime
+	var variants, rules, geoRules []byte
+	err := row.Scan(&link.Id, &link.OriginalURL, &link.CreatedAt, &link.OwnerID, &link.WorkspaceID, &link.Domain, &disabledAt, &link.DisabledReason, &link.PasswordHash, &link.RequireSignature, &link.RedirectType, &link.ForwardQuery, &link.ForwardPath, &link.CampaignID, &variants, &rules, &geoRules)
+	if errors.Is(err, sql.ErrNoRows) {
+		return link, err
+	}
+	if err != nil {
+		return link, fmt.Errorf("failed to scan link: %w", err)
+	}
+	if disabledAt.Valid {
+		link.DisabledAt = &disabledAt.Time
+	}
+	if err := json.Unmarshal(variants, &link.Variants); err != nil {
+		return link, fmt.Errorf("failed to decode variants of link %q: %w", link.Id, err)
+	}
+	if len(link.Variants) == 0 {
+		link.Variants = nil
+	}
+	if err := json.Unmarshal(rules, &link.Rules); err != nil {
+		return link, fmt.Errorf("failed to decode routing rules of link %q: %w", link.Id, err)
+	}
+	if len(link.Rules) == 0 {
+		link.Rules = nil
+	}
+	if err := json.Unmarshal(geoRules, &link.GeoRules); err != nil {
+		return link, fmt.Errorf("failed to decode geo rules of link %q: %w", link.Id, err)
+	}
+	if len(link.GeoRules) == 0 {
+		link.GeoRules = nil
+	}
+	return link, nil
+}
+
+// encodeList encodes a list column as JSON, with an empty array for nil.
+func encodeList[T any](list []T) (string, error) {
+	if len(list) == 0 {
+		return "[]", nil
+	}
+	data, err := json.Marshal(list)
+	return string(data), err
+}
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS api_keys;
+DROP INDEX IF EXISTS idx_links_owner_id_created_at;
+ALTER TABLE links DROP COLUMN IF EXISTS owner_id;
@@ -0,0 +1,17 @@
+-- API keys and link ownership. Links created before this migration have
+-- no owner and are only visible to admin keys.
+
+ALTER TABLE links ADD COLUMN IF NOT EXISTS owner_id VARCHAR(64) NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_links_owner_id_created_at ON links(owner_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id VARCHAR(32) PRIMARY KEY,
+    owner_id VARCHAR(64) NOT NULL,
+    name TEXT NOT NULL DEFAULT '',
+    key_hash CHAR(64) NOT NULL,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_owner_id ON api_keys(owner_id);
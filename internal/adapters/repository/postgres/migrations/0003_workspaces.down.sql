@@ -0,0 +1,22 @@
+-- Fails if two workspaces use the same link ID; remove the duplicates
+-- first.
+
+DROP INDEX IF EXISTS idx_api_keys_workspace_id;
+DROP INDEX IF EXISTS idx_links_workspace_owner_created_at;
+DROP INDEX IF EXISTS idx_links_workspace_created_at;
+DROP INDEX IF EXISTS idx_stats_workspace_link_id;
+CREATE INDEX IF NOT EXISTS idx_links_owner_id_created_at ON links(owner_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_stats_link_id ON stats(link_id);
+
+ALTER TABLE stats DROP CONSTRAINT IF EXISTS stats_workspace_link_fkey;
+ALTER TABLE links DROP CONSTRAINT links_pkey;
+ALTER TABLE links ADD PRIMARY KEY (id);
+ALTER TABLE stats ADD CONSTRAINT stats_link_id_fkey
+    FOREIGN KEY (link_id) REFERENCES links(id) ON DELETE CASCADE;
+
+ALTER TABLE api_keys DROP COLUMN IF EXISTS workspace_id;
+ALTER TABLE stats DROP COLUMN IF EXISTS workspace_id;
+ALTER TABLE links DROP COLUMN IF EXISTS workspace_id;
+
+DROP TABLE IF EXISTS workspace_domains;
+DROP TABLE IF EXISTS workspaces;
@@ -0,0 +1,36 @@
+-- Workspaces (tenants). Existing links, stats and API keys move to the
+-- 'default' workspace, and link IDs become unique per workspace.
+
+CREATE TABLE IF NOT EXISTS workspaces (
+    id VARCHAR(64) PRIMARY KEY,
+    name TEXT NOT NULL,
+    max_links INTEGER NOT NULL DEFAULT 0,
+    short_id_length INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO workspaces (id, name) VALUES ('default', 'Default') ON CONFLICT (id) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS workspace_domains (
+    domain VARCHAR(253) PRIMARY KEY,
+    workspace_id VARCHAR(64) NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_workspace_domains_workspace_id ON workspace_domains(workspace_id);
+
+ALTER TABLE links ADD COLUMN IF NOT EXISTS workspace_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE stats ADD COLUMN IF NOT EXISTS workspace_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS workspace_id VARCHAR(64) NOT NULL DEFAULT 'default';
+
+ALTER TABLE stats DROP CONSTRAINT IF EXISTS stats_link_id_fkey;
+ALTER TABLE links DROP CONSTRAINT links_pkey;
+ALTER TABLE links ADD PRIMARY KEY (workspace_id, id);
+ALTER TABLE stats ADD CONSTRAINT stats_workspace_link_fkey
+    FOREIGN KEY (workspace_id, link_id) REFERENCES links(workspace_id, id) ON DELETE CASCADE;
+
+DROP INDEX IF EXISTS idx_stats_link_id;
+DROP INDEX IF EXISTS idx_links_owner_id_created_at;
+CREATE INDEX IF NOT EXISTS idx_stats_workspace_link_id ON stats(workspace_id, link_id);
+CREATE INDEX IF NOT EXISTS idx_links_workspace_created_at ON links(workspace_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_links_workspace_owner_created_at ON links(workspace_id, owner_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_api_keys_workspace_id ON api_keys(workspace_id);
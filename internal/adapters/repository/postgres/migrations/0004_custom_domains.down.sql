@@ -0,0 +1,22 @@
+-- Fails if a workspace uses the same link ID on two domains; remove the
+-- duplicates first. Pending domains are dropped, verified ones are kept.
+
+DROP INDEX IF EXISTS idx_stats_workspace_domain_link_id;
+CREATE INDEX IF NOT EXISTS idx_stats_workspace_link_id ON stats(workspace_id, link_id);
+
+ALTER TABLE stats DROP CONSTRAINT IF EXISTS stats_workspace_domain_link_fkey;
+ALTER TABLE links DROP CONSTRAINT links_pkey;
+ALTER TABLE links ADD PRIMARY KEY (workspace_id, id);
+ALTER TABLE stats ADD CONSTRAINT stats_workspace_link_fkey
+    FOREIGN KEY (workspace_id, link_id) REFERENCES links(workspace_id, id) ON DELETE CASCADE;
+
+ALTER TABLE stats DROP COLUMN IF EXISTS domain;
+ALTER TABLE links DROP COLUMN IF EXISTS domain;
+
+DELETE FROM domains WHERE verified_at IS NULL;
+ALTER INDEX IF EXISTS idx_domains_workspace_id RENAME TO idx_workspace_domains_workspace_id;
+ALTER TABLE domains DROP COLUMN IF EXISTS verified_at;
+ALTER TABLE domains DROP COLUMN IF EXISTS created_at;
+ALTER TABLE domains DROP COLUMN IF EXISTS token;
+ALTER TABLE domains RENAME COLUMN name TO domain;
+ALTER TABLE domains RENAME TO workspace_domains;
@@ -0,0 +1,24 @@
+-- Custom domains are registered per workspace and only route once the
+-- workspace proves control of them with a DNS TXT record. Domains that
+-- operators assigned to workspaces directly count as verified. Link IDs
+-- become unique per workspace and domain; '' is the shared domain.
+
+ALTER TABLE workspace_domains RENAME TO domains;
+ALTER TABLE domains RENAME COLUMN domain TO name;
+ALTER TABLE domains ADD COLUMN IF NOT EXISTS token VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE domains ADD COLUMN IF NOT EXISTS created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW();
+ALTER TABLE domains ADD COLUMN IF NOT EXISTS verified_at TIMESTAMP WITH TIME ZONE;
+UPDATE domains SET verified_at = created_at;
+ALTER INDEX IF EXISTS idx_workspace_domains_workspace_id RENAME TO idx_domains_workspace_id;
+
+ALTER TABLE links ADD COLUMN IF NOT EXISTS domain VARCHAR(253) NOT NULL DEFAULT '';
+ALTER TABLE stats ADD COLUMN IF NOT EXISTS domain VARCHAR(253) NOT NULL DEFAULT '';
+
+ALTER TABLE stats DROP CONSTRAINT IF EXISTS stats_workspace_link_fkey;
+ALTER TABLE links DROP CONSTRAINT links_pkey;
+ALTER TABLE links ADD PRIMARY KEY (workspace_id, domain, id);
+ALTER TABLE stats ADD CONSTRAINT stats_workspace_domain_link_fkey
+    FOREIGN KEY (workspace_id, domain, link_id) REFERENCES links(workspace_id, domain, id) ON DELETE CASCADE;
+
+DROP INDEX IF EXISTS idx_stats_workspace_link_id;
+CREATE INDEX IF NOT EXISTS idx_stats_workspace_domain_link_id ON stats(workspace_id, domain, link_id);
@@ -0,0 +1,6 @@
+-- Disabled links redirect again.
+
+ALTER TABLE links DROP COLUMN IF EXISTS disabled_reason;
+ALTER TABLE links DROP COLUMN IF EXISTS disabled_at;
+
+DROP TABLE IF EXISTS blocklist_rules;
@@ -0,0 +1,14 @@
+-- Destination blocklist rules managed through the API, and links taken
+-- down because their destination was flagged. Rules loaded from files are
+-- not stored.
+
+CREATE TABLE IF NOT EXISTS blocklist_rules (
+    id VARCHAR(32) PRIMARY KEY,
+    kind VARCHAR(16) NOT NULL,
+    pattern TEXT NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE links ADD COLUMN IF NOT EXISTS disabled_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE links ADD COLUMN IF NOT EXISTS disabled_reason TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,21 @@
+-- Abuse reports from the public and how reviewers resolved them. Reports
+-- outlive the links they are about, so there is no foreign key.
+
+CREATE TABLE IF NOT EXISTS abuse_reports (
+    id VARCHAR(32) PRIMARY KEY,
+    workspace_id VARCHAR(64) NOT NULL,
+    domain VARCHAR(253) NOT NULL DEFAULT '',
+    link_id VARCHAR(255) NOT NULL,
+    reason VARCHAR(32) NOT NULL,
+    details TEXT NOT NULL DEFAULT '',
+    reporter_email TEXT NOT NULL DEFAULT '',
+    reporter_ip VARCHAR(64) NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    status VARCHAR(16) NOT NULL DEFAULT 'open',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    resolution_note TEXT NOT NULL DEFAULT '',
+    resolved_by VARCHAR(255) NOT NULL DEFAULT '',
+    resolved_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_abuse_reports_status_created_at ON abuse_reports(status, created_at);
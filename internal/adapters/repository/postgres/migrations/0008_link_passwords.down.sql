@@ -0,0 +1,4 @@
+-- Protected links become public.
+
+ALTER TABLE stats DROP COLUMN IF EXISTS password_attempt;
+ALTER TABLE links DROP COLUMN IF EXISTS password_hash;
@@ -0,0 +1,5 @@
+-- Password protected links, and whether a redirect followed a password
+-- attempt. Only the bcrypt hash of a password is stored.
+
+ALTER TABLE links ADD COLUMN IF NOT EXISTS password_hash TEXT NOT NULL DEFAULT '';
+ALTER TABLE stats ADD COLUMN IF NOT EXISTS password_attempt VARCHAR(16) NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+-- Links that required a signature redirect anyone.
+
+ALTER TABLE links DROP COLUMN IF EXISTS require_signature;
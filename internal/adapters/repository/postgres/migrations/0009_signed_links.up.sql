@@ -0,0 +1,3 @@
+-- Links that only redirect through signed, expiring URLs.
+
+ALTER TABLE links ADD COLUMN IF NOT EXISTS require_signature BOOLEAN NOT NULL DEFAULT FALSE;
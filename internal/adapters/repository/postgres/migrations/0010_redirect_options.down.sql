@@ -0,0 +1,5 @@
+-- Every link redirects with a 301 again.
+
+ALTER TABLE links DROP COLUMN IF EXISTS forward_path;
+ALTER TABLE links DROP COLUMN IF EXISTS forward_query;
+ALTER TABLE links DROP COLUMN IF EXISTS redirect_type;
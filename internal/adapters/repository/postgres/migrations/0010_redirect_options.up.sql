@@ -0,0 +1,6 @@
+-- How links redirect, and whether they forward the query string and the
+-- path after the link ID. An empty redirect type is a 301.
+
+ALTER TABLE links ADD COLUMN IF NOT EXISTS redirect_type VARCHAR(16) NOT NULL DEFAULT '';
+ALTER TABLE links ADD COLUMN IF NOT EXISTS forward_query BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE links ADD COLUMN IF NOT EXISTS forward_path BOOLEAN NOT NULL DEFAULT FALSE;
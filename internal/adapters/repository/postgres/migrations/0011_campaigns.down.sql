@@ -0,0 +1,6 @@
+-- Links and stats no longer belong to campaigns.
+
+DROP INDEX IF EXISTS idx_stats_workspace_campaign_id;
+ALTER TABLE stats DROP COLUMN IF EXISTS campaign_id;
+ALTER TABLE links DROP COLUMN IF EXISTS campaign_id;
+DROP TABLE IF EXISTS campaigns;
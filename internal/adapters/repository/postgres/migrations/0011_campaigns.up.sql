@@ -0,0 +1,17 @@
+-- Campaigns with the UTM template merged into their links' destinations.
+-- Stats record the campaign of the link at the time of the click, so they
+-- can be counted per campaign even after links move.
+
+CREATE TABLE IF NOT EXISTS campaigns (
+    workspace_id VARCHAR(64) NOT NULL,
+    id VARCHAR(32) NOT NULL,
+    name TEXT NOT NULL,
+    utm JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (workspace_id, id)
+);
+
+ALTER TABLE links ADD COLUMN IF NOT EXISTS campaign_id VARCHAR(32) NOT NULL DEFAULT '';
+ALTER TABLE stats ADD COLUMN IF NOT EXISTS campaign_id VARCHAR(32) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_stats_workspace_campaign_id ON stats(workspace_id, campaign_id) WHERE campaign_id <> '';
@@ -0,0 +1,4 @@
+-- Links redirect to their original URL only again.
+
+ALTER TABLE stats DROP COLUMN IF EXISTS variant;
+ALTER TABLE links DROP COLUMN IF EXISTS variants;
@@ -0,0 +1,5 @@
+-- Weighted destinations of links split between visitors, and the variant
+-- each click was sent to.
+
+ALTER TABLE links ADD COLUMN IF NOT EXISTS variants JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE stats ADD COLUMN IF NOT EXISTS variant VARCHAR(32) NOT NULL DEFAULT '';
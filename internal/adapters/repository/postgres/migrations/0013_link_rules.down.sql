@@ -0,0 +1,4 @@
+-- Every device goes to the destination of the link again.
+
+ALTER TABLE stats DROP COLUMN IF EXISTS rule;
+ALTER TABLE links DROP COLUMN IF EXISTS rules;
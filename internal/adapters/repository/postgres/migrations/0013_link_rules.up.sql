@@ -0,0 +1,5 @@
+-- Routing rules sending visitors on some devices to an app or app store,
+-- and the rule each click was sent to.
+
+ALTER TABLE links ADD COLUMN IF NOT EXISTS rules JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE stats ADD COLUMN IF NOT EXISTS rule VARCHAR(16) NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+-- Every country goes to the destination of the link again.
+
+ALTER TABLE links DROP COLUMN IF EXISTS geo_rules;
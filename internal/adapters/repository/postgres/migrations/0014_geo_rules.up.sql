@@ -0,0 +1,3 @@
+-- Geo rules sending visitors from some countries to a regional site.
+
+ALTER TABLE links ADD COLUMN IF NOT EXISTS geo_rules JSONB NOT NULL DEFAULT '[]';
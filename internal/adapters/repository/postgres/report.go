@@ -0,0 +1,113 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+)
+
+const selectReports = `SELECT id, workspace_id, domain, link_id, reason, details, reporter_email, reporter_ip, user_agent, status, created_at, resolution_note, resolved_by, resolved_at FROM abuse_reports`
+
+type PostgresAbuseReportRepository struct {
+	db tracedDB
+}
+
+func NewPostgresAbuseReportRepository(db *sql.DB) *PostgresAbuseReportRepository {
+	return &PostgresAbuseReportRepository{db: tracedDB{db}}
+}
+
+func (r *PostgresAbuseReportRepository) Create(ctx context.Context, report domain.AbuseReport) error {
+	query := `INSERT INTO abuse_reports (id, workspace_id, domain, link_id, reason, details, reporter_email, reporter_ip, user_agent, status, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+
+	_, err := r.db.ExecContext(ctx, query, report.Id, report.WorkspaceID, report.Domain, report.LinkID, report.Reason, report.Details,
+		report.ReporterEmail, report.ReporterIP, report.UserAgent, report.Status, report.CreatedAt)
+	if err != nil {
+		return wrapErr("failed to create abuse report", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresAbuseReportRepository) Get(ctx context.Context, id string) (domain.AbuseReport, error) {
+	report, err := scanReport(r.db.QueryRowContext(ctx, selectReports+` WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.AbuseReport{}, fmt.Errorf("abuse report %q: %w", id, domain.ErrNotFound)
+	}
+	if err != nil {
+		return domain.AbuseReport{}, wrapErr("failed to get abuse report", err)
+	}
+
+	return report, nil
+}
+
+func (r *PostgresAbuseReportRepository) All(ctx context.Context, status string) ([]domain.AbuseReport, error) {
+	query := selectReports + ` WHERE $1::text = '' OR status = $1 ORDER BY created_at, id LIMIT 1000`
+	rows, err := r.db.QueryContext(ctx, query, status)
+	if err != nil {
+		return nil, wrapErr("failed to query abuse reports", err)
+	}
+	defer rows.Close()
+
+	var reports []domain.AbuseReport
+	for rows.Next() {
+		report, err := scanReport(rows)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, wrapErr("row iteration error", err)
+	}
+
+	return reports, nil
+}
+
+// Resolve closes an open report. The status check makes concurrent
+// reviews of the same report resolve it once.
+func (r *PostgresAbuseReportRepository) Resolve(ctx context.Context, id string, resolution domain.ReportResolution) error {
+	query := `UPDATE abuse_reports SET status = $2, resolution_note = $3, resolved_by = $4, resolved_at = $5 WHERE id = $1 AND status = $6`
+
+	result, err := r.db.ExecContext(ctx, query, id, resolution.Status, resolution.Note, resolution.ResolvedBy, resolution.ResolvedAt, domain.ReportOpen)
+	if err != nil {
+		return wrapErr("failed to resolve abuse report", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return wrapErr("failed to get rows affected", err)
+	}
+	if rowsAffected == 0 {
+		if _, err := r.Get(ctx, id); err != nil {
+			return err
+		}
+		return fmt.Errorf("abuse report %q is already resolved: %w", id, domain.ErrConflict)
+	}
+
+	return nil
+}
+
+func scanReport(row scanner) (domain.AbuseReport, error) {
+	var report domain.AbuseReport
+	var resolution domain.ReportResolution
+	var resolvedAt sql.NullTime
+	err := row.Scan(&report.Id, &report.WorkspaceID, &report.Domain, &report.LinkID, &report.Reason, &report.Details,
+		&report.ReporterEmail, &report.ReporterIP, &report.UserAgent, &report.Status, &report.CreatedAt,
+		&resolution.Note, &resolution.ResolvedBy, &resolvedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return report, err
+	}
+	if err != nil {
+		return report, fmt.Errorf("failed to scan abuse report: %w", err)
+	}
+	if resolvedAt.Valid {
+		resolution.Status = report.Status
+		resolution.ResolvedAt = resolvedAt.Time
+		report.Resolution = &resolution
+	}
+	return report, nil
+}
@@ -0,0 +1,13 @@
+-- Example data for local development. Only applied by `migrate seed`.
+
+INSERT INTO links (id, original_url) VALUES
+    ('testid1', 'https://example.com/link1'),
+    ('testid2', 'https://example.com/link2'),
+    ('testid3', 'https://example.com/link3')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO stats (id, link_id, platform) VALUES
+    ('stat1', 'testid1', 0),
+    ('stat2', 'testid2', 1),
+    ('stat3', 'testid3', 2)
+ON CONFLICT (id) DO NOTHING;
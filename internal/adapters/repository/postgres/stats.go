@@ -3,109 +3,139 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 
 	"github.com/itsbaivab/url-shortener/internal/core/domain"
 	_ "github.com/lib/pq"
 )
 
+const selectStats = `SELECT id, link_id, platform, created_at, workspace_id, domain, password_attempt, campaign_id, variant, rule FROM stats`
+
 type PostgresStatsRepository struct {
-	db *sql.DB
+	db tracedDB
 }
 
 func NewPostgresStatsRepository(db *sql.DB) *PostgresStatsRepository {
-	return &PostgresStatsRepository{db: db}
+	return &PostgresStatsRepository{db: tracedDB{db}}
 }
 
-func (r *PostgresStatsRepository) All(ctx context.Context) ([]domain.Stats, error) {
-	query := `SELECT id, link_id, platform, created_at FROM stats ORDER BY created_at DESC`
-	rows, err := r.db.QueryContext(ctx, query)
+func (r *PostgresStatsRepository) All(ctx context.Context, workspaceID string) ([]domain.Stats, error) {
+	query := selectStats + ` WHERE workspace_id = $1 ORDER BY created_at DESC`
+	rows, err := r.db.QueryContext(ctx, query, workspaceID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query stats: %w", err)
+		return nil, wrapErr("failed to query stats", err)
 	}
 	defer rows.Close()
 
 	var stats []domain.Stats
 	for rows.Next() {
-		var stat domain.Stats
-		err := rows.Scan(&stat.Id, &stat.LinkID, &stat.Platform, &stat.CreatedAt)
+		stat, err := scanStats(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan stat: %w", err)
+			return nil, err
 		}
 		stats = append(stats, stat)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("row iteration error: %w", err)
+		return nil, wrapErr("row iteration error", err)
 	}
 
 	return stats, nil
 }
 
-func (r *PostgresStatsRepository) Get(ctx context.Context, id string) (domain.Stats, error) {
-	var stat domain.Stats
-	query := `SELECT id, link_id, platform, created_at FROM stats WHERE id = $1`
-
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&stat.Id,
-		&stat.LinkID,
-		&stat.Platform,
-		&stat.CreatedAt,
-	)
+func (r *PostgresStatsRepository) Get(ctx context.Context, workspaceID, id string) (domain.Stats, error) {
+	query := selectStats + ` WHERE workspace_id = $1 AND id = $2`
 
-	if err == sql.ErrNoRows {
-		return domain.Stats{}, fmt.Errorf("stats not found")
+	stat, err := scanStats(r.db.QueryRowContext(ctx, query, workspaceID, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Stats{}, fmt.Errorf("stats %q: %w", id, domain.ErrNotFound)
 	}
 	if err != nil {
-		return domain.Stats{}, fmt.Errorf("failed to get stats: %w", err)
+		return domain.Stats{}, wrapErr("failed to get stats", err)
 	}
 
 	return stat, nil
 }
 
 func (r *PostgresStatsRepository) Create(ctx context.Context, stats domain.Stats) error {
-	query := `INSERT INTO stats (id, link_id, platform, created_at) VALUES ($1, $2, $3, $4)`
+	query := `INSERT INTO stats (id, link_id, platform, created_at, workspace_id, domain, password_attempt, campaign_id, variant, rule) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
 
-	_, err := r.db.ExecContext(ctx, query, stats.Id, stats.LinkID, stats.Platform, stats.CreatedAt)
+	_, err := r.db.ExecContext(ctx, query, stats.Id, stats.LinkID, stats.Platform, stats.CreatedAt, stats.WorkspaceID, stats.Domain, stats.PasswordAttempt, stats.CampaignID, stats.Variant, stats.Rule)
 	if err != nil {
-		return fmt.Errorf("failed to create stats: %w", err)
+		return wrapErr("failed to create stats", err)
 	}
 
 	return nil
 }
 
-func (r *PostgresStatsRepository) Delete(ctx context.Context, linkID string) error {
-	query := `DELETE FROM stats WHERE link_id = $1`
+func (r *PostgresStatsRepository) Delete(ctx context.Context, workspaceID, host, linkID string) error {
+	query := `DELETE FROM stats WHERE workspace_id = $1 AND domain = $2 AND link_id = $3`
 
-	_, err := r.db.ExecContext(ctx, query, linkID)
+	_, err := r.db.ExecContext(ctx, query, workspaceID, host, linkID)
 	if err != nil {
-		return fmt.Errorf("failed to delete stats: %w", err)
+		return wrapErr("failed to delete stats", err)
 	}
 
 	return nil
 }
 
-func (r *PostgresStatsRepository) GetStatsByLinkID(ctx context.Context, linkID string) ([]domain.Stats, error) {
-	query := `SELECT id, link_id, platform, created_at FROM stats WHERE link_id = $1 ORDER BY created_at DESC`
-	rows, err := r.db.QueryContext(ctx, query, linkID)
+func (r *PostgresStatsRepository) GetStatsByLinkID(ctx context.Context, workspaceID, host, linkID string) ([]domain.Stats, error) {
+	query := selectStats + ` WHERE workspace_id = $1 AND domain = $2 AND link_id = $3 ORDER BY created_at DESC`
+	rows, err := r.db.QueryContext(ctx, query, workspaceID, host, linkID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query stats by link ID: %w", err)
+		return nil, wrapErr("failed to query stats by link ID", err)
 	}
 	defer rows.Close()
 
 	var stats []domain.Stats
 	for rows.Next() {
-		var stat domain.Stats
-		err := rows.Scan(&stat.Id, &stat.LinkID, &stat.Platform, &stat.CreatedAt)
+		stat, err := scanStats(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan stat: %w", err)
+			return nil, err
 		}
 		stats = append(stats, stat)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("row iteration error: %w", err)
+		return nil, wrapErr("row iteration error", err)
 	}
 
 	return stats, nil
 }
+
+func (r *PostgresStatsRepository) GetStatsByCampaign(ctx context.Context, workspaceID, campaignID string) ([]domain.Stats, error) {
+	query := selectStats + ` WHERE workspace_id = $1 AND campaign_id = $2 ORDER BY created_at DESC`
+	rows, err := r.db.QueryContext(ctx, query, workspaceID, campaignID)
+	if err != nil {
+		return nil, wrapErr("failed to query stats by campaign", err)
+	}
+	defer rows.Close()
+
+	var stats []domain.Stats
+	for rows.Next() {
+		stat, err := scanStats(rows)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, stat)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, wrapErr("row iteration error", err)
+	}
+
+	return stats, nil
+}
+
+func scanStats(row scanner) (domain.Stats, error) {
+	var stat domain.Stats
+	err := row.Scan(&stat.Id, &stat.LinkID, &stat.Platform, &stat.CreatedAt, &stat.WorkspaceID, &stat.Domain, &stat.PasswordAttempt, &stat.CampaignID, &stat.Variant, &stat.Rule)
+	if errors.Is(err, sql.ErrNoRows) {
+		return stat, err
+	}
+	if err != nil {
+		return stat, fmt.Errorf("failed to scan stat: %w", err)
+	}
+	return stat, nil
+}
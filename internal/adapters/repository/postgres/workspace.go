@@ -0,0 +1,104 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+)
+
+const selectWorkspaces = `SELECT id, name, max_links, short_id_length, created_at FROM workspaces`
+
+type PostgresWorkspaceRepository struct {
+	db tracedDB
+}
+
+func NewPostgresWorkspaceRepository(db *sql.DB) *PostgresWorkspaceRepository {
+	return &PostgresWorkspaceRepository{db: tracedDB{db}}
+}
+
+func (r *PostgresWorkspaceRepository) All(ctx context.Context) ([]domain.Workspace, error) {
+	query := selectWorkspaces + ` ORDER BY id`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, wrapErr("failed to query workspaces", err)
+	}
+	defer rows.Close()
+
+	var workspaces []domain.Workspace
+	for rows.Next() {
+		workspace, err := scanWorkspace(rows)
+		if err != nil {
+			return nil, err
+		}
+		workspaces = append(workspaces, workspace)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, wrapErr("row iteration error", err)
+	}
+
+	return workspaces, nil
+}
+
+func (r *PostgresWorkspaceRepository) Get(ctx context.Context, id string) (domain.Workspace, error) {
+	query := selectWorkspaces + ` WHERE id = $1`
+
+	workspace, err := scanWorkspace(r.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Workspace{}, fmt.Errorf("workspace %q: %w", id, domain.ErrNotFound)
+	}
+	if err != nil {
+		return domain.Workspace{}, wrapErr("failed to get workspace", err)
+	}
+
+	return workspace, nil
+}
+
+func (r *PostgresWorkspaceRepository) Create(ctx context.Context, workspace domain.Workspace) error {
+	query := `INSERT INTO workspaces (id, name, max_links, short_id_length, created_at) VALUES ($1, $2, $3, $4, $5)`
+
+	_, err := r.db.ExecContext(ctx, query, workspace.Id, workspace.Name,
+		workspace.Quotas.MaxLinks, workspace.Settings.ShortIDLength, workspace.CreatedAt)
+	if err != nil {
+		return wrapErr("failed to create workspace", err)
+	}
+
+	return nil
+}
+
+// Update replaces the workspace's name, quotas and settings.
+func (r *PostgresWorkspaceRepository) Update(ctx context.Context, workspace domain.Workspace) error {
+	query := `UPDATE workspaces SET name = $2, max_links = $3, short_id_length = $4 WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, workspace.Id, workspace.Name,
+		workspace.Quotas.MaxLinks, workspace.Settings.ShortIDLength)
+	if err != nil {
+		return wrapErr("failed to update workspace", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return wrapErr("failed to get rows affected", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("workspace %q: %w", workspace.Id, domain.ErrNotFound)
+	}
+
+	return nil
+}
+
+func scanWorkspace(row scanner) (domain.Workspace, error) {
+	var workspace domain.Workspace
+	err := row.Scan(&workspace.Id, &workspace.Name, &workspace.Quotas.MaxLinks,
+		&workspace.Settings.ShortIDLength, &workspace.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return workspace, err
+	}
+	if err != nil {
+		return workspace, fmt.Errorf("failed to scan workspace: %w", err)
+	}
+	return workspace, nil
+}
@@ -2,8 +2,9 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"sort"
 
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
@@ -11,6 +12,7 @@ import (
 	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/itsbaivab/url-shortener/internal/logging"
 )
 
 type StatsRepository struct {
@@ -21,7 +23,7 @@ type StatsRepository struct {
 func NewStatsRepository(ctx context.Context, tableName string) *StatsRepository {
 	cfg, err := config.LoadDefaultConfig(ctx)
 	if err != nil {
-		log.Fatalf("unable to load SDK config, %v", err)
+		logging.Fatal("unable to load SDK config", "error", err)
 	}
 
 	client := dynamodb.NewFromConfig(cfg)
@@ -31,7 +33,7 @@ func NewStatsRepository(ctx context.Context, tableName string) *StatsRepository
 	}
 }
 
-func (d *StatsRepository) Get(ctx context.Context, id string) (domain.Stats, error) {
+func (d *StatsRepository) Get(ctx context.Context, workspaceID, id string) (domain.Stats, error) {
 	input := &dynamodb.GetItemInput{
 		TableName: &d.tableName,
 		Key: map[string]ddbtypes.AttributeValue{
@@ -41,7 +43,10 @@ func (d *StatsRepository) Get(ctx context.Context, id string) (domain.Stats, err
 
 	result, err := d.client.GetItem(ctx, input)
 	if err != nil {
-		return domain.Stats{}, fmt.Errorf("failed to get item from DynamoDB: %w", err)
+		return domain.Stats{}, fmt.Errorf("failed to get item from DynamoDB: %w: %w", domain.ErrUnavailable, err)
+	}
+	if result.Item == nil {
+		return domain.Stats{}, fmt.Errorf("stats %q: %w", id, domain.ErrNotFound)
 	}
 
 	stats := domain.Stats{}
@@ -49,18 +54,24 @@ func (d *StatsRepository) Get(ctx context.Context, id string) (domain.Stats, err
 	if err != nil {
 		return domain.Stats{}, fmt.Errorf("failed to unmarshal data: %w", err)
 	}
+	if stats = statsFromItem(stats); stats.WorkspaceID != workspaceID {
+		return domain.Stats{}, fmt.Errorf("stats %q: %w", id, domain.ErrNotFound)
+	}
 
 	return stats, nil
 }
 
-func (d *StatsRepository) All(ctx context.Context) ([]domain.Stats, error) {
+func (d *StatsRepository) All(ctx context.Context, workspaceID string) ([]domain.Stats, error) {
+	filter, values := workspaceFilter(workspaceID)
 	input := &dynamodb.ScanInput{
-		TableName: &d.tableName,
+		TableName:                 &d.tableName,
+		FilterExpression:          aws.String(filter),
+		ExpressionAttributeValues: values,
 	}
 
 	result, err := d.client.Scan(ctx, input)
 	if err != nil {
-		return nil, fmt.Errorf("failed to scan table: %w", err)
+		return nil, fmt.Errorf("failed to scan table: %w: %w", domain.ErrUnavailable, err)
 	}
 
 	stats := []domain.Stats{}
@@ -69,7 +80,7 @@ func (d *StatsRepository) All(ctx context.Context) ([]domain.Stats, error) {
 		return nil, fmt.Errorf("failed to unmarshal data: %w", err)
 	}
 
-	return stats, nil
+	return newestFirst(stats), nil
 }
 
 func (d *StatsRepository) Create(ctx context.Context, stats domain.Stats) error {
@@ -79,45 +90,64 @@ func (d *StatsRepository) Create(ctx context.Context, stats domain.Stats) error
 	}
 
 	input := &dynamodb.PutItemInput{
-		TableName: &d.tableName,
-		Item:      item,
+		TableName:           &d.tableName,
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(id)"),
 	}
 
 	_, err = d.client.PutItem(ctx, input)
+	var conditionFailed *ddbtypes.ConditionalCheckFailedException
+	if errors.As(err, &conditionFailed) {
+		return fmt.Errorf("stats %q: %w", stats.Id, domain.ErrConflict)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to put item to DynamoDB: %w", err)
+		return fmt.Errorf("failed to put item to DynamoDB: %w: %w", domain.ErrUnavailable, err)
 	}
 
 	return nil
 }
 
-func (d *StatsRepository) Delete(ctx context.Context, id string) error {
-	input := &dynamodb.DeleteItemInput{
-		TableName: &d.tableName,
-		Key: map[string]ddbtypes.AttributeValue{
-			"id": &ddbtypes.AttributeValueMemberS{Value: id},
-		},
+// Delete removes every stats item recorded for linkID, matching the
+// postgres adapter where stats rows are keyed by link.
+func (d *StatsRepository) Delete(ctx context.Context, workspaceID, host, linkID string) error {
+	stats, err := d.GetStatsByLinkID(ctx, workspaceID, host, linkID)
+	if err != nil {
+		return err
 	}
 
-	_, err := d.client.DeleteItem(ctx, input)
-	if err != nil {
-		return fmt.Errorf("failed to delete item from DynamoDB: %w", err)
+	for _, stat := range stats {
+		input := &dynamodb.DeleteItemInput{
+			TableName: &d.tableName,
+			Key: map[string]ddbtypes.AttributeValue{
+				"id": &ddbtypes.AttributeValueMemberS{Value: stat.Id},
+			},
+		}
+
+		_, err := d.client.DeleteItem(ctx, input)
+		if err != nil {
+			return fmt.Errorf("failed to delete item from DynamoDB: %w: %w", domain.ErrUnavailable, err)
+		}
 	}
 	return nil
 }
 
-func (d *StatsRepository) GetStatsByLinkID(ctx context.Context, linkID string) ([]domain.Stats, error) {
+func (d *StatsRepository) GetStatsByLinkID(ctx context.Context, workspaceID, host, linkID string) ([]domain.Stats, error) {
+	filter, values := workspaceFilter(workspaceID)
+	hostFilter, hostValues, names := domainFilter(host)
+	for k, v := range hostValues {
+		values[k] = v
+	}
+	values[":linkID"] = &ddbtypes.AttributeValueMemberS{Value: linkID}
 	input := &dynamodb.ScanInput{
-		TableName: &d.tableName,
-		ExpressionAttributeValues: map[string]ddbtypes.AttributeValue{
-			":linkID": &ddbtypes.AttributeValueMemberS{Value: linkID},
-		},
-		FilterExpression: aws.String("link_id = :linkID"),
+		TableName:                 &d.tableName,
+		ExpressionAttributeValues: values,
+		ExpressionAttributeNames:  names,
+		FilterExpression:          aws.String("(" + filter + ") AND " + hostFilter + " AND link_id = :linkID"),
 	}
 
 	result, err := d.client.Scan(ctx, input)
 	if err != nil {
-		return nil, fmt.Errorf("failed to scan table: %w", err)
+		return nil, fmt.Errorf("failed to scan table: %w: %w", domain.ErrUnavailable, err)
 	}
 
 	stats := []domain.Stats{}
@@ -126,5 +156,47 @@ func (d *StatsRepository) GetStatsByLinkID(ctx context.Context, linkID string) (
 		return nil, fmt.Errorf("failed to unmarshal data: %w", err)
 	}
 
-	return stats, nil
+	return newestFirst(stats), nil
+}
+
+func (d *StatsRepository) GetStatsByCampaign(ctx context.Context, workspaceID, campaignID string) ([]domain.Stats, error) {
+	filter, values := workspaceFilter(workspaceID)
+	values[":campaignID"] = &ddbtypes.AttributeValueMemberS{Value: campaignID}
+	input := &dynamodb.ScanInput{
+		TableName:                 &d.tableName,
+		ExpressionAttributeValues: values,
+		FilterExpression:          aws.String("(" + filter + ") AND campaign_id = :campaignID"),
+	}
+
+	result, err := d.client.Scan(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan table: %w: %w", domain.ErrUnavailable, err)
+	}
+
+	stats := []domain.Stats{}
+	err = attributevalue.UnmarshalListOfMaps(result.Items, &stats)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal data: %w", err)
+	}
+
+	return newestFirst(stats), nil
+}
+
+// newestFirst sorts stats by creation time, newest first, and places
+// items written before workspaces existed in the default workspace.
+func newestFirst(stats []domain.Stats) []domain.Stats {
+	for i := range stats {
+		stats[i] = statsFromItem(stats[i])
+	}
+	sort.SliceStable(stats, func(i, j int) bool {
+		return stats[i].CreatedAt.After(stats[j].CreatedAt)
+	})
+	return stats
+}
+
+func statsFromItem(stats domain.Stats) domain.Stats {
+	if stats.WorkspaceID == "" {
+		stats.WorkspaceID = domain.DefaultWorkspaceID
+	}
+	return stats
 }
@@ -0,0 +1,76 @@
+// Package takedown renders the page browsers get instead of a redirect
+// when a link was disabled because its destination was flagged or
+// reported. API clients keep getting problem responses.
+package takedown
+
+import (
+	"bytes"
+	"html/template"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/gin-gonic/gin"
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+)
+
+// ContentType is the media type of the page.
+const ContentType = "text/html; charset=utf-8"
+
+var page = template.Must(template.New("takedown").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Link disabled</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 36rem; margin: 4rem auto; padding: 0 1rem; color: #222; }
+h1 { color: #b00020; }
+</style>
+</head>
+<body>
+<h1>This link has been disabled</h1>
+<p>The short link <code>{{.LinkID}}</code> was taken down because its destination was found to be unsafe or abusive.</p>
+<p>Reason: {{.Reason}}</p>
+<p>If you were expecting to be redirected, do not look for the destination elsewhere; it may try to steal your data or infect your device.</p>
+</body>
+</html>
+`))
+
+// Accepts reports whether a client sending the Accept header accept takes
+// HTML, as browsers do.
+func Accepts(accept string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err == nil && (mediaType == "text/html" || mediaType == "application/xhtml+xml") {
+			return true
+		}
+	}
+	return false
+}
+
+// Render returns the page for d.
+func Render(d *domain.DisabledError) []byte {
+	var buf bytes.Buffer
+	// The template only formats strings into a buffer and cannot fail.
+	_ = page.Execute(&buf, d)
+	return buf.Bytes()
+}
+
+// Abort writes the page for d and stops the gin handler chain.
+func Abort(c *gin.Context, d *domain.DisabledError) {
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusUnavailableForLegalReasons, ContentType, Render(d))
+	c.Abort()
+}
+
+// Response renders the page for d as an API Gateway response.
+func Response(d *domain.DisabledError) events.APIGatewayProxyResponse {
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusUnavailableForLegalReasons,
+		Headers:    map[string]string{"Content-Type": ContentType, "Cache-Control": "no-store"},
+		Body:       string(Render(d)),
+	}
+}
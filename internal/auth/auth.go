@@ -0,0 +1,126 @@
+// Package auth authenticates gin requests with API keys or JWT bearer
+// tokens, scopes them to the caller's workspace and enforces scopes.
+// Ownership checks live in the core services, which read the principal
+// that Middleware stores in the request context.
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/itsbaivab/url-shortener/internal/adapters/problem"
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/itsbaivab/url-shortener/internal/logging"
+)
+
+// Authenticator verifies one kind of credential.
+type Authenticator interface {
+	// Accepts reports whether token looks like a credential this
+	// authenticator handles.
+	Accepts(token string) bool
+	Authenticate(ctx context.Context, token string) (domain.Principal, error)
+}
+
+// APIKeyHeader is accepted as an alternative to a bearer token.
+const APIKeyHeader = "X-API-Key"
+
+// Middleware rejects requests without a credential accepted by one of
+// authenticators and stores the caller's principal and workspace in the
+// request context. Requests to a custom domain of another workspace are
+// forbidden.
+func Middleware(authenticators ...Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := credentials(c)
+		if token == "" {
+			abortUnauthorized(c, fmt.Errorf("an API key or bearer token is required: %w", domain.ErrUnauthorized))
+			return
+		}
+
+		var authenticator Authenticator
+		for _, a := range authenticators {
+			if a.Accepts(token) {
+				authenticator = a
+				break
+			}
+		}
+		if authenticator == nil {
+			abortUnauthorized(c, fmt.Errorf("unsupported credential: %w", domain.ErrUnauthorized))
+			return
+		}
+
+		principal, err := authenticator.Authenticate(c.Request.Context(), token)
+		if err != nil {
+			abortUnauthorized(c, err)
+			return
+		}
+
+		ctx := c.Request.Context()
+		if workspaceID, ok := domain.WorkspaceFrom(ctx); ok && workspaceID != principal.Workspace() {
+			problem.Abort(c, fmt.Errorf("credentials of workspace %q are not valid on this domain: %w", principal.Workspace(), domain.ErrForbidden))
+			return
+		}
+
+		ctx = domain.WithWorkspace(domain.WithPrincipal(ctx, principal), principal.Workspace())
+		c.Request = c.Request.WithContext(ctx)
+		logging.Annotate(c, "workspace_id", principal.Workspace(), "owner_id", principal.OwnerID)
+		if principal.KeyID != "" {
+			logging.Annotate(c, "api_key_id", principal.KeyID)
+		} else {
+			logging.Annotate(c, "subject", principal.Subject)
+		}
+		c.Next()
+	}
+}
+
+// RequireScope rejects authenticated callers that lack scope. It must run
+// after Middleware.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := domain.PrincipalFrom(c.Request.Context())
+		if !ok {
+			abortUnauthorized(c, fmt.Errorf("an API key or bearer token is required: %w", domain.ErrUnauthorized))
+			return
+		}
+		if !principal.HasScope(scope) {
+			problem.Abort(c, fmt.Errorf("this endpoint requires the %q scope: %w", scope, domain.ErrForbidden))
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireOperator rejects callers that are not operators: admins of the
+// default workspace. It guards settings of the whole process, which
+// workspace admins must not change. It must run after Middleware.
+func RequireOperator() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := domain.PrincipalFrom(c.Request.Context())
+		if !ok {
+			abortUnauthorized(c, fmt.Errorf("an API key or bearer token is required: %w", domain.ErrUnauthorized))
+			return
+		}
+		if !principal.IsOperator() {
+			problem.Abort(c, fmt.Errorf("this endpoint is for operators only: %w", domain.ErrForbidden))
+			return
+		}
+		c.Next()
+	}
+}
+
+func credentials(c *gin.Context) string {
+	if key := c.GetHeader(APIKeyHeader); key != "" {
+		return key
+	}
+	scheme, token, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+	if ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
+
+func abortUnauthorized(c *gin.Context, err error) {
+	c.Header("WWW-Authenticate", `Bearer realm="url-shortener"`)
+	problem.Abort(c, err)
+}
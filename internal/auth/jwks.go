@@ -0,0 +1,279 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// minRetry limits how often a token with an unknown key ID can trigger a
+// JWKS refresh, so forged kids cannot hammer the identity provider, and
+// how often a failed refresh is retried.
+const minRetry = 30 * time.Second
+
+// JWKS is a cached JSON Web Key Set loaded from a URL or a file. Keys are
+// refreshed every refresh interval and, to pick up rotated keys early,
+// when a token names a key ID that is not cached. The last good set is
+// kept when a refresh fails. Refreshes run outside the lock, one at a
+// time, and cached keys are served while one is in flight.
+type JWKS struct {
+	url     string
+	file    string
+	refresh time.Duration
+	client  *http.Client
+
+	mu          sync.Mutex
+	keys        map[string]crypto.PublicKey
+	fetchedAt   time.Time
+	attemptedAt time.Time
+	loading     *jwksLoad
+}
+
+// jwksLoad is a refresh in flight. done is closed once it has finished
+// with err.
+type jwksLoad struct {
+	done chan struct{}
+	err  error
+}
+
+// NewJWKS returns a key set read from url or, if url is empty, from file.
+func NewJWKS(url, file string, refresh time.Duration) *JWKS {
+	return &JWKS{
+		url:     url,
+		file:    file,
+		refresh: refresh,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Key returns the public key with ID kid. Only callers that need a key
+// that is not cached wait for a refresh.
+func (j *JWKS) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	j.mu.Lock()
+	key, ok := j.keys[kid]
+	load := j.loading
+	if j.due(time.Now(), !ok) {
+		load = j.start(ctx)
+	}
+	j.mu.Unlock()
+
+	if ok {
+		return key, nil
+	}
+	if load != nil {
+		select {
+		case <-load.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		j.mu.Lock()
+		key, ok = j.keys[kid]
+		j.mu.Unlock()
+		if ok {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("no key with id %q in the JWKS", kid)
+}
+
+// Refresh reloads the key set now, or waits for the refresh in flight.
+func (j *JWKS) Refresh(ctx context.Context) error {
+	j.mu.Lock()
+	load := j.start(ctx)
+	j.mu.Unlock()
+
+	select {
+	case <-load.done:
+		return load.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// due reports whether a refresh should start: when the keys are stale,
+// or missing a wanted key, and no attempt failed within minRetry. A
+// missing key only triggers a refresh once per minRetry even after
+// successful ones. due must be called with mu held.
+func (j *JWKS) due(now time.Time, missing bool) bool {
+	throttled := now.Sub(j.attemptedAt) <= minRetry
+	if j.keys == nil || now.Sub(j.fetchedAt) > j.refresh {
+		failed := j.attemptedAt.After(j.fetchedAt)
+		return !throttled || !failed
+	}
+	return missing && !throttled
+}
+
+// start starts a refresh unless one is in flight and returns it. The
+// fetch is shared by every caller waiting for it, so it is not cancelled
+// with ctx. start must be called with mu held.
+func (j *JWKS) start(ctx context.Context) *jwksLoad {
+	if j.loading != nil {
+		return j.loading
+	}
+	load := &jwksLoad{done: make(chan struct{})}
+	j.loading = load
+
+	go func() {
+		keys, err := j.fetch(context.WithoutCancel(ctx))
+
+		j.mu.Lock()
+		now := time.Now()
+		j.attemptedAt = now
+		if err == nil {
+			j.keys = keys
+			j.fetchedAt = now
+		}
+		cached := len(j.keys)
+		j.loading = nil
+		j.mu.Unlock()
+
+		if err != nil {
+			slog.WarnContext(ctx, "failed to load JWKS; keeping cached keys", "error", err, "cached_keys", cached)
+		}
+		load.err = err
+		close(load.done)
+	}()
+	return load
+}
+
+func (j *JWKS) fetch(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	data, err := j.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ParseJWKS(data)
+}
+
+func (j *JWKS) read(ctx context.Context) ([]byte, error) {
+	if j.url == "" {
+		return os.ReadFile(j.file)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("JWKS request returned %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// ParseJWKS decodes the signing keys of a JWKS document by key ID. RSA, EC
+// (P-256, P-384, P-521) and Ed25519 keys are supported; other keys are
+// skipped.
+func ParseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use == "enc" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("JWKS key %q: %w", k.Kid, err)
+		}
+		if key != nil {
+			keys[k.Kid] = key
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS has no usable signing keys")
+	}
+	return keys, nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, nil
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid base64url integer")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
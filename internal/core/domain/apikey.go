@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// Scopes gate what an authenticated caller may do.
+const (
+	// ScopeAdmin lets a caller see and manage every owner's links and issue
+	// or revoke API keys in its workspace. It implies every other scope.
+	ScopeAdmin = "admin"
+	// ScopeLinksRead allows listing the caller's links.
+	ScopeLinksRead = "links:read"
+	// ScopeLinksWrite allows creating and deleting the caller's links.
+	ScopeLinksWrite = "links:write"
+	// ScopeStatsRead allows reading stats of the caller's links.
+	ScopeStatsRead = "stats:read"
+)
+
+// Scopes lists every known scope.
+var Scopes = []string{ScopeAdmin, ScopeLinksRead, ScopeLinksWrite, ScopeStatsRead}
+
+// DefaultAPIKeyScopes are granted to every API key on top of the scopes it
+// was issued with.
+var DefaultAPIKeyScopes = []string{ScopeLinksRead, ScopeLinksWrite, ScopeStatsRead}
+
+// APIKey is an issued API key. Only a hash of the secret is stored; the
+// full key is shown once, when it is issued.
+type APIKey struct {
+	Id          string     `json:"id"`
+	WorkspaceID string     `json:"workspace_id"`
+	OwnerID     string     `json:"owner_id"`
+	Name        string     `json:"name"`
+	Hash        string     `json:"-"`
+	Scopes      []string   `json:"scopes"`
+	CreatedAt   time.Time  `json:"created_at"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Revoked reports whether the key can no longer be used.
+func (k APIKey) Revoked() bool {
+	return k.RevokedAt != nil
+}
+
+// Principal is the authenticated caller of a request. KeyID is set for
+// API keys and Subject for bearer tokens.
+type Principal struct {
+	WorkspaceID string
+	OwnerID     string
+	KeyID       string
+	Subject     string
+	Scopes      []string
+}
+
+// HasScope reports whether the principal holds scope, directly or through
+// the admin scope.
+func (p Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope) || slices.Contains(p.Scopes, ScopeAdmin)
+}
+
+// Workspace returns the workspace of the principal. Principals without
+// one, such as those of keys issued before workspaces, belong to the
+// default workspace.
+func (p Principal) Workspace() string {
+	if p.WorkspaceID == "" {
+		return DefaultWorkspaceID
+	}
+	return p.WorkspaceID
+}
+
+// IsOperator reports whether the principal administers the deployment
+// rather than a single workspace.
+func (p Principal) IsOperator() bool {
+	return p.Workspace() == DefaultWorkspaceID && p.HasScope(ScopeAdmin)
+}
+
+// CanAccess reports whether the principal may see or change a resource of
+// its workspace belonging to ownerID.
+func (p Principal) CanAccess(ownerID string) bool {
+	return p.HasScope(ScopeAdmin) || p.OwnerID == ownerID
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns ctx carrying the authenticated caller.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFrom returns the caller stored in ctx. Calls without a
+// principal come from trusted code, such as the Lambda handlers and
+// command line tools, and are not restricted.
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
@@ -0,0 +1,118 @@
+package domain
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// Actions recorded in the audit log, named "<resource>.<change>".
+const (
+	AuditLinkCreated         = "link.created"
+	AuditLinkDeleted         = "link.deleted"
+	AuditLinkDisabled        = "link.disabled"
+	AuditLinkRestored        = "link.restored"
+	AuditAPIKeyIssued        = "api_key.issued"
+	AuditAPIKeyRevoked       = "api_key.revoked"
+	AuditWorkspaceCreated    = "workspace.created"
+	AuditWorkspaceUpdated    = "workspace.updated"
+	AuditDomainAdded         = "domain.added"
+	AuditDomainVerified      = "domain.verified"
+	AuditDomainRemoved       = "domain.removed"
+	AuditBlockRuleAdded      = "block_rule.added"
+	AuditBlockRuleRemoved    = "block_rule.removed"
+	AuditAbuseReportResolved = "abuse_report.resolved"
+	AuditCampaignCreated     = "campaign.created"
+	AuditCampaignUpdated     = "campaign.updated"
+	AuditCampaignDeleted     = "campaign.deleted"
+)
+
+// Paging limits of audit log queries.
+const (
+	DefaultAuditPageSize = 100
+	MaxAuditPageSize     = 1000
+)
+
+// AuditEntry records one change: who made it, from where, and the
+// resource before and after, as JSON. Before is empty for creations and
+// After for deletions. Seq orders the log. Hash and PrevHash are set when
+// the hash chain is enabled.
+type AuditEntry struct {
+	Seq         int64           `json:"seq"`
+	Action      string          `json:"action"`
+	Actor       string          `json:"actor"`
+	WorkspaceID string          `json:"workspace_id"`
+	ResourceID  string          `json:"resource_id"`
+	Note        string          `json:"note,omitempty"`
+	RequestID   string          `json:"request_id,omitempty"`
+	SourceIP    string          `json:"source_ip,omitempty"`
+	Before      json.RawMessage `json:"before,omitempty"`
+	After       json.RawMessage `json:"after,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	PrevHash    string          `json:"prev_hash,omitempty"`
+	Hash        string          `json:"hash,omitempty"`
+}
+
+// ComputeHash returns the chain hash of the entry: a SHA-256 over its
+// content and PrevHash. Seq is left out, as it is assigned on insert.
+func (e AuditEntry) ComputeHash() string {
+	content, _ := json.Marshal([]any{
+		e.PrevHash, e.Action, e.Actor, e.WorkspaceID, e.ResourceID, e.Note, e.RequestID, e.SourceIP,
+		e.Before, e.After, e.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// AuditFilter selects audit entries. Empty fields match everything.
+// Action matches a whole action or, ending in ".", every action on a
+// resource. Before pages backwards: only entries with a lower Seq match.
+type AuditFilter struct {
+	WorkspaceID string
+	Actor       string
+	Action      string
+	ResourceID  string
+	Since       time.Time
+	Until       time.Time
+	Before      int64
+	Limit       int
+}
+
+// AuditVerification is the result of checking the hash chain. BrokenAt is
+// the Seq of the first entry that does not match, or 0 when the chain is
+// intact.
+type AuditVerification struct {
+	Checked  int   `json:"checked"`
+	Chained  int   `json:"chained"`
+	BrokenAt int64 `json:"broken_at,omitempty"`
+}
+
+// Intact reports whether every chained entry matched.
+func (v AuditVerification) Intact() bool {
+	return v.BrokenAt == 0
+}
+
+// Origin is where a request came from, recorded on audit entries.
+// SourceIP is the client IP: the peer address of the request, or the one
+// a trusted proxy forwarded. Behind a proxy that is not trusted it is the
+// proxy's address.
+type Origin struct {
+	RequestID string
+	SourceIP  string
+}
+
+type originKey struct{}
+
+// WithOrigin returns ctx carrying the origin of the request.
+func WithOrigin(ctx context.Context, o Origin) context.Context {
+	return context.WithValue(ctx, originKey{}, o)
+}
+
+// OriginOf returns the origin of the request of ctx. Calls outside a
+// request, such as periodic tasks, have none.
+func OriginOf(ctx context.Context) Origin {
+	o, _ := ctx.Value(originKey{}).(Origin)
+	return o
+}
@@ -0,0 +1,28 @@
+package domain
+
+import "time"
+
+// Kinds of BlockRule.
+const (
+	// BlockDomain matches a host and all of its subdomains.
+	BlockDomain = "domain"
+	// BlockPrefix matches URLs starting with the pattern.
+	BlockPrefix = "prefix"
+	// BlockRegex matches URLs the pattern, a Go regular expression, matches.
+	BlockRegex = "regex"
+)
+
+// BlockKinds lists every kind of BlockRule.
+var BlockKinds = []string{BlockDomain, BlockPrefix, BlockRegex}
+
+// BlockRule flags destinations known to serve spam, phishing or malware.
+// Rules are managed through the API or loaded from blocklist files; Source
+// names the file a rule came from and is empty for managed rules.
+type BlockRule struct {
+	Id        string    `json:"id"`
+	Kind      string    `json:"kind"`
+	Pattern   string    `json:"pattern"`
+	Reason    string    `json:"reason"`
+	Source    string    `json:"source,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
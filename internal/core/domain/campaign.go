@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"net/url"
+	"time"
+)
+
+// Campaign groups links of a workspace for marketing. The UTM template of
+// a campaign is merged into the destination of each of its links when
+// they redirect, and its clicks can be counted together.
+type Campaign struct {
+	Id          string      `json:"id"`
+	WorkspaceID string      `json:"workspace_id"`
+	Name        string      `json:"name"`
+	UTM         UTMTemplate `json:"utm"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+// UTM holds the utm_* query parameters that tell analytics tools where a
+// visit came from. Empty fields are left out.
+type UTM struct {
+	Source   string `json:"source,omitempty"`
+	Medium   string `json:"medium,omitempty"`
+	Campaign string `json:"campaign,omitempty"`
+	Term     string `json:"term,omitempty"`
+	Content  string `json:"content,omitempty"`
+}
+
+// Values returns the non-empty parameters of u.
+func (u UTM) Values() url.Values {
+	values := url.Values{}
+	for name, value := range map[string]string{
+		"utm_source":   u.Source,
+		"utm_medium":   u.Medium,
+		"utm_campaign": u.Campaign,
+		"utm_term":     u.Term,
+		"utm_content":  u.Content,
+	} {
+		if value != "" {
+			values.Set(name, value)
+		}
+	}
+	return values
+}
+
+// Override returns u with the non-empty fields of o.
+func (u UTM) Override(o UTM) UTM {
+	if o.Source != "" {
+		u.Source = o.Source
+	}
+	if o.Medium != "" {
+		u.Medium = o.Medium
+	}
+	if o.Campaign != "" {
+		u.Campaign = o.Campaign
+	}
+	if o.Term != "" {
+		u.Term = o.Term
+	}
+	if o.Content != "" {
+		u.Content = o.Content
+	}
+	return u
+}
+
+// UTMTemplate is the UTM of a campaign: Defaults for every visitor,
+// overridden field by field for visitors from a detected platform.
+// Platforms is keyed by lowercase platform name, such as "instagram".
+type UTMTemplate struct {
+	Defaults  UTM            `json:"defaults"`
+	Platforms map[string]UTM `json:"platforms,omitempty"`
+}
+
+// For returns the UTM for visitors from p.
+func (t UTMTemplate) For(p Platform) UTM {
+	if override, ok := t.Platforms[p.Name()]; ok {
+		return t.Defaults.Override(override)
+	}
+	return t.Defaults
+}
+
+// LinkClicks counts the clicks of one link.
+type LinkClicks struct {
+	LinkID string `json:"link_id"`
+	Domain string `json:"domain,omitempty"`
+	Clicks int    `json:"clicks"`
+}
+
+// CampaignStats adds up the clicks on the links of a campaign, per link
+// and per platform name. Rejected password attempts are not clicks.
+type CampaignStats struct {
+	CampaignID string         `json:"campaign_id"`
+	Clicks     int            `json:"clicks"`
+	Links      []LinkClicks   `json:"links"`
+	Platforms  map[string]int `json:"platforms"`
+}
@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Verification records are published under this label of the domain, so
+// they do not clash with records the domain already has.
+const (
+	verificationLabel  = "_url-shortener"
+	verificationPrefix = "url-shortener-verification="
+)
+
+// CustomDomain is a domain a workspace serves its links on, such as
+// go.acme.com. A workspace registers it, then proves it controls it by
+// publishing Token in a DNS TXT record; only verified domains are routed.
+type CustomDomain struct {
+	Name        string     `json:"name"`
+	WorkspaceID string     `json:"workspace_id"`
+	Token       string     `json:"token"`
+	CreatedAt   time.Time  `json:"created_at"`
+	VerifiedAt  *time.Time `json:"verified_at,omitempty"`
+}
+
+// Verified reports whether the workspace proved it controls the domain.
+func (d CustomDomain) Verified() bool {
+	return d.VerifiedAt != nil
+}
+
+// TXTName is the DNS name the verification record is published at.
+func (d CustomDomain) TXTName() string {
+	return verificationLabel + "." + d.Name
+}
+
+// TXTValue is the content of the verification record.
+func (d CustomDomain) TXTValue() string {
+	return verificationPrefix + d.Token
+}
+
+type linkDomainKey struct{}
+
+// WithLinkDomain returns ctx scoped to the links of custom domain name.
+// An empty name selects links on the shared domain.
+func WithLinkDomain(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, linkDomainKey{}, name)
+}
+
+// LinkDomainOf returns the custom domain of ctx, or "" for the shared
+// domain.
+func LinkDomainOf(ctx context.Context) string {
+	name, _ := ctx.Value(linkDomainKey{}).(string)
+	return name
+}
@@ -0,0 +1,18 @@
+package domain
+
+// Codes of the ValidationErrors returned for rejected link destinations.
+const (
+	CodeInvalidURL         = "invalid_url"
+	CodeURLTooLong         = "url_too_long"
+	CodeUnsupportedScheme  = "unsupported_scheme"
+	CodeCredentialsInURL   = "credentials_in_url"
+	CodeInvalidHost        = "invalid_host"
+	CodeUnresolvableHost   = "unresolvable_host"
+	CodeForbiddenAddress   = "forbidden_address"
+	CodeSelfReference      = "self_reference"
+	CodeBlockedDestination = "blocked_destination"
+)
+
+// DefaultMaxURLLength is the longest destination accepted unless
+// configured otherwise.
+const DefaultMaxURLLength = 2048
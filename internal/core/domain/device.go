@@ -0,0 +1,134 @@
+package domain
+
+import "strings"
+
+// OS is the operating system of a visitor, as named in routing rules.
+type OS string
+
+const (
+	OSUnknown  OS = ""
+	OSiOS      OS = "ios"
+	OSAndroid  OS = "android"
+	OSWindows  OS = "windows"
+	OSMacOS    OS = "macos"
+	OSLinux    OS = "linux"
+	OSChromeOS OS = "chromeos"
+)
+
+// Device is what the user agent of a visitor tells about their device.
+type Device struct {
+	OS     OS
+	Mobile bool
+}
+
+// ParseUserAgent returns the device of a user agent. Tablets count as
+// mobile.
+func ParseUserAgent(userAgent string) Device {
+	var d Device
+	switch {
+	case strings.Contains(userAgent, "iPhone"), strings.Contains(userAgent, "iPad"), strings.Contains(userAgent, "iPod"):
+		d.OS = OSiOS
+	case strings.Contains(userAgent, "Android"):
+		d.OS = OSAndroid
+	case strings.Contains(userAgent, "CrOS"):
+		d.OS = OSChromeOS
+	case strings.Contains(userAgent, "Windows"):
+		d.OS = OSWindows
+	case strings.Contains(userAgent, "Macintosh"), strings.Contains(userAgent, "Mac OS X"):
+		d.OS = OSMacOS
+	case strings.Contains(userAgent, "Linux"):
+		d.OS = OSLinux
+	}
+	d.Mobile = d.OS == OSiOS || d.OS == OSAndroid || strings.Contains(userAgent, "Mobile")
+	return d
+}
+
+// DeviceMatch is the devices a routing rule applies to: an OS, or every
+// mobile or desktop device.
+type DeviceMatch string
+
+const (
+	MatchIOS                  = DeviceMatch(OSiOS)
+	MatchAndroid              = DeviceMatch(OSAndroid)
+	MatchWindows              = DeviceMatch(OSWindows)
+	MatchMacOS                = DeviceMatch(OSMacOS)
+	MatchLinux                = DeviceMatch(OSLinux)
+	MatchChromeOS             = DeviceMatch(OSChromeOS)
+	MatchMobile   DeviceMatch = "mobile"
+	MatchDesktop  DeviceMatch = "desktop"
+)
+
+// DeviceMatches lists the valid device matches.
+var DeviceMatches = []DeviceMatch{MatchIOS, MatchAndroid, MatchWindows, MatchMacOS, MatchLinux, MatchChromeOS, MatchMobile, MatchDesktop}
+
+// Valid reports whether m is a known device match.
+func (m DeviceMatch) Valid() bool {
+	for _, known := range DeviceMatches {
+		if m == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches reports whether d is one of the devices of m. Desktops are
+// devices with a known desktop OS; bots and tools with neither count as
+// no device.
+func (m DeviceMatch) Matches(d Device) bool {
+	switch m {
+	case MatchMobile:
+		return d.Mobile
+	case MatchDesktop:
+		return !d.Mobile && d.OS != OSUnknown
+	default:
+		return d.OS != OSUnknown && DeviceMatch(d.OS) == m
+	}
+}
+
+// AppURLScheme is the scheme of Android intent URLs, which open an app,
+// or the Play Store when it is not installed. Only rules for Android may
+// use it.
+const AppURLScheme = "intent"
+
+// RoutingRule sends visitors on matching devices to URL instead of the
+// destination of the link: an App Store or universal link for iOS, an
+// intent or Play Store link for Android.
+type RoutingRule struct {
+	Device DeviceMatch `dynamodbav:"device" json:"device"`
+	URL    string      `dynamodbav:"url" json:"url"`
+}
+
+// AppURL reports whether the rule opens an app through an intent URL
+// rather than a web page.
+func (r RoutingRule) AppURL() bool {
+	return strings.HasPrefix(strings.ToLower(r.URL), AppURLScheme+":")
+}
+
+// RuleClicks counts the clicks sent to one routing rule of a link.
+type RuleClicks struct {
+	Device DeviceMatch `json:"device"`
+	URL    string      `json:"url"`
+	Clicks int         `json:"clicks"`
+}
+
+// RoutingStats counts the clicks of a link per routing rule, and those
+// that matched no rule and went to the destination of the link.
+type RoutingStats struct {
+	Rules    []RuleClicks `json:"rules"`
+	Fallback int          `json:"fallback"`
+}
+
+// Route returns t sent to the first of its rules matching d, and the
+// device match of that rule. Visitors matching no rule keep the
+// destination, and its variants, with an empty match.
+func (t Target) Route(d Device) (Target, DeviceMatch) {
+	rules := t.Rules
+	t.Rules = nil
+	for _, rule := range rules {
+		if rule.Device.Matches(d) {
+			t.URL, t.Variants = rule.URL, nil
+			return t, rule.Device
+		}
+	}
+	return t, ""
+}
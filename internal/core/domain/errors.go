@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Adapters wrap these sentinels so callers can branch on the failure with
+// errors.Is without knowing which backend produced it.
+var (
+	// ErrNotFound means the requested entity does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict means the entity already exists or changed concurrently.
+	ErrConflict = errors.New("conflict")
+	// ErrExpired means the entity existed but is no longer valid.
+	ErrExpired = errors.New("expired")
+	// ErrValidation means the caller supplied invalid input.
+	ErrValidation = errors.New("validation failed")
+	// ErrUnavailable means a backing store or dependency could not be reached.
+	ErrUnavailable = errors.New("unavailable")
+	// ErrUnauthorized means the caller did not present valid credentials.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrForbidden means the caller is known but lacks permission.
+	ErrForbidden = errors.New("forbidden")
+	// ErrQuotaExceeded means the request would go over a usage quota.
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	// ErrRateLimited means the caller sent too many requests too quickly.
+	ErrRateLimited = errors.New("rate limited")
+	// ErrDisabled means the entity exists but was taken down.
+	ErrDisabled = errors.New("disabled")
+	// ErrPasswordRequired means the entity is password protected and the
+	// caller did not unlock it.
+	ErrPasswordRequired = errors.New("password required")
+)
+
+// ValidationError describes a single invalid input field. It matches
+// ErrValidation under errors.Is. Code, when set, names the rule that
+// failed so clients can branch on it.
+type ValidationError struct {
+	Field  string
+	Reason string
+	Code   string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Reason
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// DisabledError reports a link that was taken down and why. It matches
+// ErrDisabled under errors.Is.
+type DisabledError struct {
+	LinkID string
+	Reason string
+}
+
+func (e *DisabledError) Error() string {
+	return fmt.Sprintf("link %q was disabled: %s", e.LinkID, e.Reason)
+}
+
+func (e *DisabledError) Is(target error) bool {
+	return target == ErrDisabled
+}
+
+// PasswordRequiredError reports a password protected link the caller has
+// not unlocked; Rejected is set when a password was given but was wrong.
+// It matches ErrPasswordRequired under errors.Is.
+type PasswordRequiredError struct {
+	LinkID   string
+	Rejected bool
+}
+
+func (e *PasswordRequiredError) Error() string {
+	if e.Rejected {
+		return fmt.Sprintf("incorrect password for link %q", e.LinkID)
+	}
+	return fmt.Sprintf("link %q is password protected", e.LinkID)
+}
+
+func (e *PasswordRequiredError) Is(target error) bool {
+	return target == ErrPasswordRequired
+}
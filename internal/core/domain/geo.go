@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"slices"
+	"strings"
+)
+
+// MaxGeoRules is how many geo rules a link may have.
+const MaxGeoRules = 50
+
+// GeoRule sends visitors from some countries to URL instead of the
+// destination of the link. Countries are ISO 3166-1 alpha-2 codes, such
+// as "DE"; Regions name groups of them, such as "europe".
+type GeoRule struct {
+	Countries []string `dynamodbav:"countries,omitempty" json:"countries,omitempty"`
+	Regions   []string `dynamodbav:"regions,omitempty" json:"regions,omitempty"`
+	URL       string   `dynamodbav:"url" json:"url"`
+}
+
+// Matches reports whether visitors from country follow the rule. Visitors
+// whose country is unknown follow no rule.
+func (r GeoRule) Matches(country string) bool {
+	if country == "" {
+		return false
+	}
+	if slices.Contains(r.Countries, country) {
+		return true
+	}
+	for _, region := range r.Regions {
+		if slices.Contains(Regions[region], country) {
+			return true
+		}
+	}
+	return false
+}
+
+// Regions are the groups of countries geo rules can name: the continents
+// and the European Union.
+var Regions = map[string][]string{
+	"africa":        strings.Fields("DZ AO BJ BW BF BI CV CM CF TD KM CG CD CI DJ EG GQ ER SZ ET GA GM GH GN GW KE LS LR LY MG MW ML MR MU YT MA MZ NA NE NG RE RW SH ST SN SC SL SO ZA SS SD TZ TG TN UG EH ZM ZW"),
+	"antarctica":    strings.Fields("AQ BV GS HM TF"),
+	"asia":          strings.Fields("AF AM AZ BH BD BT IO BN KH CN GE HK IN ID IR IQ IL JP JO KZ KW KG LA LB MO MY MV MN MM NP KP OM PK PS PH QA SA SG KR LK SY TW TJ TH TL TR TM AE UZ VN YE"),
+	"europe":        strings.Fields("AD AL AT AX BA BE BG BY CH CY CZ DE DK EE ES FI FO FR GB GG GI GR HR HU IE IM IS IT JE LI LT LU LV MC MD ME MK MT NL NO PL PT RO RS RU SE SI SJ SK SM UA VA XK"),
+	"north-america": strings.Fields("AG AI AW BB BL BM BQ BS BZ CA CR CU CW DM DO GD GL GP GT HN HT JM KN KY LC MF MQ MS MX NI PA PM PR SV SX TC TT US VC VG VI"),
+	"oceania":       strings.Fields("AS AU CK FJ FM GU KI MH MP NC NF NR NU NZ PF PG PN PW SB TK TO TV UM VU WF WS"),
+	"south-america": strings.Fields("AR BO BR CL CO EC FK GF GY PE PY SR UY VE"),
+	"eu":            strings.Fields("AT BE BG HR CY CZ DK EE FI FR DE GR HU IE IT LV LT LU MT NL PL PT RO SK SI ES SE"),
+}
+
+// Locate returns t sent to the first of its geo rules matching country,
+// and the index of that rule. Visitors matching no rule keep the
+// destination, and its variants, with an index of -1.
+func (t Target) Locate(country string) (Target, int) {
+	rules := t.GeoRules
+	t.GeoRules = nil
+	for i, rule := range rules {
+		if rule.Matches(country) {
+			t.URL, t.Variants = rule.URL, nil
+			return t, i
+		}
+	}
+	return t, -1
+}
@@ -1,10 +1,70 @@
 package domain
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
+// Link is a short link. IDs are unique per workspace and custom domain;
+// Domain is empty for links served on the shared domain. Links whose
+// destination was flagged are disabled rather than deleted, so their
+// owners can see why they stopped redirecting. Links with a PasswordHash
+// only redirect visitors who enter the password, and links with
+// RequireSignature only requests through a signed URL. RedirectType and
+// the Forward options decide how visitors are redirected; see Target.
+// Links of a campaign get its UTM parameters merged into their
+// destination. Links with Variants split their visitors between several
+// destinations instead of OriginalURL, Rules send visitors on some
+// devices to an app or app store, and GeoRules send visitors from some
+// countries to a regional site.
 type Link struct {
 	Id          string    `dynamodbav:"id" json:"id"`
 	OriginalURL string    `dynamodbav:"original_url" json:"original_url"`
 	CreatedAt   time.Time `dynamodbav:"created_at" json:"created_at"`
+	OwnerID     string    `dynamodbav:"owner_id,omitempty" json:"owner_id,omitempty"`
+	WorkspaceID string    `dynamodbav:"workspace_id,omitempty" json:"workspace_id,omitempty"`
+	Domain      string    `dynamodbav:"domain,omitempty" json:"domain,omitempty"`
 	Stats       []Stats   `dynamodbav:"-" json:"stats"`
+
+	DisabledAt     *time.Time `dynamodbav:"disabled_at,omitempty" json:"disabled_at,omitempty"`
+	DisabledReason string     `dynamodbav:"disabled_reason,omitempty" json:"disabled_reason,omitempty"`
+
+	PasswordHash     string `dynamodbav:"password_hash,omitempty" json:"-"`
+	RequireSignature bool   `dynamodbav:"require_signature,omitempty" json:"require_signature,omitempty"`
+
+	RedirectType RedirectType `dynamodbav:"redirect_type,omitempty" json:"redirect_type,omitempty"`
+	ForwardQuery bool         `dynamodbav:"forward_query,omitempty" json:"forward_query,omitempty"`
+	ForwardPath  bool         `dynamodbav:"forward_path,omitempty" json:"forward_path,omitempty"`
+
+	CampaignID string `dynamodbav:"campaign_id,omitempty" json:"campaign_id,omitempty"`
+
+	Variants []Variant     `dynamodbav:"variants,omitempty" json:"variants,omitempty"`
+	Rules    []RoutingRule `dynamodbav:"rules,omitempty" json:"rules,omitempty"`
+	GeoRules []GeoRule     `dynamodbav:"geo_rules,omitempty" json:"geo_rules,omitempty"`
+}
+
+// Protected reports whether visitors need a password to follow the link.
+func (l Link) Protected() bool {
+	return l.PasswordHash != ""
+}
+
+// Public reports whether any request may follow the link, which is what
+// makes it safe to cache.
+func (l Link) Public() bool {
+	return !l.Protected() && !l.RequireSignature
+}
+
+// MarshalJSON encodes whether the link is password protected; the hash
+// itself is never encoded.
+func (l Link) MarshalJSON() ([]byte, error) {
+	type plain Link
+	return json.Marshal(struct {
+		plain
+		PasswordProtected bool `json:"password_protected,omitempty"`
+	}{plain(l), l.Protected()})
+}
+
+// Disabled reports whether the link stopped redirecting.
+func (l Link) Disabled() bool {
+	return l.DisabledAt != nil
 }
@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// Password length limits of protected links. bcrypt ignores everything
+// after 72 bytes, so longer passwords are refused rather than truncated.
+const (
+	MinLinkPasswordLength = 6
+	MaxLinkPasswordLength = 72
+)
+
+// DefaultLinkAccessTTL is how long a visitor who entered the password of
+// a link may follow it without entering it again.
+const DefaultLinkAccessTTL = 15 * time.Minute
+
+// LinkAccess lets a visitor follow a password protected link until
+// Expires. Token is signed by the service and only valid for the link it
+// was issued for, and only until the link's password changes.
+type LinkAccess struct {
+	Token   string
+	Expires time.Time
+}
@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"math"
+	"time"
+)
+
+// RateLimit is a token bucket holding up to Requests tokens, refilled
+// evenly over Window. A zero Requests means unlimited.
+type RateLimit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// Enabled reports whether the limit applies.
+func (l RateLimit) Enabled() bool {
+	return l.Requests > 0 && l.Window > 0
+}
+
+// Decide describes the bucket after a request left tokens in it.
+func (l RateLimit) Decide(allowed bool, tokens float64) RateDecision {
+	perToken := l.Window / time.Duration(l.Requests)
+	d := RateDecision{
+		Allowed:   allowed,
+		Limit:     l.Requests,
+		Remaining: int(math.Floor(tokens)),
+		Window:    l.Window,
+		Reset:     time.Duration((float64(l.Requests) - tokens) * float64(perToken)),
+	}
+	if !allowed {
+		d.RetryAfter = time.Duration((1 - tokens) * float64(perToken))
+	}
+	return d
+}
+
+// RateDecision is the outcome of taking a token from a bucket. Reset is
+// how long until the bucket is full again and RetryAfter, for refused
+// requests, how long until the next token.
+type RateDecision struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Window     time.Duration
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
@@ -0,0 +1,279 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// RedirectType is how a link sends visitors to its destination: an HTTP
+// redirect with one of the status codes, or a page that redirects once
+// it has loaded.
+type RedirectType string
+
+const (
+	RedirectPermanent         RedirectType = "301"
+	RedirectFound             RedirectType = "302"
+	RedirectTemporary         RedirectType = "307"
+	RedirectPermanentSameVerb RedirectType = "308"
+	RedirectMetaRefresh       RedirectType = "meta_refresh"
+	RedirectJavaScript        RedirectType = "javascript"
+)
+
+// DefaultRedirectType is the redirect of links that do not choose one.
+const DefaultRedirectType = RedirectPermanent
+
+// RedirectTypes lists the valid redirect types.
+var RedirectTypes = []RedirectType{
+	RedirectPermanent, RedirectFound, RedirectTemporary, RedirectPermanentSameVerb,
+	RedirectMetaRefresh, RedirectJavaScript,
+}
+
+// Valid reports whether t is a known redirect type. The empty type is
+// valid and means DefaultRedirectType.
+func (t RedirectType) Valid() bool {
+	if t == "" {
+		return true
+	}
+	for _, known := range RedirectTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Page reports whether t is served as a page rather than an HTTP redirect.
+func (t RedirectType) Page() bool {
+	return t == RedirectMetaRefresh || t == RedirectJavaScript
+}
+
+// Status returns the HTTP status of the redirect, or 200 for pages.
+// Browsers cache permanent redirects, so links that must be checked on
+// every request set cacheable to false to get the temporary equivalent.
+func (t RedirectType) Status(cacheable bool) int {
+	switch t {
+	case RedirectFound:
+		return http.StatusFound
+	case RedirectTemporary:
+		return http.StatusTemporaryRedirect
+	case RedirectPermanentSameVerb:
+		if !cacheable {
+			return http.StatusTemporaryRedirect
+		}
+		return http.StatusPermanentRedirect
+	case RedirectMetaRefresh, RedirectJavaScript:
+		return http.StatusOK
+	default:
+		if !cacheable {
+			return http.StatusFound
+		}
+		return http.StatusMovedPermanently
+	}
+}
+
+// Target is where a link redirects and how. It holds everything a
+// redirect needs, so it is what the link cache stores.
+type Target struct {
+	URL  string       `json:"url"`
+	Type RedirectType `json:"type,omitempty"`
+	// ForwardQuery appends the query string of the short URL to the
+	// destination, overriding destination parameters of the same name.
+	ForwardQuery bool `json:"forward_query,omitempty"`
+	// ForwardPath appends the path after the link ID (/r/abc/extra) to
+	// the destination path.
+	ForwardPath bool `json:"forward_path,omitempty"`
+	// CampaignID is the campaign of the link, and UTM its template when
+	// the campaign was found.
+	CampaignID string       `json:"campaign_id,omitempty"`
+	UTM        *UTMTemplate `json:"utm,omitempty"`
+	// Variants split visitors between several destinations; see Pick.
+	Variants []Variant `json:"variants,omitempty"`
+	// Rules send visitors on some devices elsewhere; see Route. GeoRules
+	// do the same for visitors from some countries; see Locate.
+	Rules    []RoutingRule `json:"rules,omitempty"`
+	GeoRules []GeoRule     `json:"geo_rules,omitempty"`
+}
+
+// Target returns where l redirects and how.
+func (l Link) Target() Target {
+	return Target{URL: l.OriginalURL, Type: l.RedirectType, ForwardQuery: l.ForwardQuery, ForwardPath: l.ForwardPath, CampaignID: l.CampaignID, Variants: l.Variants, Rules: l.Rules, GeoRules: l.GeoRules}
+}
+
+// Destinations returns every URL t may redirect to.
+func (t Target) Destinations() []string {
+	urls := []string{t.URL}
+	for _, v := range t.Variants {
+		urls = append(urls, v.URL)
+	}
+	for _, rule := range t.Rules {
+		urls = append(urls, rule.URL)
+	}
+	for _, rule := range t.GeoRules {
+		urls = append(urls, rule.URL)
+	}
+	return urls
+}
+
+// Visitor is what a redirect knows about who follows a link.
+type Visitor struct {
+	Device   Device
+	Platform Platform
+	// Country is the ISO 3166-1 alpha-2 code of the visitor's country, or
+	// empty when unknown.
+	Country string
+	// Variant is the variant the visitor was sent to before, if known,
+	// and Key identifies them otherwise; see Pick.
+	Variant string
+	Key     string
+}
+
+// NewVisitor returns the visitor of link linkID from ip, located in
+// country, with the given user agent and referer.
+func NewVisitor(linkID, ip, country, userAgent, referer string) Visitor {
+	return Visitor{
+		Device:   ParseUserAgent(userAgent),
+		Platform: DetectPlatform(userAgent, referer),
+		Country:  country,
+		Key:      VisitorKey(linkID, ip, userAgent),
+	}
+}
+
+// Choice is where a visitor of a link goes and why.
+type Choice struct {
+	Target Target
+	// Rule is the device match of the routing rule that matched, GeoRule
+	// the index of the geo rule that matched or -1, and Variant the ID of
+	// the variant picked.
+	Rule    DeviceMatch
+	GeoRule int
+	Variant string
+}
+
+// Choose decides where t sends v. Routing rules for the device come
+// first, then geo rules for the country; visitors matching neither are
+// split between the variants of the destination.
+func (t Target) Choose(v Visitor) Choice {
+	choice := Choice{GeoRule: -1}
+	t, choice.Rule = t.Route(v.Device)
+	if choice.Rule == "" {
+		t, choice.GeoRule = t.Locate(v.Country)
+	}
+	t.GeoRules = nil
+	choice.Target, choice.Variant = t.Pick(v.Variant, v.Key)
+	return choice
+}
+
+// ReservedQueryParams are query parameters of short URLs that are meant
+// for the shortener and never forwarded.
+var ReservedQueryParams = []string{"exp", "sig"}
+
+// Location returns the URL to send a visitor from platform to who
+// requested the short URL with the path suffix after the link ID and
+// query. Both are ignored unless the target forwards them; a suffix of a
+// target that does not forward paths fails with ErrNotFound, as no such
+// short URL exists, and so does one with dot segments. The campaign's UTM
+// parameters and the forwarded query, the latter winning, are appended
+// to the destination's query unless it sets them itself.
+func (t Target) Location(suffix string, query url.Values, platform Platform) (string, error) {
+	suffix = strings.Trim(suffix, "/")
+	forwardQuery := t.ForwardQuery && len(query) > 0
+	var utm url.Values
+	if t.UTM != nil {
+		utm = t.UTM.For(platform).Values()
+	}
+	if suffix != "" && (!t.ForwardPath || !belowPath(suffix)) {
+		return "", fmt.Errorf("path %q after the link ID: %w", suffix, ErrNotFound)
+	}
+	if suffix == "" && !forwardQuery && len(utm) == 0 {
+		return t.URL, nil
+	}
+
+	destination, err := url.Parse(t.URL)
+	if err != nil {
+		return "", fmt.Errorf("invalid destination %q: %w", t.URL, err)
+	}
+	if suffix != "" {
+		destination = destination.JoinPath(suffix)
+	}
+	if forwardQuery || len(utm) > 0 {
+		// The destination's own query is kept byte for byte, as signed
+		// URLs depend on it; only parameters it does not set are added.
+		added := utm
+		if added == nil {
+			added = url.Values{}
+		}
+		if forwardQuery {
+			for name, values := range query {
+				if !isReservedQueryParam(name) {
+					added[name] = values
+				}
+			}
+		}
+		for name := range destination.Query() {
+			delete(added, name)
+		}
+		if len(added) > 0 {
+			if destination.RawQuery != "" {
+				destination.RawQuery += "&"
+			}
+			destination.RawQuery += added.Encode()
+		}
+	}
+	return destination.String(), nil
+}
+
+// belowPath reports whether suffix, appended to a path, stays below it.
+// Dot segments, percent-encoded or not, would climb out of it.
+func belowPath(suffix string) bool {
+	decoded, err := url.PathUnescape(suffix)
+	if err != nil {
+		return false
+	}
+	for _, path := range []string{suffix, decoded} {
+		for _, segment := range strings.Split(path, "/") {
+			if segment == "." || segment == ".." {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func isReservedQueryParam(name string) bool {
+	for _, reserved := range ReservedQueryParams {
+		if name == reserved {
+			return true
+		}
+	}
+	return false
+}
+
+// EncodeTarget encodes t for the link cache. Targets without options are
+// stored as the plain URL, so entries of either form can be read back
+// with DecodeTarget.
+func EncodeTarget(t Target) string {
+	if t.Type == "" && !t.ForwardQuery && !t.ForwardPath && t.CampaignID == "" && t.UTM == nil && len(t.Variants) == 0 && len(t.Rules) == 0 && len(t.GeoRules) == 0 {
+		return t.URL
+	}
+	data, err := json.Marshal(t)
+	if err != nil {
+		return t.URL
+	}
+	return string(data)
+}
+
+// DecodeTarget decodes a target stored by EncodeTarget. Destinations are
+// http(s) URLs, so only encoded targets start with a brace.
+func DecodeTarget(s string) (Target, error) {
+	if !strings.HasPrefix(s, "{") {
+		return Target{URL: s}, nil
+	}
+	var t Target
+	if err := json.Unmarshal([]byte(s), &t); err != nil {
+		return Target{}, fmt.Errorf("invalid cached target: %w", err)
+	}
+	return t, nil
+}
@@ -0,0 +1,55 @@
+package domain
+
+import "time"
+
+// Reasons a link can be reported for.
+const (
+	ReportPhishing = "phishing"
+	ReportMalware  = "malware"
+	ReportSpam     = "spam"
+	ReportIllegal  = "illegal"
+	ReportOther    = "other"
+)
+
+// ReportReasons lists every reason a link can be reported for.
+var ReportReasons = []string{ReportPhishing, ReportMalware, ReportSpam, ReportIllegal, ReportOther}
+
+// States of an AbuseReport. Open reports wait in the review queue; the
+// others record what the reviewer decided.
+const (
+	ReportOpen      = "open"
+	ReportDisabled  = "disabled"
+	ReportRestored  = "restored"
+	ReportDismissed = "dismissed"
+)
+
+// MaxReportDetails caps the free text of a report.
+const MaxReportDetails = 2000
+
+// AbuseReport is a report from the public that a short link is malicious.
+// The email and user agent are as supplied by the client and only used
+// to follow up; the email is optional. ReporterIP is the client IP, taken
+// from forwarding headers only when a trusted proxy set them.
+type AbuseReport struct {
+	Id            string    `json:"id"`
+	WorkspaceID   string    `json:"workspace_id"`
+	Domain        string    `json:"domain,omitempty"`
+	LinkID        string    `json:"link_id"`
+	Reason        string    `json:"reason"`
+	Details       string    `json:"details,omitempty"`
+	ReporterEmail string    `json:"reporter_email,omitempty"`
+	ReporterIP    string    `json:"reporter_ip,omitempty"`
+	UserAgent     string    `json:"user_agent,omitempty"`
+	Status        string    `json:"status"`
+	CreatedAt     time.Time `json:"created_at"`
+
+	Resolution *ReportResolution `json:"resolution,omitempty"`
+}
+
+// ReportResolution records who closed a report, when and why.
+type ReportResolution struct {
+	Status     string    `json:"status"`
+	Note       string    `json:"note"`
+	ResolvedBy string    `json:"resolved_by"`
+	ResolvedAt time.Time `json:"resolved_at"`
+}
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Lifetimes of signed URLs when the deployment does not configure them.
+const (
+	DefaultSignedURLTTL = 24 * time.Hour
+	MaxSignedURLTTL     = 30 * 24 * time.Hour
+)
+
+// URLSignature is the exp and sig query parameters of a signed short
+// URL: when the URL expires and the signature over the link and expiry.
+type URLSignature struct {
+	Expires   time.Time
+	Signature string
+}
+
+// ParseURLSignature reads the exp (Unix seconds) and sig query parameters.
+// Malformed values yield a signature that never verifies.
+func ParseURLSignature(exp, sig string) URLSignature {
+	unix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || sig == "" {
+		return URLSignature{}
+	}
+	return URLSignature{Expires: time.Unix(unix, 0), Signature: sig}
+}
+
+// Exp returns the exp query parameter.
+func (s URLSignature) Exp() string {
+	return strconv.FormatInt(s.Expires.Unix(), 10)
+}
+
+// Query encodes the signature as the query string of a signed URL.
+func (s URLSignature) Query() string {
+	return url.Values{"exp": {s.Exp()}, "sig": {s.Signature}}.Encode()
+}
+
+type urlSignatureKey struct{}
+
+// WithURLSignature returns ctx carrying the signature the request was
+// made with.
+func WithURLSignature(ctx context.Context, s URLSignature) context.Context {
+	return context.WithValue(ctx, urlSignatureKey{}, s)
+}
+
+// URLSignatureOf returns the signature of the request of ctx, or a zero
+// one when it was not signed.
+func URLSignatureOf(ctx context.Context) URLSignature {
+	s, _ := ctx.Value(urlSignatureKey{}).(URLSignature)
+	return s
+}
+
+// SignatureError reports a request for a link that requires a signed URL
+// without a valid signature, or with one that expired. It matches
+// ErrForbidden under errors.Is, or ErrExpired when Expired is set.
+type SignatureError struct {
+	LinkID  string
+	Expired bool
+}
+
+func (e *SignatureError) Error() string {
+	if e.Expired {
+		return fmt.Sprintf("signed URL of link %q has expired", e.LinkID)
+	}
+	return fmt.Sprintf("link %q requires a valid signed URL", e.LinkID)
+}
+
+func (e *SignatureError) Is(target error) bool {
+	if e.Expired {
+		return target == ErrExpired
+	}
+	return target == ErrForbidden
+}
@@ -1,6 +1,9 @@
 package domain
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 type Platform int
 
@@ -24,9 +27,72 @@ func (p Platform) String() string {
 	}
 }
 
+// Name returns the lowercase name of p, such as "instagram".
+func (p Platform) Name() string {
+	return strings.ToLower(p.String())
+}
+
+// Platforms lists the platforms DetectPlatform recognises.
+var Platforms = []Platform{PlatformInstagram, PlatformTwitter, PlatformYouTube}
+
+// ParsePlatform returns the platform named name, as returned by Name.
+func ParsePlatform(name string) (Platform, bool) {
+	for _, p := range Platforms {
+		if p.Name() == name {
+			return p, true
+		}
+	}
+	return PlatformUnknown, false
+}
+
+// DetectPlatform guesses the platform a visitor followed a link from, by
+// the in-app browser of its user agent or the host of its referer.
+func DetectPlatform(userAgent, referer string) Platform {
+	switch {
+	case strings.Contains(userAgent, "Instagram"):
+		return PlatformInstagram
+	case strings.Contains(userAgent, "Twitter"):
+		return PlatformTwitter
+	}
+
+	host := referer
+	if _, rest, ok := strings.Cut(host, "://"); ok {
+		host = rest
+	}
+	host, _, _ = strings.Cut(host, "/")
+	host = strings.TrimPrefix(strings.ToLower(host), "www.")
+	switch host {
+	case "instagram.com", "l.instagram.com":
+		return PlatformInstagram
+	case "t.co", "twitter.com", "x.com", "mobile.twitter.com":
+		return PlatformTwitter
+	case "youtube.com", "m.youtube.com", "youtu.be":
+		return PlatformYouTube
+	}
+	return PlatformUnknown
+}
+
+// Outcomes of a password attempt on a protected link, recorded in
+// Stats.PasswordAttempt. Redirects without an attempt leave it empty.
+const (
+	PasswordAccepted = "accepted"
+	PasswordRejected = "rejected"
+)
+
 type Stats struct {
-	Id        string    `dynamodbav:"id" json:"id"`
-	Platform  Platform  `dynamodbav:"platform" json:"platform"`
-	LinkID    string    `dynamodbav:"link_id" json:"link_id"`
-	CreatedAt time.Time `dynamodbav:"created_at" json:"created_at"`
+	Id          string    `dynamodbav:"id" json:"id"`
+	Platform    Platform  `dynamodbav:"platform" json:"platform"`
+	LinkID      string    `dynamodbav:"link_id" json:"link_id"`
+	CreatedAt   time.Time `dynamodbav:"created_at" json:"created_at"`
+	WorkspaceID string    `dynamodbav:"workspace_id,omitempty" json:"workspace_id,omitempty"`
+	Domain      string    `dynamodbav:"domain,omitempty" json:"domain,omitempty"`
+
+	PasswordAttempt string `dynamodbav:"password_attempt,omitempty" json:"password_attempt,omitempty"`
+	// CampaignID is the campaign the link belonged to when it was clicked.
+	CampaignID string `dynamodbav:"campaign_id,omitempty" json:"campaign_id,omitempty"`
+	// Variant is the ID of the variant served, for links with variants.
+	Variant string `dynamodbav:"variant,omitempty" json:"variant,omitempty"`
+	// Rule is the device match of the routing rule the visitor was sent
+	// to, or empty when no rule matched.
+	Rule DeviceMatch `dynamodbav:"rule,omitempty" json:"rule,omitempty"`
 }
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+// Limits of the variants of a link.
+const (
+	MaxVariants      = 10
+	MaxVariantWeight = 1000
+	MaxVariantIDLen  = 32
+)
+
+// Variant is one of several destinations of a link. Visitors are split
+// between the variants of a link in proportion to their weights.
+type Variant struct {
+	ID     string `dynamodbav:"id" json:"id"`
+	URL    string `dynamodbav:"url" json:"url"`
+	Weight int    `dynamodbav:"weight" json:"weight"`
+}
+
+// VariantClicks counts the clicks on one variant of a link.
+type VariantClicks struct {
+	Variant string `json:"variant"`
+	URL     string `json:"url,omitempty"`
+	Clicks  int    `json:"clicks"`
+}
+
+// VisitorKey identifies a visitor of a link well enough to keep showing
+// them the same variant when they carry no cookie.
+func VisitorKey(linkID, ip, userAgent string) string {
+	return linkID + "\x00" + ip + "\x00" + userAgent
+}
+
+// Pick returns t redirecting to one of its variants, and the ID of that
+// variant. Visitors keep the variant named sticky while the link has it;
+// others are assigned by weight from the hash of key, so the same key
+// always gets the same variant. Targets without variants are returned as
+// they are, with an empty ID.
+func (t Target) Pick(sticky, key string) (Target, string) {
+	if len(t.Variants) == 0 {
+		return t, ""
+	}
+	chosen := t.Variants[0]
+	if v, ok := t.variant(sticky); ok {
+		chosen = v
+	} else {
+		total := 0
+		for _, v := range t.Variants {
+			total += v.Weight
+		}
+		if total > 0 {
+			sum := sha256.Sum256([]byte(key))
+			n := int(binary.BigEndian.Uint64(sum[:8]) % uint64(total))
+			for _, v := range t.Variants {
+				if n < v.Weight {
+					chosen = v
+					break
+				}
+				n -= v.Weight
+			}
+		}
+	}
+	t.URL, t.Variants = chosen.URL, nil
+	return t, chosen.ID
+}
+
+func (t Target) variant(id string) (Variant, bool) {
+	if id == "" {
+		return Variant{}, false
+	}
+	for _, v := range t.Variants {
+		if v.ID == id {
+			return v, true
+		}
+	}
+	return Variant{}, false
+}
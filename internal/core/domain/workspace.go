@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultWorkspaceID is the workspace of data created before workspaces
+// existed and of trusted callers that do not name one, such as the Lambda
+// handlers. Admins of the default workspace operate the deployment and may
+// manage every workspace.
+const DefaultWorkspaceID = "default"
+
+// DefaultShortIDLength is the length of generated link IDs unless a
+// workspace overrides it.
+const DefaultShortIDLength = 8
+
+// Workspace is a tenant. Links, stats and API keys belong to exactly one
+// workspace, and nothing in one workspace is visible from another.
+// Workspaces serve their links on the custom domains they register.
+type Workspace struct {
+	Id        string            `json:"id"`
+	Name      string            `json:"name"`
+	Quotas    WorkspaceQuotas   `json:"quotas"`
+	Settings  WorkspaceSettings `json:"settings"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// WorkspaceQuotas limit what a workspace may store. Zero means unlimited.
+type WorkspaceQuotas struct {
+	MaxLinks int `json:"max_links"`
+}
+
+// WorkspaceSettings tune behaviour per workspace. Zero values fall back to
+// the deployment defaults.
+type WorkspaceSettings struct {
+	ShortIDLength int `json:"short_id_length"`
+}
+
+type workspaceKey struct{}
+
+// WithWorkspace returns ctx scoped to workspace id.
+func WithWorkspace(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, workspaceKey{}, id)
+}
+
+// WorkspaceFrom returns the workspace ctx was explicitly scoped to.
+func WorkspaceFrom(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(workspaceKey{}).(string)
+	return id, ok
+}
+
+// WorkspaceOf returns the workspace of ctx, or DefaultWorkspaceID when
+// none was set.
+func WorkspaceOf(ctx context.Context) string {
+	if id, ok := WorkspaceFrom(ctx); ok && id != "" {
+		return id
+	}
+	return DefaultWorkspaceID
+}
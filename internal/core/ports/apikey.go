@@ -0,0 +1,15 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+)
+
+type APIKeyPort interface {
+	All(context.Context) ([]domain.APIKey, error)
+	Get(context.Context, string) (domain.APIKey, error)
+	Create(context.Context, domain.APIKey) error
+	Revoke(ctx context.Context, id string, at time.Time) error
+}
@@ -0,0 +1,23 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+)
+
+// AuditPort stores the audit log. Entries are only ever appended.
+//
+// Append assigns the entry its Seq and returns it. With chain it also
+// links the entry to the last chained one: PrevHash is set to that entry's
+// Hash and Hash to the entry's domain.AuditEntry.ComputeHash. Chained
+// appends are serialised so the chain has no forks.
+//
+// Find returns the entries matching filter, newest first, at most
+// filter.Limit of them. Scan returns up to limit entries with a Seq above
+// after, oldest first; an empty page means the end of the log.
+type AuditPort interface {
+	Append(ctx context.Context, entry domain.AuditEntry, chain bool) (domain.AuditEntry, error)
+	Find(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEntry, error)
+	Scan(ctx context.Context, after int64, limit int) ([]domain.AuditEntry, error)
+}
@@ -0,0 +1,14 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+)
+
+// BlocklistPort stores the managed block rules, oldest first.
+type BlocklistPort interface {
+	All(context.Context) ([]domain.BlockRule, error)
+	Create(context.Context, domain.BlockRule) error
+	Delete(ctx context.Context, id string) error
+}
@@ -0,0 +1,17 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+)
+
+// CampaignPort stores campaigns. Campaigns are addressed by workspace and
+// ID; All lists those of a workspace oldest first.
+type CampaignPort interface {
+	All(ctx context.Context, workspaceID string) ([]domain.Campaign, error)
+	Get(ctx context.Context, workspaceID, id string) (domain.Campaign, error)
+	Create(context.Context, domain.Campaign) error
+	Update(context.Context, domain.Campaign) error
+	Delete(ctx context.Context, workspaceID, id string) error
+}
@@ -0,0 +1,12 @@
+package ports
+
+import (
+	"context"
+	"net/netip"
+)
+
+// IPResolver looks up the addresses of a host. Hosts without addresses
+// fail with domain.ErrNotFound.
+type IPResolver interface {
+	LookupIP(ctx context.Context, host string) ([]netip.Addr, error)
+}
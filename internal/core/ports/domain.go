@@ -0,0 +1,24 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+)
+
+// DomainPort stores custom domains. A domain name is registered by at
+// most one workspace at a time. All lists domains oldest first.
+type DomainPort interface {
+	All(ctx context.Context, workspaceID string) ([]domain.CustomDomain, error)
+	Get(ctx context.Context, name string) (domain.CustomDomain, error)
+	Create(context.Context, domain.CustomDomain) error
+	Verify(ctx context.Context, name string, at time.Time) error
+	Delete(ctx context.Context, workspaceID, name string) error
+}
+
+// TXTResolver looks up DNS TXT records. Names without records fail with
+// domain.ErrNotFound.
+type TXTResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
@@ -0,0 +1,8 @@
+package ports
+
+// GeoIP locates client IP addresses. Country returns the ISO 3166-1
+// alpha-2 code of the country of ip, or an empty string when the address
+// is invalid or not in the database.
+type GeoIP interface {
+	Country(ip string) string
+}
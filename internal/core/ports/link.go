@@ -2,13 +2,28 @@ package ports
 
 import (
 	"context"
+	"time"
 
 	"github.com/itsbaivab/url-shortener/internal/core/domain"
 )
 
+// LinkPort stores links. Every lookup is scoped to a workspace; links are
+// created in link.WorkspaceID. IDs are unique within a workspace and
+// custom domain only, so single links are addressed by all three; host
+// is empty for links on the shared domain.
+//
+// Scan walks the links of every workspace in pages of up to limit links,
+// in an order that is stable across calls: pass the last link of a page
+// as after to get the next one, or the zero Link to start. An empty page
+// means every link was returned.
 type LinkPort interface {
-	All(context.Context) ([]domain.Link, error)
-	Get(context.Context, string) (domain.Link, error)
+	All(ctx context.Context, workspaceID string) ([]domain.Link, error)
+	AllByOwner(ctx context.Context, workspaceID, ownerID string) ([]domain.Link, error)
+	Count(ctx context.Context, workspaceID string) (int, error)
+	Get(ctx context.Context, workspaceID, host, id string) (domain.Link, error)
 	Create(context.Context, domain.Link) error
-	Delete(context.Context, string) error
+	Delete(ctx context.Context, workspaceID, host, id string) error
+	Disable(ctx context.Context, workspaceID, host, id, reason string, at time.Time) error
+	Enable(ctx context.Context, workspaceID, host, id string) error
+	Scan(ctx context.Context, after domain.Link, limit int) ([]domain.Link, error)
 }
@@ -0,0 +1,41 @@
+package ports
+
+// RedirectResult classifies the outcome of resolving a short link.
+type RedirectResult string
+
+const (
+	// RedirectHit means the destination came from the cache.
+	RedirectHit RedirectResult = "hit"
+	// RedirectMiss means the cache missed and the repository answered.
+	RedirectMiss RedirectResult = "miss"
+	// RedirectNotFound means the link does not exist.
+	RedirectNotFound RedirectResult = "not_found"
+	// RedirectExpired means the link exists but is no longer valid.
+	RedirectExpired RedirectResult = "expired"
+	// RedirectDisabled means the link was taken down.
+	RedirectDisabled RedirectResult = "disabled"
+	// RedirectProtected means the link needs a password the caller did
+	// not give.
+	RedirectProtected RedirectResult = "protected"
+	// RedirectUnsigned means the link needs a signed URL and the request
+	// had no valid signature.
+	RedirectUnsigned RedirectResult = "unsigned"
+)
+
+// Metrics receives business events from the core services.
+type Metrics interface {
+	LinkCreated()
+	Redirect(result RedirectResult)
+	LinkClicked(linkID string)
+	CacheLookup(hit bool)
+	StatsWriteFailed()
+}
+
+// NopMetrics discards every event.
+type NopMetrics struct{}
+
+func (NopMetrics) LinkCreated()                   {}
+func (NopMetrics) Redirect(result RedirectResult) {}
+func (NopMetrics) LinkClicked(linkID string)      {}
+func (NopMetrics) CacheLookup(hit bool)           {}
+func (NopMetrics) StatsWriteFailed()              {}
@@ -0,0 +1,18 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+)
+
+// RateLimiter keeps request budgets. Buckets and counters are created on
+// first use and forgotten once idle.
+type RateLimiter interface {
+	// Take removes a token from the bucket key if one is left.
+	Take(ctx context.Context, key string, limit domain.RateLimit) (domain.RateDecision, error)
+	// Increment adds one to the counter key, which is dropped at
+	// expireAt, and returns the new count.
+	Increment(ctx context.Context, key string, expireAt time.Time) (int64, error)
+}
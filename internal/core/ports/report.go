@@ -0,0 +1,17 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+)
+
+// AbuseReportPort stores abuse reports. All returns the reports in status,
+// or every report when status is empty, oldest first. Resolve closes an
+// open report; resolving a closed one fails with domain.ErrConflict.
+type AbuseReportPort interface {
+	Create(context.Context, domain.AbuseReport) error
+	Get(ctx context.Context, id string) (domain.AbuseReport, error)
+	All(ctx context.Context, status string) ([]domain.AbuseReport, error)
+	Resolve(ctx context.Context, id string, resolution domain.ReportResolution) error
+}
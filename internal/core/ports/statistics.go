@@ -6,10 +6,15 @@ import (
 	"github.com/itsbaivab/url-shortener/internal/core/domain"
 )
 
+// StatsPort stores click stats. Every lookup is scoped to a workspace;
+// stats are created in stats.WorkspaceID. Stats of a link are addressed
+// like the link itself, by workspace, custom domain and link ID, and
+// GetStatsByCampaign returns those recorded for links of a campaign.
 type StatsPort interface {
-	All(context.Context) ([]domain.Stats, error)
-	Get(context.Context, string) (domain.Stats, error)
+	All(ctx context.Context, workspaceID string) ([]domain.Stats, error)
+	Get(ctx context.Context, workspaceID, id string) (domain.Stats, error)
 	Create(context.Context, domain.Stats) error
-	Delete(context.Context, string) error
-	GetStatsByLinkID(context.Context, string) ([]domain.Stats, error)
+	Delete(ctx context.Context, workspaceID, host, linkID string) error
+	GetStatsByLinkID(ctx context.Context, workspaceID, host, linkID string) ([]domain.Stats, error)
+	GetStatsByCampaign(ctx context.Context, workspaceID, campaignID string) ([]domain.Stats, error)
 }
@@ -0,0 +1,14 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+)
+
+type WorkspacePort interface {
+	All(context.Context) ([]domain.Workspace, error)
+	Get(context.Context, string) (domain.Workspace, error)
+	Create(context.Context, domain.Workspace) error
+	Update(context.Context, domain.Workspace) error
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/itsbaivab/url-shortener/internal/core/ports"
+)
+
+// apiKeyPrefix starts every key, so leaked keys are easy to recognise.
+const apiKeyPrefix = "usk"
+
+const (
+	apiKeyIDLength     = 12
+	apiKeySecretLength = 32
+)
+
+type APIKeyService struct {
+	port  ports.APIKeyPort
+	audit *AuditService
+}
+
+func NewAPIKeyService(p ports.APIKeyPort) *APIKeyService {
+	return &APIKeyService{port: p}
+}
+
+// WithAudit records issued and revoked keys in a.
+func (service *APIKeyService) WithAudit(a *AuditService) *APIKeyService {
+	service.audit = a
+	return service
+}
+
+// Issue creates a key for ownerID in the workspace of ctx and returns it
+// in full. The full key is not stored and cannot be shown again. Only
+// operators may issue keys for a workspace other than their own.
+func (service *APIKeyService) Issue(ctx context.Context, ownerID, name string, scopes []string) (string, domain.APIKey, error) {
+	workspaceID := domain.WorkspaceOf(ctx)
+	if err := requireAdmin(ctx, "api key management"); err != nil {
+		return "", domain.APIKey{}, err
+	}
+	if p, ok := domain.PrincipalFrom(ctx); ok && !p.IsOperator() && p.Workspace() != workspaceID {
+		return "", domain.APIKey{}, fmt.Errorf("cannot issue keys for workspace %q: %w", workspaceID, domain.ErrForbidden)
+	}
+	if ownerID == "" {
+		return "", domain.APIKey{}, &domain.ValidationError{Field: "owner_id", Reason: "owner_id is required"}
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(domain.Scopes, scope) {
+			return "", domain.APIKey{}, &domain.ValidationError{Field: "scopes", Reason: fmt.Sprintf("unknown scope %q", scope)}
+		}
+	}
+
+	id, err := randomString(apiKeyIDLength)
+	if err != nil {
+		return "", domain.APIKey{}, err
+	}
+	secret, err := randomString(apiKeySecretLength)
+	if err != nil {
+		return "", domain.APIKey{}, err
+	}
+
+	key := domain.APIKey{
+		Id:          id,
+		WorkspaceID: workspaceID,
+		OwnerID:     ownerID,
+		Name:        name,
+		Hash:        hashSecret(secret),
+		Scopes:      append([]string{}, scopes...),
+		CreatedAt:   time.Now(),
+	}
+	if err := service.port.Create(ctx, key); err != nil {
+		return "", domain.APIKey{}, fmt.Errorf("failed to issue api key: %w", err)
+	}
+	service.audit.Record(ctx, domain.AuditEntry{Action: domain.AuditAPIKeyIssued, WorkspaceID: key.WorkspaceID, ResourceID: key.Id}, nil, key)
+
+	return strings.Join([]string{apiKeyPrefix, id, secret}, "_"), key, nil
+}
+
+// errInvalidAPIKey is returned for every kind of bad key, so callers
+// cannot tell unknown, wrong and revoked keys apart.
+var errInvalidAPIKey = fmt.Errorf("invalid api key: %w", domain.ErrUnauthorized)
+
+// Authenticate resolves a full key to the principal it identifies.
+func (service *APIKeyService) Authenticate(ctx context.Context, token string) (domain.Principal, error) {
+	prefix, rest, _ := strings.Cut(token, "_")
+	id, secret, _ := strings.Cut(rest, "_")
+	if prefix != apiKeyPrefix || id == "" || secret == "" {
+		return domain.Principal{}, errInvalidAPIKey
+	}
+
+	key, err := service.port.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.Principal{}, errInvalidAPIKey
+		}
+		return domain.Principal{}, fmt.Errorf("failed to look up api key: %w", err)
+	}
+	if subtle.ConstantTimeCompare([]byte(key.Hash), []byte(hashSecret(secret))) != 1 {
+		return domain.Principal{}, errInvalidAPIKey
+	}
+	if key.Revoked() {
+		return domain.Principal{}, errInvalidAPIKey
+	}
+
+	scopes := append(slices.Clone(domain.DefaultAPIKeyScopes), key.Scopes...)
+	return domain.Principal{WorkspaceID: key.WorkspaceID, OwnerID: key.OwnerID, KeyID: key.Id, Scopes: scopes}, nil
+}
+
+// Accepts reports whether token looks like an API key rather than a
+// bearer token.
+func (service *APIKeyService) Accepts(token string) bool {
+	return strings.HasPrefix(token, apiKeyPrefix+"_")
+}
+
+// All returns the keys of the caller's workspace, or every key for
+// operators and trusted callers.
+func (service *APIKeyService) All(ctx context.Context) ([]domain.APIKey, error) {
+	if err := requireAdmin(ctx, "api key management"); err != nil {
+		return nil, err
+	}
+	keys, err := service.port.All(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api keys: %w", err)
+	}
+	if p, ok := domain.PrincipalFrom(ctx); ok && !p.IsOperator() {
+		keys = slices.DeleteFunc(keys, func(key domain.APIKey) bool {
+			return key.WorkspaceID != p.Workspace()
+		})
+	}
+	return keys, nil
+}
+
+// Revoke revokes a key of the caller's workspace. Keys of other
+// workspaces are reported as not found.
+func (service *APIKeyService) Revoke(ctx context.Context, id string) error {
+	if err := requireAdmin(ctx, "api key management"); err != nil {
+		return err
+	}
+	key, err := service.port.Get(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key '%s': %w", id, err)
+	}
+	if p, ok := domain.PrincipalFrom(ctx); ok && !p.IsOperator() && key.WorkspaceID != p.Workspace() {
+		return fmt.Errorf("failed to revoke api key '%s': %w", id, domain.ErrNotFound)
+	}
+	now := time.Now()
+	if err := service.port.Revoke(ctx, id, now); err != nil {
+		return fmt.Errorf("failed to revoke api key '%s': %w", id, err)
+	}
+
+	revoked := key
+	revoked.RevokedAt = &now
+	service.audit.Record(ctx, domain.AuditEntry{Action: domain.AuditAPIKeyRevoked, WorkspaceID: key.WorkspaceID, ResourceID: id}, key, revoked)
+	return nil
+}
+
+// requireAdmin allows trusted callers without a principal, such as the
+// command line, and principals holding the admin scope.
+func requireAdmin(ctx context.Context, action string) error {
+	if p, ok := domain.PrincipalFrom(ctx); ok && !p.HasScope(domain.ScopeAdmin) {
+		return fmt.Errorf("%s requires the %q scope: %w", action, domain.ScopeAdmin, domain.ErrForbidden)
+	}
+	return nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomString(length int) (string, error) {
+	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	result := make([]byte, length)
+	for i := range result {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
+		if err != nil {
+			return "", fmt.Errorf("failed to generate random string: %w", err)
+		}
+		result[i] = charset[n.Int64()]
+	}
+	return string(result), nil
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/itsbaivab/url-shortener/internal/core/ports"
+)
+
+// auditScanPageSize is how many entries Verify reads per page.
+const auditScanPageSize = 1000
+
+// AuditService keeps the audit log of changes to links, keys, workspaces,
+// domains, block rules and moderation decisions. Services given one
+// through their WithAudit builder record every change they make. A nil
+// *AuditService records nothing.
+type AuditService struct {
+	port  ports.AuditPort
+	chain bool
+}
+
+func NewAuditService(p ports.AuditPort) *AuditService {
+	return &AuditService{port: p}
+}
+
+// WithHashChain links every new entry to the previous one by hash, so
+// Verify can detect entries changed after the fact.
+func (service *AuditService) WithHashChain(enabled bool) *AuditService {
+	service.chain = enabled
+	return service
+}
+
+// Record appends entry to the log, with the caller and origin of ctx and
+// before and after encoded as JSON; nil values are left empty. The change
+// has already been made by the time it is recorded, so failures are
+// logged rather than returned.
+func (service *AuditService) Record(ctx context.Context, entry domain.AuditEntry, before, after any) {
+	if service == nil {
+		return
+	}
+	origin := domain.OriginOf(ctx)
+	entry.Actor = actorOf(ctx)
+	entry.RequestID, entry.SourceIP = origin.RequestID, origin.SourceIP
+	entry.Before, entry.After = auditValue(before), auditValue(after)
+	// Stored timestamps keep microseconds; hashes must survive the round
+	// trip.
+	entry.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+
+	if _, err := service.port.Append(ctx, entry, service.chain); err != nil {
+		slog.ErrorContext(ctx, "failed to record audit entry", "action", entry.Action, "actor", entry.Actor,
+			"workspace_id", entry.WorkspaceID, "resource_id", entry.ResourceID, "error", err)
+	}
+}
+
+// Find returns the entries matching filter, newest first. Admins of a
+// workspace only see the entries of their own workspace; operators see
+// every entry. The limit defaults to domain.DefaultAuditPageSize.
+func (service *AuditService) Find(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEntry, error) {
+	if err := requireAdmin(ctx, "reading the audit log"); err != nil {
+		return nil, err
+	}
+	if p, ok := domain.PrincipalFrom(ctx); ok && !p.IsOperator() {
+		if filter.WorkspaceID != "" && filter.WorkspaceID != p.Workspace() {
+			return nil, fmt.Errorf("cannot read the audit log of workspace %q: %w", filter.WorkspaceID, domain.ErrForbidden)
+		}
+		filter.WorkspaceID = p.Workspace()
+	}
+	switch {
+	case filter.Limit == 0:
+		filter.Limit = domain.DefaultAuditPageSize
+	case filter.Limit < 0 || filter.Limit > domain.MaxAuditPageSize:
+		return nil, &domain.ValidationError{Field: "limit", Reason: fmt.Sprintf("limit must be between 1 and %d", domain.MaxAuditPageSize)}
+	}
+	if filter.Before < 0 {
+		return nil, &domain.ValidationError{Field: "before", Reason: "before must not be negative"}
+	}
+	if !filter.Since.IsZero() && !filter.Until.IsZero() && filter.Until.Before(filter.Since) {
+		return nil, &domain.ValidationError{Field: "until", Reason: "until must not be before since"}
+	}
+
+	entries, err := service.port.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return entries, nil
+}
+
+// Verify walks the whole log and checks the hash of every chained entry
+// and its link to the previous one. Entries recorded while the chain was
+// disabled are skipped. Only operators may verify the log.
+func (service *AuditService) Verify(ctx context.Context) (domain.AuditVerification, error) {
+	var result domain.AuditVerification
+	if err := requireOperator(ctx); err != nil {
+		return result, err
+	}
+
+	prev := ""
+	var after int64
+	for {
+		entries, err := service.port.Scan(ctx, after, auditScanPageSize)
+		if err != nil {
+			return result, fmt.Errorf("failed to read audit log: %w", err)
+		}
+		if len(entries) == 0 {
+			return result, nil
+		}
+		after = entries[len(entries)-1].Seq
+
+		for _, entry := range entries {
+			result.Checked++
+			if entry.Hash == "" {
+				continue
+			}
+			result.Chained++
+			if entry.PrevHash != prev || entry.Hash != entry.ComputeHash() {
+				result.BrokenAt = entry.Seq
+				slog.WarnContext(ctx, "audit log hash chain is broken", "seq", entry.Seq)
+				return result, nil
+			}
+			prev = entry.Hash
+		}
+	}
+}
+
+// auditValue encodes v for an audit entry. Values that cannot be encoded
+// are recorded as a JSON string describing the failure.
+func auditValue(v any) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprintf("unencodable value: %v", err))
+	}
+	return data
+}
+
+// actorOf names the caller of ctx for audit records: the owner and
+// credential of its principal, or "system" for trusted callers.
+func actorOf(ctx context.Context) string {
+	p, ok := domain.PrincipalFrom(ctx)
+	if !ok {
+		return "system"
+	}
+	switch {
+	case p.KeyID != "":
+		return p.OwnerID + " (key " + p.KeyID + ")"
+	case p.Subject != "" && p.Subject != p.OwnerID:
+		return p.OwnerID + " (token " + p.Subject + ")"
+	}
+	return p.OwnerID
+}
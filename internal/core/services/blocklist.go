@@ -0,0 +1,295 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/itsbaivab/url-shortener/internal/core/ports"
+)
+
+const blockRuleIDLength = 12
+
+// BlocklistService screens destinations against block rules: rules
+// managed through the API and rules loaded from blocklist files, such as
+// exported phishing and malware feeds. Rules are compiled into an
+// in-memory set that Reload swaps atomically, so lists can change without
+// a restart and lookups never wait for storage.
+type BlocklistService struct {
+	port  ports.BlocklistPort
+	files []string
+	audit *AuditService
+	set   atomic.Pointer[blockSet]
+}
+
+// blockSet is a compiled set of rules.
+type blockSet struct {
+	rules    []domain.BlockRule
+	domains  map[string]domain.BlockRule
+	prefixes []domain.BlockRule
+	regexes  []blockRegex
+}
+
+type blockRegex struct {
+	rule    domain.BlockRule
+	pattern *regexp.Regexp
+}
+
+// NewBlocklistService screens against the rules of p. p may be nil, in
+// which case only file rules apply and rules cannot be managed. Nothing is
+// blocked until the first Reload.
+func NewBlocklistService(p ports.BlocklistPort) *BlocklistService {
+	service := &BlocklistService{port: p}
+	service.set.Store(&blockSet{domains: map[string]domain.BlockRule{}})
+	return service
+}
+
+// WithFiles loads rules from paths as well. Each line holds a kind, a
+// pattern and an optional reason separated by whitespace, or just a domain
+// name; blank lines and lines starting with # are skipped.
+func (service *BlocklistService) WithFiles(paths ...string) *BlocklistService {
+	service.files = append(service.files, paths...)
+	return service
+}
+
+// WithAudit records added and removed rules in a.
+func (service *BlocklistService) WithAudit(a *AuditService) *BlocklistService {
+	service.audit = a
+	return service
+}
+
+// Reload reads every rule again and replaces the active set. On failure
+// the previous set stays active. Invalid lines in files are logged and
+// skipped, so one bad entry in a feed does not hold back the rest.
+func (service *BlocklistService) Reload(ctx context.Context) error {
+	var rules []domain.BlockRule
+	if service.port != nil {
+		managed, err := service.port.All(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to load blocklist rules: %w", err)
+		}
+		rules = append(rules, managed...)
+	}
+	for _, path := range service.files {
+		loaded, err := readBlocklistFile(ctx, path)
+		if err != nil {
+			return err
+		}
+		rules = append(rules, loaded...)
+	}
+
+	set := &blockSet{domains: make(map[string]domain.BlockRule)}
+	for _, rule := range rules {
+		if err := set.add(rule); err != nil {
+			slog.WarnContext(ctx, "skipping invalid blocklist rule", "id", rule.Id, "source", rule.Source, "error", err)
+		}
+	}
+	service.set.Store(set)
+	return nil
+}
+
+// Check reports the rule that blocks raw, if any. Domain rules match the
+// host and its subdomains, prefix rules match case-insensitively and
+// regex rules are matched against the whole URL.
+func (service *BlocklistService) Check(raw string) (domain.BlockRule, bool) {
+	set := service.set.Load()
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return domain.BlockRule{}, false
+	}
+
+	for host := normalizeHost(u.Hostname()); host != ""; {
+		if rule, ok := set.domains[host]; ok {
+			return rule, true
+		}
+		_, parent, found := strings.Cut(host, ".")
+		if !found {
+			break
+		}
+		host = parent
+	}
+
+	lower := strings.ToLower(u.String())
+	for _, rule := range set.prefixes {
+		if strings.HasPrefix(lower, rule.Pattern) {
+			return rule, true
+		}
+	}
+	for _, r := range set.regexes {
+		if r.pattern.MatchString(u.String()) {
+			return r.rule, true
+		}
+	}
+	return domain.BlockRule{}, false
+}
+
+// All returns every active rule, managed rules first. Only operators may
+// list rules.
+func (service *BlocklistService) All(ctx context.Context) ([]domain.BlockRule, error) {
+	if err := requireOperator(ctx); err != nil {
+		return nil, err
+	}
+	return slices.Clone(service.set.Load().rules), nil
+}
+
+// Add stores a managed rule and reloads the active set. Only operators may
+// add rules.
+func (service *BlocklistService) Add(ctx context.Context, rule domain.BlockRule) (domain.BlockRule, error) {
+	if err := requireOperator(ctx); err != nil {
+		return domain.BlockRule{}, err
+	}
+	if service.port == nil {
+		return domain.BlockRule{}, &domain.ValidationError{Field: "kind", Reason: "managed blocklist rules are not enabled"}
+	}
+
+	rule, err := normalizeBlockRule(rule)
+	if err != nil {
+		return domain.BlockRule{}, err
+	}
+	if rule.Id, err = randomString(blockRuleIDLength); err != nil {
+		return domain.BlockRule{}, err
+	}
+	rule.Source = ""
+	rule.CreatedAt = time.Now()
+
+	if err := service.port.Create(ctx, rule); err != nil {
+		return domain.BlockRule{}, fmt.Errorf("failed to add blocklist rule: %w", err)
+	}
+	service.audit.Record(ctx, domain.AuditEntry{Action: domain.AuditBlockRuleAdded, WorkspaceID: domain.DefaultWorkspaceID, ResourceID: rule.Id}, nil, rule)
+	if err := service.Reload(ctx); err != nil {
+		return domain.BlockRule{}, err
+	}
+	return rule, nil
+}
+
+// Remove deletes a managed rule and reloads the active set. Rules loaded
+// from files are removed by editing the file. Only operators may remove
+// rules.
+func (service *BlocklistService) Remove(ctx context.Context, id string) error {
+	if err := requireOperator(ctx); err != nil {
+		return err
+	}
+	if service.port == nil {
+		return fmt.Errorf("blocklist rule %q: %w", id, domain.ErrNotFound)
+	}
+	if err := service.port.Delete(ctx, id); err != nil {
+		return fmt.Errorf("failed to remove blocklist rule '%s': %w", id, err)
+	}
+
+	var before any
+	rules := service.set.Load().rules
+	if i := slices.IndexFunc(rules, func(rule domain.BlockRule) bool { return rule.Id == id }); i >= 0 {
+		before = rules[i]
+	}
+	service.audit.Record(ctx, domain.AuditEntry{Action: domain.AuditBlockRuleRemoved, WorkspaceID: domain.DefaultWorkspaceID, ResourceID: id}, before, nil)
+	return service.Reload(ctx)
+}
+
+func (set *blockSet) add(rule domain.BlockRule) error {
+	rule, err := normalizeBlockRule(rule)
+	if err != nil {
+		return err
+	}
+
+	switch rule.Kind {
+	case domain.BlockDomain:
+		if _, exists := set.domains[rule.Pattern]; exists {
+			return nil
+		}
+		set.domains[rule.Pattern] = rule
+	case domain.BlockPrefix:
+		set.prefixes = append(set.prefixes, rule)
+	case domain.BlockRegex:
+		// normalizeBlockRule compiled the pattern already.
+		set.regexes = append(set.regexes, blockRegex{rule: rule, pattern: regexp.MustCompile(rule.Pattern)})
+	}
+	set.rules = append(set.rules, rule)
+	return nil
+}
+
+// normalizeBlockRule validates rule and puts its pattern in the form Check
+// compares against.
+func normalizeBlockRule(rule domain.BlockRule) (domain.BlockRule, error) {
+	rule.Kind = strings.ToLower(strings.TrimSpace(rule.Kind))
+	rule.Pattern = strings.TrimSpace(rule.Pattern)
+	rule.Reason = strings.TrimSpace(rule.Reason)
+
+	switch rule.Kind {
+	case domain.BlockDomain:
+		rule.Pattern = normalizeHost(rule.Pattern)
+		if !hostnamePattern.MatchString(rule.Pattern) {
+			return rule, &domain.ValidationError{Field: "pattern", Reason: fmt.Sprintf("%q is not a valid domain name", rule.Pattern)}
+		}
+	case domain.BlockPrefix:
+		u, err := url.Parse(rule.Pattern)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return rule, &domain.ValidationError{Field: "pattern", Reason: "prefix must be an absolute http or https URL"}
+		}
+		rule.Pattern = strings.ToLower(rule.Pattern)
+	case domain.BlockRegex:
+		if _, err := regexp.Compile(rule.Pattern); err != nil || rule.Pattern == "" {
+			return rule, &domain.ValidationError{Field: "pattern", Reason: fmt.Sprintf("%q is not a valid regular expression", rule.Pattern)}
+		}
+	default:
+		return rule, &domain.ValidationError{Field: "kind", Reason: fmt.Sprintf("kind must be one of %s", strings.Join(domain.BlockKinds, ", "))}
+	}
+
+	if rule.Reason == "" {
+		rule.Reason = "destination is blocklisted"
+	}
+	return rule, nil
+}
+
+// readBlocklistFile parses a blocklist file. A missing file is an error,
+// so a typo in the configuration does not silently disable screening.
+func readBlocklistFile(ctx context.Context, path string) ([]domain.BlockRule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open blocklist: %w", err)
+	}
+	defer f.Close()
+
+	var rules []domain.BlockRule
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		rule := domain.BlockRule{
+			Id:     fmt.Sprintf("%s:%d", filepath.Base(path), line),
+			Kind:   domain.BlockDomain,
+			Source: path,
+			Reason: "listed in " + filepath.Base(path),
+		}
+		fields := strings.Fields(text)
+		switch {
+		case len(fields) == 1:
+			rule.Pattern = fields[0]
+		case slices.Contains(domain.BlockKinds, fields[0]):
+			rule.Kind, rule.Pattern = fields[0], fields[1]
+			if reason := strings.Join(fields[2:], " "); reason != "" {
+				rule.Reason = reason
+			}
+		default:
+			slog.WarnContext(ctx, "skipping invalid blocklist line", "source", path, "line", line)
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read blocklist %s: %w", path, err)
+	}
+	return rules, nil
+}
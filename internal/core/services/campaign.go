@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/itsbaivab/url-shortener/internal/core/ports"
+)
+
+const (
+	campaignIDLength     = 12
+	maxCampaignNameLen   = 200
+	maxUTMParameterBytes = 256
+)
+
+// CampaignService manages the campaigns of the caller's workspace.
+type CampaignService struct {
+	port  ports.CampaignPort
+	audit *AuditService
+}
+
+func NewCampaignService(p ports.CampaignPort) *CampaignService {
+	return &CampaignService{port: p}
+}
+
+// WithAudit records campaign changes in a.
+func (service *CampaignService) WithAudit(a *AuditService) *CampaignService {
+	service.audit = a
+	return service
+}
+
+// All returns the campaigns of the caller's workspace, oldest first.
+func (service *CampaignService) All(ctx context.Context) ([]domain.Campaign, error) {
+	campaigns, err := service.port.All(ctx, domain.WorkspaceOf(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get campaigns: %w", err)
+	}
+	return campaigns, nil
+}
+
+func (service *CampaignService) Get(ctx context.Context, id string) (domain.Campaign, error) {
+	campaign, err := service.port.Get(ctx, domain.WorkspaceOf(ctx), id)
+	if err != nil {
+		return domain.Campaign{}, fmt.Errorf("failed to get campaign '%s': %w", id, err)
+	}
+	return campaign, nil
+}
+
+// Create adds a campaign to the caller's workspace under a new ID.
+func (service *CampaignService) Create(ctx context.Context, campaign domain.Campaign) (domain.Campaign, error) {
+	if err := validateCampaign(campaign); err != nil {
+		return domain.Campaign{}, err
+	}
+	id, err := randomString(campaignIDLength)
+	if err != nil {
+		return domain.Campaign{}, err
+	}
+	campaign.Id = id
+	campaign.WorkspaceID = domain.WorkspaceOf(ctx)
+	campaign.CreatedAt = time.Now()
+
+	if err := service.port.Create(ctx, campaign); err != nil {
+		return domain.Campaign{}, fmt.Errorf("failed to create campaign: %w", err)
+	}
+	service.audit.Record(ctx, campaignAuditEntry(domain.AuditCampaignCreated, campaign), nil, campaign)
+	return campaign, nil
+}
+
+// Update replaces the name and UTM template of a campaign. Links of the
+// campaign pick up the new template once their cache entry expires.
+func (service *CampaignService) Update(ctx context.Context, campaign domain.Campaign) (domain.Campaign, error) {
+	if err := validateCampaign(campaign); err != nil {
+		return domain.Campaign{}, err
+	}
+	before, err := service.Get(ctx, campaign.Id)
+	if err != nil {
+		return domain.Campaign{}, err
+	}
+	campaign.WorkspaceID = before.WorkspaceID
+	campaign.CreatedAt = before.CreatedAt
+
+	if err := service.port.Update(ctx, campaign); err != nil {
+		return domain.Campaign{}, fmt.Errorf("failed to update campaign '%s': %w", campaign.Id, err)
+	}
+	service.audit.Record(ctx, campaignAuditEntry(domain.AuditCampaignUpdated, campaign), before, campaign)
+	return campaign, nil
+}
+
+// Delete removes a campaign. Its links stay, without UTM parameters, and
+// their stats can still be counted under its ID.
+func (service *CampaignService) Delete(ctx context.Context, id string) error {
+	before, err := service.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := service.port.Delete(ctx, before.WorkspaceID, id); err != nil {
+		return fmt.Errorf("failed to delete campaign '%s': %w", id, err)
+	}
+	service.audit.Record(ctx, campaignAuditEntry(domain.AuditCampaignDeleted, before), before, nil)
+	return nil
+}
+
+func validateCampaign(campaign domain.Campaign) error {
+	if campaign.Name == "" || len(campaign.Name) > maxCampaignNameLen {
+		return &domain.ValidationError{Field: "name", Reason: fmt.Sprintf("name must be between 1 and %d bytes long", maxCampaignNameLen)}
+	}
+	if err := validateUTM("utm.defaults", campaign.UTM.Defaults); err != nil {
+		return err
+	}
+	for name, utm := range campaign.UTM.Platforms {
+		if _, ok := domain.ParsePlatform(name); !ok {
+			return &domain.ValidationError{Field: "utm.platforms", Reason: fmt.Sprintf("unknown platform %q", name)}
+		}
+		if err := validateUTM("utm.platforms."+name, utm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateUTM(field string, utm domain.UTM) error {
+	for name, values := range utm.Values() {
+		if len(values[0]) > maxUTMParameterBytes {
+			return &domain.ValidationError{Field: field, Reason: fmt.Sprintf("%s must be at most %d bytes long", name, maxUTMParameterBytes)}
+		}
+	}
+	return nil
+}
+
+func campaignAuditEntry(action string, campaign domain.Campaign) domain.AuditEntry {
+	return domain.AuditEntry{Action: action, WorkspaceID: campaign.WorkspaceID, ResourceID: campaign.Id}
+}
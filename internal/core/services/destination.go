@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/itsbaivab/url-shortener/internal/core/ports"
+	"golang.org/x/net/idna"
+)
+
+// forbiddenPrefixes are address ranges a destination may not resolve to:
+// they reach the deployment's own network rather than the internet.
+// Loopback, private, link-local, multicast and unspecified addresses are
+// covered by the netip predicates in forbiddenAddr.
+var forbiddenPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+}
+
+var hostLabelPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// DestinationValidator checks the URLs links redirect to. Destinations
+// must be absolute http(s) URLs to public hosts: anything that resolves to
+// a private, loopback or link-local address is refused, so short links
+// cannot be used to reach internal services, and links may not point back
+// at the shortener itself. Blocklisted destinations are refused too.
+type DestinationValidator struct {
+	resolver  ports.IPResolver
+	domains   ports.DomainPort
+	blocklist *BlocklistService
+	ownHosts  []string
+	maxLength int
+}
+
+func NewDestinationValidator(r ports.IPResolver) *DestinationValidator {
+	return &DestinationValidator{resolver: r, maxLength: domain.DefaultMaxURLLength}
+}
+
+// WithMaxLength sets the longest accepted destination.
+func (v *DestinationValidator) WithMaxLength(n int) *DestinationValidator {
+	v.maxLength = n
+	return v
+}
+
+// WithOwnHosts names the hosts the shortener is served on. Destinations
+// on them are refused.
+func (v *DestinationValidator) WithOwnHosts(hosts ...string) *DestinationValidator {
+	for _, host := range hosts {
+		v.ownHosts = append(v.ownHosts, normalizeHost(host))
+	}
+	return v
+}
+
+// WithDomains refuses destinations on verified custom domains as well.
+func (v *DestinationValidator) WithDomains(p ports.DomainPort) *DestinationValidator {
+	v.domains = p
+	return v
+}
+
+// WithBlocklist refuses destinations that b blocks.
+func (v *DestinationValidator) WithBlocklist(b *BlocklistService) *DestinationValidator {
+	v.blocklist = b
+	return v
+}
+
+// Validate checks raw and returns it normalised, with the host in its
+// ASCII form. Rejections are ValidationErrors whose Code names the rule
+// that failed; lookups that fail for other reasons are returned as is.
+func (v *DestinationValidator) Validate(ctx context.Context, raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", invalidDestination(domain.CodeInvalidURL, "URL cannot be empty")
+	}
+	if len(raw) > v.maxLength {
+		return "", invalidDestination(domain.CodeURLTooLong, fmt.Sprintf("URL must be at most %d characters long", v.maxLength))
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || !u.IsAbs() {
+		return "", invalidDestination(domain.CodeInvalidURL, "URL must be an absolute http or https URL")
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", invalidDestination(domain.CodeUnsupportedScheme, fmt.Sprintf("URL scheme %q is not allowed; use http or https", u.Scheme))
+	}
+	if u.Opaque != "" || u.Host == "" {
+		return "", invalidDestination(domain.CodeInvalidURL, "URL must be an absolute http or https URL")
+	}
+	if u.User != nil {
+		return "", invalidDestination(domain.CodeCredentialsInURL, "URL must not contain credentials")
+	}
+
+	host, addr, err := destinationHost(u.Hostname())
+	if err != nil {
+		return "", err
+	}
+	switch port := u.Port(); {
+	case port != "":
+		u.Host = net.JoinHostPort(host, port)
+	case addr.Is6():
+		u.Host = "[" + host + "]"
+	default:
+		u.Host = host
+	}
+
+	if err := v.checkSelfReference(ctx, host); err != nil {
+		return "", err
+	}
+	if v.blocklist != nil {
+		if rule, blocked := v.blocklist.Check(u.String()); blocked {
+			return "", invalidDestination(domain.CodeBlockedDestination, "URL is blocklisted: "+rule.Reason)
+		}
+	}
+	if err := v.checkAddresses(ctx, host, addr); err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+// destinationHost returns host in ASCII form, and its address when host
+// is an IP literal.
+func destinationHost(host string) (string, netip.Addr, error) {
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return addr.String(), addr, nil
+	}
+
+	ascii, err := idna.Lookup.ToASCII(strings.TrimSuffix(host, "."))
+	if err != nil {
+		return "", netip.Addr{}, invalidDestination(domain.CodeInvalidHost, fmt.Sprintf("host %q is not a valid domain name", host))
+	}
+	labels := strings.Split(ascii, ".")
+	valid := len(labels) > 1 && !isNumeric(labels[len(labels)-1])
+	for _, label := range labels {
+		valid = valid && hostLabelPattern.MatchString(label)
+	}
+	if !valid {
+		return "", netip.Addr{}, invalidDestination(domain.CodeInvalidHost, fmt.Sprintf("host %q must be a fully qualified domain name", host))
+	}
+	return ascii, netip.Addr{}, nil
+}
+
+func (v *DestinationValidator) checkSelfReference(ctx context.Context, host string) error {
+	self := invalidDestination(domain.CodeSelfReference, "URL must not point to this URL shortener")
+	if slices.Contains(v.ownHosts, host) {
+		return self
+	}
+	if v.domains == nil {
+		return nil
+	}
+	d, err := v.domains.Get(ctx, host)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check destination host: %w", err)
+	}
+	if d.Verified() {
+		return self
+	}
+	return nil
+}
+
+// checkAddresses refuses hosts that are or resolve to a forbidden
+// address. Every address is checked, since a client may connect to any.
+func (v *DestinationValidator) checkAddresses(ctx context.Context, host string, addr netip.Addr) error {
+	addrs := []netip.Addr{addr}
+	if !addr.IsValid() {
+		var err error
+		addrs, err = v.resolver.LookupIP(ctx, host)
+		if errors.Is(err, domain.ErrNotFound) || (err == nil && len(addrs) == 0) {
+			return invalidDestination(domain.CodeUnresolvableHost, fmt.Sprintf("host %q does not resolve", host))
+		}
+		if err != nil {
+			return fmt.Errorf("failed to resolve destination host: %w", err)
+		}
+	}
+
+	for _, a := range addrs {
+		if forbiddenAddr(a) {
+			return invalidDestination(domain.CodeForbiddenAddress, fmt.Sprintf("host %q resolves to a non-public address", host))
+		}
+	}
+	return nil
+}
+
+func forbiddenAddr(a netip.Addr) bool {
+	a = a.Unmap()
+	if a.IsLoopback() || a.IsPrivate() || a.IsLinkLocalUnicast() || a.IsLinkLocalMulticast() ||
+		a.IsInterfaceLocalMulticast() || a.IsMulticast() || a.IsUnspecified() {
+		return true
+	}
+	for _, prefix := range forbiddenPrefixes {
+		if prefix.Contains(a) {
+			return true
+		}
+	}
+	return false
+}
+
+func isNumeric(s string) bool {
+	return strings.Trim(s, "0123456789") == ""
+}
+
+func invalidDestination(code, reason string) error {
+	return &domain.ValidationError{Field: "long", Reason: reason, Code: code}
+}
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/itsbaivab/url-shortener/internal/core/ports"
+)
+
+// domainCacheTTL bounds how long a host keeps resolving to a workspace
+// after its domain is removed.
+const domainCacheTTL = time.Minute
+
+// maxResolvedDomains caps the hosts Resolve remembers, as clients can
+// send any Host header.
+const maxResolvedDomains = 10000
+
+const domainTokenLength = 32
+
+var hostnamePattern = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,63}$`)
+
+type DomainService struct {
+	port     ports.DomainPort
+	resolver ports.TXTResolver
+	audit    *AuditService
+
+	mu       sync.Mutex
+	resolved map[string]resolvedDomain
+}
+
+type resolvedDomain struct {
+	workspaceID string
+	expires     time.Time
+}
+
+func NewDomainService(p ports.DomainPort, r ports.TXTResolver) *DomainService {
+	return &DomainService{port: p, resolver: r, resolved: map[string]resolvedDomain{}}
+}
+
+// WithAudit records added, verified and removed domains in a.
+func (service *DomainService) WithAudit(a *AuditService) *DomainService {
+	service.audit = a
+	return service
+}
+
+// All returns the custom domains of the caller's workspace.
+func (service *DomainService) All(ctx context.Context) ([]domain.CustomDomain, error) {
+	domains, err := service.port.All(ctx, domain.WorkspaceOf(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domains: %w", err)
+	}
+	return domains, nil
+}
+
+// Get returns a custom domain of the caller's workspace. Domains of other
+// workspaces are reported as not found.
+func (service *DomainService) Get(ctx context.Context, name string) (domain.CustomDomain, error) {
+	name = normalizeHost(name)
+	d, err := service.port.Get(ctx, name)
+	if err != nil {
+		return domain.CustomDomain{}, fmt.Errorf("failed to get domain '%s': %w", name, err)
+	}
+	if d.WorkspaceID != domain.WorkspaceOf(ctx) {
+		return domain.CustomDomain{}, fmt.Errorf("domain %q: %w", name, domain.ErrNotFound)
+	}
+	return d, nil
+}
+
+// Add registers name for the caller's workspace and returns it with the
+// token to publish. A pending registration by another workspace is
+// replaced, so unverified claims cannot hold a domain hostage; a verified
+// domain has to be removed by its workspace first.
+func (service *DomainService) Add(ctx context.Context, name string) (domain.CustomDomain, error) {
+	if err := requireAdmin(ctx, "domain management"); err != nil {
+		return domain.CustomDomain{}, err
+	}
+	name = normalizeHost(strings.TrimSpace(name))
+	if !hostnamePattern.MatchString(name) {
+		return domain.CustomDomain{}, &domain.ValidationError{Field: "name", Reason: fmt.Sprintf("%q is not a valid domain name", name)}
+	}
+	workspaceID := domain.WorkspaceOf(ctx)
+
+	existing, err := service.port.Get(ctx, name)
+	switch {
+	case err == nil && (existing.Verified() || existing.WorkspaceID == workspaceID):
+		return domain.CustomDomain{}, fmt.Errorf("domain %q is already registered: %w", name, domain.ErrConflict)
+	case err == nil:
+		if err := service.port.Delete(ctx, existing.WorkspaceID, name); err != nil && !errors.Is(err, domain.ErrNotFound) {
+			return domain.CustomDomain{}, fmt.Errorf("failed to replace pending domain '%s': %w", name, err)
+		}
+	case !errors.Is(err, domain.ErrNotFound):
+		return domain.CustomDomain{}, fmt.Errorf("failed to get domain '%s': %w", name, err)
+	}
+
+	token, err := randomString(domainTokenLength)
+	if err != nil {
+		return domain.CustomDomain{}, err
+	}
+	d := domain.CustomDomain{Name: name, WorkspaceID: workspaceID, Token: token, CreatedAt: time.Now()}
+	if err := service.port.Create(ctx, d); err != nil {
+		return domain.CustomDomain{}, fmt.Errorf("failed to add domain '%s': %w", name, err)
+	}
+	service.audit.Record(ctx, domain.AuditEntry{Action: domain.AuditDomainAdded, WorkspaceID: workspaceID, ResourceID: name}, nil, d)
+	return d, nil
+}
+
+// Verify checks that the verification record of a domain of the caller's
+// workspace is published and, if so, starts routing the domain.
+func (service *DomainService) Verify(ctx context.Context, name string) (domain.CustomDomain, error) {
+	if err := requireAdmin(ctx, "domain management"); err != nil {
+		return domain.CustomDomain{}, err
+	}
+	d, err := service.Get(ctx, name)
+	if err != nil {
+		return domain.CustomDomain{}, err
+	}
+	if d.Verified() {
+		return d, nil
+	}
+
+	records, err := service.resolver.LookupTXT(ctx, d.TXTName())
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return domain.CustomDomain{}, fmt.Errorf("failed to look up TXT records of '%s': %w", d.TXTName(), err)
+	}
+	published := slices.ContainsFunc(records, func(record string) bool {
+		return strings.TrimSpace(record) == d.TXTValue()
+	})
+	if !published {
+		return domain.CustomDomain{}, &domain.ValidationError{
+			Field:  "name",
+			Reason: fmt.Sprintf("no TXT record %q found at %s", d.TXTValue(), d.TXTName()),
+		}
+	}
+
+	now := time.Now()
+	if err := service.port.Verify(ctx, d.Name, now); err != nil {
+		return domain.CustomDomain{}, fmt.Errorf("failed to verify domain '%s': %w", d.Name, err)
+	}
+	service.forget()
+	verified := d
+	verified.VerifiedAt = &now
+	service.audit.Record(ctx, domain.AuditEntry{Action: domain.AuditDomainVerified, WorkspaceID: d.WorkspaceID, ResourceID: d.Name}, d, verified)
+	return verified, nil
+}
+
+// Remove unregisters a domain of the caller's workspace. Its links stay
+// in the workspace but stop redirecting.
+func (service *DomainService) Remove(ctx context.Context, name string) error {
+	if err := requireAdmin(ctx, "domain management"); err != nil {
+		return err
+	}
+	name = normalizeHost(name)
+	var before any
+	if d, err := service.Get(ctx, name); err == nil {
+		before = d
+	}
+	if err := service.port.Delete(ctx, domain.WorkspaceOf(ctx), name); err != nil {
+		return fmt.Errorf("failed to remove domain '%s': %w", name, err)
+	}
+	service.forget()
+
+	service.audit.Record(ctx, domain.AuditEntry{Action: domain.AuditDomainRemoved, WorkspaceID: domain.WorkspaceOf(ctx), ResourceID: name}, before, nil)
+	return nil
+}
+
+// Resolve maps host, a Host header value with or without a port, to the
+// workspace that verified it and the domain name its links are stored
+// under. ok is false when no workspace verified host. Results, including
+// misses, are cached for domainCacheTTL; hosts that are not valid domain
+// names are never looked up.
+func (service *DomainService) Resolve(ctx context.Context, host string) (workspaceID, name string, ok bool, err error) {
+	name = normalizeHost(host)
+	if !hostnamePattern.MatchString(name) {
+		return "", "", false, nil
+	}
+
+	service.mu.Lock()
+	entry, cached := service.resolved[name]
+	if cached && !time.Now().Before(entry.expires) {
+		delete(service.resolved, name)
+		cached = false
+	}
+	service.mu.Unlock()
+	if cached {
+		return entry.workspaceID, name, entry.workspaceID != "", nil
+	}
+
+	d, err := service.port.Get(ctx, name)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return "", "", false, fmt.Errorf("failed to resolve domain '%s': %w", name, err)
+	}
+	if !d.Verified() {
+		d.WorkspaceID = ""
+	}
+
+	service.remember(name, d.WorkspaceID)
+	return d.WorkspaceID, name, d.WorkspaceID != "", nil
+}
+
+// remember caches the workspace of name. A full cache first drops its
+// expired entries, then a tenth of the others.
+func (service *DomainService) remember(name, workspaceID string) {
+	now := time.Now()
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	if len(service.resolved) >= maxResolvedDomains {
+		for host, entry := range service.resolved {
+			if !now.Before(entry.expires) {
+				delete(service.resolved, host)
+			}
+		}
+		for host := range service.resolved {
+			if len(service.resolved) < maxResolvedDomains*9/10 {
+				break
+			}
+			delete(service.resolved, host)
+		}
+	}
+	service.resolved[name] = resolvedDomain{workspaceID: workspaceID, expires: now.Add(domainCacheTTL)}
+}
+
+func (service *DomainService) forget() {
+	service.mu.Lock()
+	clear(service.resolved)
+	service.mu.Unlock()
+}
+
+// normalizeHost lowercases host and strips any port and trailing dot.
+func normalizeHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
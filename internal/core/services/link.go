@@ -2,54 +2,693 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/itsbaivab/url-shortener/internal/core/domain"
 	"github.com/itsbaivab/url-shortener/internal/core/ports"
+	"github.com/itsbaivab/url-shortener/internal/logging"
+)
+
+// scanPageSize is how many links DisableFlagged checks per page.
+const scanPageSize = 500
+
+var (
+	variantIDPattern   = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+	countryCodePattern = regexp.MustCompile(`^[A-Z]{2}$`)
 )
 
 type LinkService struct {
-	port  ports.LinkPort
-	cache ports.Cache
+	port       ports.LinkPort
+	cache      ports.Cache
+	metrics    ports.Metrics
+	workspaces ports.WorkspacePort
+	domains    ports.DomainPort
+	blocklist  *BlocklistService
+	audit      *AuditService
+	signing    *SigningService
+	campaigns  ports.CampaignPort
+
+	dailyCounter ports.RateLimiter
+	dailyLinks   int
 }
 
 func NewLinkService(p ports.LinkPort, c ports.Cache) *LinkService {
-	return &LinkService{port: p, cache: c}
+	return &LinkService{port: p, cache: c, metrics: ports.NopMetrics{}}
+}
+
+// WithMetrics reports service events to m.
+func (service *LinkService) WithMetrics(m ports.Metrics) *LinkService {
+	service.metrics = m
+	return service
+}
+
+// WithWorkspaces enforces the quotas and settings of each workspace.
+// Without it every workspace gets the deployment defaults and no quotas.
+func (service *LinkService) WithWorkspaces(p ports.WorkspacePort) *LinkService {
+	service.workspaces = p
+	return service
+}
+
+// WithDomains lets links be created on the custom domains of their
+// workspace. Without it links can only use the shared domain.
+func (service *LinkService) WithDomains(p ports.DomainPort) *LinkService {
+	service.domains = p
+	return service
+}
+
+// WithBlocklist stops redirects to blocklisted destinations and lets
+// DisableFlagged take down the links pointing at them.
+func (service *LinkService) WithBlocklist(b *BlocklistService) *LinkService {
+	service.blocklist = b
+	return service
+}
+
+// WithAudit records link changes in a.
+func (service *LinkService) WithAudit(a *AuditService) *LinkService {
+	service.audit = a
+	return service
 }
 
+// WithSigning lets links require signed URLs, signed and verified by s.
+// Without it such links cannot be created and never redirect.
+func (service *LinkService) WithSigning(s *SigningService) *LinkService {
+	service.signing = s
+	return service
+}
+
+// WithCampaigns lets links join campaigns of their workspace and merges
+// the campaign's UTM template into their destination when they redirect.
+func (service *LinkService) WithCampaigns(p ports.CampaignPort) *LinkService {
+	service.campaigns = p
+	return service
+}
+
+// WithDailyQuota limits each owner to perOwner new links per UTC day,
+// counted in counter. Links without an owner are not limited.
+func (service *LinkService) WithDailyQuota(counter ports.RateLimiter, perOwner int) *LinkService {
+	service.dailyCounter = counter
+	service.dailyLinks = perOwner
+	return service
+}
+
+// GetAll returns the caller's links, or every link of the workspace for
+// admins and trusted callers.
 func (service *LinkService) GetAll(ctx context.Context) ([]domain.Link, error) {
-	links, err := service.port.All(ctx)
+	workspaceID := domain.WorkspaceOf(ctx)
+
+	var links []domain.Link
+	var err error
+	if p, ok := domain.PrincipalFrom(ctx); ok && !p.HasScope(domain.ScopeAdmin) {
+		links, err = service.port.AllByOwner(ctx, workspaceID, p.OwnerID)
+	} else {
+		links, err = service.port.All(ctx, workspaceID)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all links: %w", err)
 	}
 	return links, nil
 }
 
+// Get returns a link of the custom domain of ctx that the caller may
+// access. Links owned by someone else are reported as not found so their
+// IDs cannot be probed.
+func (service *LinkService) Get(ctx context.Context, id string) (domain.Link, error) {
+	link, err := service.port.Get(ctx, domain.WorkspaceOf(ctx), domain.LinkDomainOf(ctx), id)
+	if err != nil {
+		return domain.Link{}, fmt.Errorf("failed to get link '%s': %w", id, err)
+	}
+	if p, ok := domain.PrincipalFrom(ctx); ok && !p.CanAccess(link.OwnerID) {
+		return domain.Link{}, fmt.Errorf("link %q: %w", id, domain.ErrNotFound)
+	}
+	return link, nil
+}
+
+// GetOriginalURL resolves a short link to its destination; see Resolve.
+// Disabled links and links whose destination is blocklisted fail with a
+// *domain.DisabledError; only the former are reported as disabled by the
+// repository, the latter are caught here until DisableFlagged runs.
+// Links that require a signed URL fail with a *domain.SignatureError
+// unless ctx carries a valid signature; see domain.WithURLSignature.
+// Password protected links fail with a *domain.PasswordRequiredError.
 func (service *LinkService) GetOriginalURL(ctx context.Context, shortLinkKey string) (*string, error) {
-	// link, err := service.cache.Get(ctx, shortLinkKey)
-	data, err := service.port.Get(ctx, shortLinkKey)
+	target, err := service.Resolve(ctx, shortLinkKey, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get short URL for identifier '%s': %w", shortLinkKey, err)
+		return nil, err
+	}
+	return &target.URL, nil
+}
+
+// Resolve resolves a short link to where and how it redirects, reading
+// through the cache. Cache failures are logged and fall back to the
+// repository. It fails like GetOriginalURL, but leaves the decision on
+// links that are not public to unlock, after their signature was
+// checked; unlock must check the password of protected links. The error
+// unlock returns is passed on. Only public links are cached, so every
+// redirect of the others goes through these checks.
+func (service *LinkService) Resolve(ctx context.Context, shortLinkKey string, unlock func(domain.Link) error) (domain.Target, error) {
+	ctx = logging.With(ctx, "link_id", shortLinkKey)
+	workspaceID, host := domain.WorkspaceOf(ctx), domain.LinkDomainOf(ctx)
+	key := cacheKey(workspaceID, host, shortLinkKey)
+
+	cached, err := service.cache.Get(ctx, key)
+	if err != nil {
+		slog.WarnContext(ctx, "cache lookup failed", "error", err)
+	}
+	if err == nil && cached != "" {
+		target, err := domain.DecodeTarget(cached)
+		if err == nil {
+			if err := service.checkBlocklist(shortLinkKey, target); err != nil {
+				return domain.Target{}, err
+			}
+			service.metrics.CacheLookup(true)
+			service.metrics.Redirect(ports.RedirectHit)
+			service.metrics.LinkClicked(key)
+			return target, nil
+		}
+		slog.WarnContext(ctx, "ignoring cached short URL", "error", err)
+	}
+	service.metrics.CacheLookup(false)
+
+	data, err := service.port.Get(ctx, workspaceID, host, shortLinkKey)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			service.metrics.Redirect(ports.RedirectNotFound)
+		case errors.Is(err, domain.ErrExpired):
+			service.metrics.Redirect(ports.RedirectExpired)
+		}
+		return domain.Target{}, fmt.Errorf("failed to get short URL for identifier '%s': %w", shortLinkKey, err)
+	}
+	if data.Disabled() {
+		service.metrics.Redirect(ports.RedirectDisabled)
+		return domain.Target{}, &domain.DisabledError{LinkID: shortLinkKey, Reason: data.DisabledReason}
+	}
+	if err := service.checkBlocklist(shortLinkKey, data.Target()); err != nil {
+		return domain.Target{}, err
+	}
+
+	if err := service.checkSignature(ctx, data); err != nil {
+		return domain.Target{}, err
+	}
+	if !data.Public() {
+		switch {
+		case unlock != nil:
+			err = unlock(data)
+		case data.Protected():
+			err = &domain.PasswordRequiredError{LinkID: shortLinkKey}
+		}
+		if err != nil {
+			service.metrics.Redirect(ports.RedirectProtected)
+			return domain.Target{}, err
+		}
+	}
+
+	target := service.target(ctx, data)
+	if data.Public() {
+		if err := service.cache.Set(ctx, key, domain.EncodeTarget(target)); err != nil {
+			slog.WarnContext(ctx, "failed to cache short URL", "error", err)
+		}
 	}
-	return &data.OriginalURL, nil
+	service.metrics.Redirect(ports.RedirectMiss)
+	service.metrics.LinkClicked(key)
+	return target, nil
 }
 
+// DryRun returns where the link id of the custom domain of ctx would
+// send visitor, and the URL it would redirect to, without counting a
+// click. Only the link's owner, or an admin, may ask. Passwords,
+// signatures and takedowns are not checked.
+func (service *LinkService) DryRun(ctx context.Context, id string, visitor domain.Visitor) (domain.Choice, string, error) {
+	link, err := service.Get(ctx, id)
+	if err != nil {
+		return domain.Choice{}, "", err
+	}
+	choice := service.target(ctx, link).Choose(visitor)
+	location, err := choice.Target.Location("", nil, visitor.Platform)
+	if err != nil {
+		return domain.Choice{}, "", err
+	}
+	return choice, location, nil
+}
+
+// NewLinkID returns a random link ID with the length configured for the
+// caller's workspace.
+func (service *LinkService) NewLinkID(ctx context.Context) (string, error) {
+	workspace, err := service.workspace(ctx)
+	if err != nil {
+		return "", err
+	}
+	length := workspace.Settings.ShortIDLength
+	if length <= 0 {
+		length = domain.DefaultShortIDLength
+	}
+	return randomString(length)
+}
+
+// Create stores link in the caller's workspace, owned by the caller when
+// there is one. link.Domain must be a verified domain of the workspace;
+// when empty, links outside the default workspace use the workspace's
+// first verified domain. The workspace link quota and the owner's daily
+// quota are checked first; concurrent creates may overshoot the former
+// slightly.
 func (service *LinkService) Create(ctx context.Context, link domain.Link) error {
-	// if err := service.cache.Set(ctx, link.Id, link.OriginalURL); err != nil {
-	// 	return fmt.Errorf("failed to set short URL for identifier '%s': %w", link.Id, err)
-	// }
+	link.WorkspaceID = domain.WorkspaceOf(ctx)
+	if p, ok := domain.PrincipalFrom(ctx); ok {
+		link.OwnerID = p.OwnerID
+	}
+	host, err := service.LinkDomain(ctx, link.Domain)
+	if err != nil {
+		return err
+	}
+	link.Domain = host
+	if !link.RedirectType.Valid() {
+		return &domain.ValidationError{Field: "redirect_type", Reason: fmt.Sprintf("unknown redirect type %q", link.RedirectType)}
+	}
+	if link.RequireSignature && !service.signing.Enabled() {
+		return &domain.ValidationError{Field: "require_signature", Reason: "signed URLs are not enabled"}
+	}
+	if err := service.checkCampaign(ctx, link); err != nil {
+		return err
+	}
+	if err := checkVariants(link.Variants); err != nil {
+		return err
+	}
+	if err := checkRules(link.Rules); err != nil {
+		return err
+	}
+	if err := checkGeoRules(link.GeoRules); err != nil {
+		return err
+	}
+
+	workspace, err := service.workspace(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create short URL: %w", err)
+	}
+	if limit := workspace.Quotas.MaxLinks; limit > 0 {
+		count, err := service.port.Count(ctx, link.WorkspaceID)
+		if err != nil {
+			return fmt.Errorf("failed to count links: %w", err)
+		}
+		if count >= limit {
+			return fmt.Errorf("workspace %q is limited to %d links: %w", link.WorkspaceID, limit, domain.ErrQuotaExceeded)
+		}
+	}
+
+	if err := service.checkDailyQuota(ctx, link); err != nil {
+		return err
+	}
+
 	if err := service.port.Create(ctx, link); err != nil {
 		return fmt.Errorf("failed to create short URL: %w", err)
 	}
+	service.metrics.LinkCreated()
+	service.audit.Record(ctx, linkAuditEntry(domain.AuditLinkCreated, link, ""), nil, link)
 	return nil
 }
 
+// Delete removes a link of the custom domain of ctx. The link is read
+// first, so callers without the admin scope can only delete their own
+// links and the audit log can record what was deleted.
 func (service *LinkService) Delete(ctx context.Context, short string) error {
-	if err := service.port.Delete(ctx, short); err != nil {
+	link, getErr := service.Get(ctx, short)
+	if p, ok := domain.PrincipalFrom(ctx); ok && !p.HasScope(domain.ScopeAdmin) && getErr != nil {
+		return fmt.Errorf("failed to delete short URL for identifier '%s': %w", short, getErr)
+	}
+	workspaceID, host := domain.WorkspaceOf(ctx), domain.LinkDomainOf(ctx)
+	if err := service.port.Delete(ctx, workspaceID, host, short); err != nil {
 		return fmt.Errorf("failed to delete short URL for identifier '%s': %w", short, err)
 	}
-	// if err := service.cache.Delete(ctx, short); err != nil {
-	// 	return fmt.Errorf("failed to delete short URL for identifier '%s': %w", short, err)
-	// }
+	if err := service.cache.Delete(ctx, cacheKey(workspaceID, host, short)); err != nil {
+		slog.WarnContext(ctx, "failed to evict short URL", "link_id", short, "error", err)
+	}
+
+	var before any
+	if getErr == nil {
+		before = link
+	}
+	service.audit.Record(ctx, linkAuditEntry(domain.AuditLinkDeleted, domain.Link{Id: short, WorkspaceID: workspaceID, Domain: host}, ""), before, nil)
+	return nil
+}
+
+// SignURL signs a URL for the link id of the custom domain of ctx, valid
+// for ttl or the default lifetime when ttl is zero. Only links that
+// require a signature are signed, for callers who may access them.
+func (service *LinkService) SignURL(ctx context.Context, id string, ttl time.Duration) (domain.Link, domain.URLSignature, error) {
+	link, err := service.Get(ctx, id)
+	if err != nil {
+		return domain.Link{}, domain.URLSignature{}, err
+	}
+	if !link.RequireSignature {
+		return domain.Link{}, domain.URLSignature{}, &domain.ValidationError{Field: "id", Reason: fmt.Sprintf("link %q does not require a signed URL", id)}
+	}
+	signature, err := service.signing.Sign(link, ttl)
+	if err != nil {
+		return domain.Link{}, domain.URLSignature{}, err
+	}
+	return link, signature, nil
+}
+
+// Disable takes link down for reason. It keeps the link so its owner can
+// see why it stopped redirecting, and evicts it from the cache.
+func (service *LinkService) Disable(ctx context.Context, link domain.Link, reason string) error {
+	now := time.Now()
+	if err := service.port.Disable(ctx, link.WorkspaceID, link.Domain, link.Id, reason, now); err != nil {
+		return fmt.Errorf("failed to disable short URL for identifier '%s': %w", link.Id, err)
+	}
+	if err := service.cache.Delete(ctx, cacheKey(link.WorkspaceID, link.Domain, link.Id)); err != nil {
+		slog.WarnContext(ctx, "failed to evict short URL", "link_id", link.Id, "error", err)
+	}
+
+	after := link
+	after.DisabledAt, after.DisabledReason = &now, reason
+	service.audit.Record(ctx, linkAuditEntry(domain.AuditLinkDisabled, link, reason), link, after)
 	return nil
 }
+
+// Restore lets a disabled link redirect again; note says why. Links whose
+// destination is still blocklisted cannot be restored, as DisableFlagged
+// would take them down again.
+func (service *LinkService) Restore(ctx context.Context, link domain.Link, note string) error {
+	if service.blocklist != nil {
+		if rule, blocked := service.blocklist.Check(link.OriginalURL); blocked {
+			return &domain.ValidationError{Field: "id", Reason: fmt.Sprintf("destination is still blocklisted (%s); remove the rule first", rule.Reason), Code: domain.CodeBlockedDestination}
+		}
+	}
+	if err := service.port.Enable(ctx, link.WorkspaceID, link.Domain, link.Id); err != nil {
+		return fmt.Errorf("failed to restore short URL for identifier '%s': %w", link.Id, err)
+	}
+
+	after := link
+	after.DisabledAt, after.DisabledReason = nil, ""
+	service.audit.Record(ctx, linkAuditEntry(domain.AuditLinkRestored, link, note), link, after)
+	return nil
+}
+
+// DisableFlagged reloads the blocklist, checks the destination of every
+// link of every workspace against it and disables the links it blocks. It
+// returns how many links were disabled. Links that fail to disable are
+// logged and retried on the next run. Only operators may run it.
+func (service *LinkService) DisableFlagged(ctx context.Context) (int, error) {
+	if err := requireOperator(ctx); err != nil {
+		return 0, err
+	}
+	if service.blocklist == nil {
+		return 0, nil
+	}
+	if err := service.blocklist.Reload(ctx); err != nil {
+		return 0, err
+	}
+
+	disabled := 0
+	var after domain.Link
+	for {
+		links, err := service.port.Scan(ctx, after, scanPageSize)
+		if err != nil {
+			return disabled, fmt.Errorf("failed to scan links: %w", err)
+		}
+		if len(links) == 0 {
+			return disabled, nil
+		}
+		after = links[len(links)-1]
+
+		for _, link := range links {
+			if link.Disabled() {
+				continue
+			}
+			rule, blocked := service.flagged(link)
+			if !blocked {
+				continue
+			}
+			if err := service.Disable(ctx, link, rule.Reason); err != nil {
+				slog.WarnContext(ctx, "failed to disable flagged link", "link_id", link.Id, "workspace_id", link.WorkspaceID, "error", err)
+				continue
+			}
+			slog.InfoContext(ctx, "disabled flagged link", "link_id", link.Id, "workspace_id", link.WorkspaceID, "rule_id", rule.Id, "reason", rule.Reason)
+			disabled++
+		}
+	}
+}
+
+// flagged returns the blocklist rule matching a destination of link.
+func (service *LinkService) flagged(link domain.Link) (domain.BlockRule, bool) {
+	for _, destination := range link.Target().Destinations() {
+		if rule, blocked := service.blocklist.Check(destination); blocked {
+			return rule, true
+		}
+	}
+	return domain.BlockRule{}, false
+}
+
+// checkSignature fails requests for links that require a signed URL
+// unless ctx carries a valid signature for the link.
+func (service *LinkService) checkSignature(ctx context.Context, link domain.Link) error {
+	if !link.RequireSignature {
+		return nil
+	}
+	var err error = &domain.SignatureError{LinkID: link.Id}
+	if service.signing != nil {
+		err = service.signing.Verify(link, domain.URLSignatureOf(ctx), time.Now())
+	}
+	switch {
+	case errors.Is(err, domain.ErrExpired):
+		service.metrics.Redirect(ports.RedirectExpired)
+	case err != nil:
+		service.metrics.Redirect(ports.RedirectUnsigned)
+	}
+	return err
+}
+
+// target returns where link redirects, with the UTM template of its
+// campaign. A campaign that cannot be read is logged and skipped, so the
+// link still redirects.
+func (service *LinkService) target(ctx context.Context, link domain.Link) domain.Target {
+	target := link.Target()
+	if link.CampaignID == "" || service.campaigns == nil {
+		return target
+	}
+	campaign, err := service.campaigns.Get(ctx, link.WorkspaceID, link.CampaignID)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to get campaign of link", "campaign_id", link.CampaignID, "error", err)
+		return target
+	}
+	target.UTM = &campaign.UTM
+	return target
+}
+
+// checkCampaign fails links that name a campaign their workspace does not
+// have.
+func (service *LinkService) checkCampaign(ctx context.Context, link domain.Link) error {
+	if link.CampaignID == "" {
+		return nil
+	}
+	if service.campaigns == nil {
+		return &domain.ValidationError{Field: "campaign_id", Reason: "campaigns are not enabled"}
+	}
+	_, err := service.campaigns.Get(ctx, link.WorkspaceID, link.CampaignID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return &domain.ValidationError{Field: "campaign_id", Reason: fmt.Sprintf("unknown campaign %q", link.CampaignID)}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get campaign '%s': %w", link.CampaignID, err)
+	}
+	return nil
+}
+
+// checkVariants validates the variants of a new link. Their URLs are
+// validated as destinations by the caller, like the original URL.
+func checkVariants(variants []domain.Variant) error {
+	if len(variants) == 0 {
+		return nil
+	}
+	if len(variants) < 2 || len(variants) > domain.MaxVariants {
+		return &domain.ValidationError{Field: "variants", Reason: fmt.Sprintf("a link must have between 2 and %d variants", domain.MaxVariants)}
+	}
+	seen := map[string]bool{}
+	for _, v := range variants {
+		if !variantIDPattern.MatchString(v.ID) {
+			return &domain.ValidationError{Field: "variants", Reason: fmt.Sprintf("variant ID %q must be 1 to %d letters, digits, '-' or '_'", v.ID, domain.MaxVariantIDLen)}
+		}
+		if seen[v.ID] {
+			return &domain.ValidationError{Field: "variants", Reason: fmt.Sprintf("duplicate variant ID %q", v.ID)}
+		}
+		seen[v.ID] = true
+		if v.Weight < 1 || v.Weight > domain.MaxVariantWeight {
+			return &domain.ValidationError{Field: "variants", Reason: fmt.Sprintf("weight of variant %q must be between 1 and %d", v.ID, domain.MaxVariantWeight)}
+		}
+		if v.URL == "" {
+			return &domain.ValidationError{Field: "variants", Reason: fmt.Sprintf("variant %q has no URL", v.ID)}
+		}
+	}
+	return nil
+}
+
+// checkRules validates the routing rules of a new link. Their web URLs
+// are validated as destinations by the caller, like the original URL.
+func checkRules(rules []domain.RoutingRule) error {
+	if len(rules) > len(domain.DeviceMatches) {
+		return &domain.ValidationError{Field: "rules", Reason: fmt.Sprintf("a link can have at most %d routing rules", len(domain.DeviceMatches))}
+	}
+	seen := map[domain.DeviceMatch]bool{}
+	for _, rule := range rules {
+		if !rule.Device.Valid() {
+			return &domain.ValidationError{Field: "rules", Reason: fmt.Sprintf("unknown device %q", rule.Device)}
+		}
+		if seen[rule.Device] {
+			return &domain.ValidationError{Field: "rules", Reason: fmt.Sprintf("duplicate rule for device %q", rule.Device)}
+		}
+		seen[rule.Device] = true
+		if rule.URL == "" {
+			return &domain.ValidationError{Field: "rules", Reason: fmt.Sprintf("rule for device %q has no URL", rule.Device)}
+		}
+		if !rule.AppURL() {
+			continue
+		}
+		if rule.Device != domain.MatchAndroid {
+			return &domain.ValidationError{Field: "rules", Reason: fmt.Sprintf("%s: URLs only work on Android", domain.AppURLScheme)}
+		}
+		if u, err := url.Parse(rule.URL); err != nil || !strings.HasPrefix(u.Fragment, "Intent;") {
+			return &domain.ValidationError{Field: "rules", Reason: "intent URLs must have an #Intent;...;end fragment"}
+		}
+	}
+	return nil
+}
+
+// checkGeoRules validates the geo rules of a new link. Their URLs are
+// validated as destinations by the caller, like the original URL.
+func checkGeoRules(rules []domain.GeoRule) error {
+	if len(rules) > domain.MaxGeoRules {
+		return &domain.ValidationError{Field: "geo_rules", Reason: fmt.Sprintf("a link can have at most %d geo rules", domain.MaxGeoRules)}
+	}
+	for i, rule := range rules {
+		if len(rule.Countries) == 0 && len(rule.Regions) == 0 {
+			return &domain.ValidationError{Field: "geo_rules", Reason: fmt.Sprintf("geo rule %d has neither countries nor regions", i+1)}
+		}
+		for _, country := range rule.Countries {
+			if !countryCodePattern.MatchString(country) {
+				return &domain.ValidationError{Field: "geo_rules", Reason: fmt.Sprintf("%q is not an ISO 3166-1 alpha-2 country code", country)}
+			}
+		}
+		for _, region := range rule.Regions {
+			if _, ok := domain.Regions[region]; !ok {
+				return &domain.ValidationError{Field: "geo_rules", Reason: fmt.Sprintf("unknown region %q", region)}
+			}
+		}
+		if rule.URL == "" {
+			return &domain.ValidationError{Field: "geo_rules", Reason: fmt.Sprintf("geo rule %d has no URL", i+1)}
+		}
+	}
+	return nil
+}
+
+// checkBlocklist fails redirects of links with a destination, or the
+// destination of a variant, blocked since the link was created.
+func (service *LinkService) checkBlocklist(id string, target domain.Target) error {
+	if service.blocklist == nil {
+		return nil
+	}
+	for _, destination := range target.Destinations() {
+		if rule, blocked := service.blocklist.Check(destination); blocked {
+			service.metrics.Redirect(ports.RedirectDisabled)
+			return &domain.DisabledError{LinkID: id, Reason: rule.Reason}
+		}
+	}
+	return nil
+}
+
+// checkDailyQuota counts a new link against its owner's quota for the
+// day. Refused and failed creates count too, which keeps the check to a
+// single round trip.
+func (service *LinkService) checkDailyQuota(ctx context.Context, link domain.Link) error {
+	if service.dailyLinks <= 0 || link.OwnerID == "" {
+		return nil
+	}
+
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+	key := fmt.Sprintf("links:%s:%s:%s", link.WorkspaceID, link.OwnerID, day.Format(time.DateOnly))
+	count, err := service.dailyCounter.Increment(ctx, key, day.Add(24*time.Hour))
+	if err != nil {
+		return fmt.Errorf("failed to count today's links: %w", err)
+	}
+	if count > int64(service.dailyLinks) {
+		return fmt.Errorf("owner %q is limited to %d links per day: %w", link.OwnerID, service.dailyLinks, domain.ErrQuotaExceeded)
+	}
+	return nil
+}
+
+// workspace returns the caller's workspace, or an empty one with default
+// settings when workspaces are not configured.
+func (service *LinkService) workspace(ctx context.Context) (domain.Workspace, error) {
+	id := domain.WorkspaceOf(ctx)
+	if service.workspaces == nil {
+		return domain.Workspace{Id: id}, nil
+	}
+	workspace, err := service.workspaces.Get(ctx, id)
+	if err != nil {
+		return domain.Workspace{}, fmt.Errorf("failed to get workspace '%s': %w", id, err)
+	}
+	return workspace, nil
+}
+
+// LinkDomain returns the domain a link created with Domain name is served
+// on. It checks that name is a verified domain of the caller's workspace,
+// or picks one for workspaces that cannot use the shared domain.
+func (service *LinkService) LinkDomain(ctx context.Context, name string) (string, error) {
+	workspaceID := domain.WorkspaceOf(ctx)
+	switch {
+	case name == "" && (service.domains == nil || workspaceID == domain.DefaultWorkspaceID):
+		return "", nil
+	case service.domains == nil:
+		return "", &domain.ValidationError{Field: "domain", Reason: "custom domains are not enabled"}
+	case name == "":
+		domains, err := service.domains.All(ctx, workspaceID)
+		if err != nil {
+			return "", fmt.Errorf("failed to get domains: %w", err)
+		}
+		for _, d := range domains {
+			if d.Verified() {
+				return d.Name, nil
+			}
+		}
+		return "", &domain.ValidationError{Field: "domain", Reason: fmt.Sprintf("workspace %q has no verified domain", workspaceID)}
+	}
+
+	name = normalizeHost(name)
+	d, err := service.domains.Get(ctx, name)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return "", fmt.Errorf("failed to get domain '%s': %w", name, err)
+	}
+	if err != nil || d.WorkspaceID != workspaceID || !d.Verified() {
+		return "", &domain.ValidationError{Field: "domain", Reason: fmt.Sprintf("%q is not a verified domain of this workspace", name)}
+	}
+	return d.Name, nil
+}
+
+// linkAuditEntry identifies link in the audit log by its custom domain and
+// ID.
+func linkAuditEntry(action string, link domain.Link, note string) domain.AuditEntry {
+	resource := link.Id
+	if link.Domain != "" {
+		resource = link.Domain + "/" + link.Id
+	}
+	return domain.AuditEntry{Action: action, WorkspaceID: link.WorkspaceID, ResourceID: resource, Note: note}
+}
+
+// cacheKey scopes a link ID to its workspace and custom domain. Links of
+// the shared domain in the default workspace keep their bare ID, so
+// entries cached before workspaces existed stay valid.
+func cacheKey(workspaceID, host, id string) string {
+	key := id
+	if host != "" {
+		key = host + "/" + id
+	}
+	if workspaceID != domain.DefaultWorkspaceID {
+		key = workspaceID + ":" + key
+	}
+	return key
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/itsbaivab/url-shortener/internal/core/ports"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordService lets visitors follow password protected links. Those
+// who enter the password get a signed, short-lived domain.LinkAccess so
+// they are not asked again on every redirect. Attempts can be rate
+// limited per link and client IP, and per link.
+type PasswordService struct {
+	links        *LinkService
+	secret       []byte
+	ttl          time.Duration
+	limiter      ports.RateLimiter
+	attempts     domain.RateLimit
+	linkAttempts domain.RateLimit
+}
+
+// NewPasswordService signs access grants with secret. Every replica must
+// share it for grants to be accepted by all of them.
+func NewPasswordService(links *LinkService, secret []byte) *PasswordService {
+	return &PasswordService{links: links, secret: secret, ttl: domain.DefaultLinkAccessTTL}
+}
+
+// WithAccessTTL sets how long access grants stay valid.
+func (service *PasswordService) WithAccessTTL(ttl time.Duration) *PasswordService {
+	service.ttl = ttl
+	return service
+}
+
+// WithAttemptLimit allows perIP password attempts per link and client IP,
+// and perLink per link from all IPs together, so a link cannot be guessed
+// at from many addresses either. Attempts are taken from limiter; a
+// disabled limit allows any number of attempts.
+func (service *PasswordService) WithAttemptLimit(limiter ports.RateLimiter, perIP, perLink domain.RateLimit) *PasswordService {
+	service.limiter = limiter
+	service.attempts = perIP
+	service.linkAttempts = perLink
+	return service
+}
+
+// HashPassword validates the password of a new link and hashes it with
+// bcrypt for Link.PasswordHash.
+func HashPassword(password string) (string, error) {
+	if len(password) < domain.MinLinkPasswordLength || len(password) > domain.MaxLinkPasswordLength {
+		return "", &domain.ValidationError{Field: "password", Reason: fmt.Sprintf("password must be between %d and %d bytes long", domain.MinLinkPasswordLength, domain.MaxLinkPasswordLength)}
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// Resolve resolves a short link like LinkService.Resolve, but lets
+// password protected links through when token is an access grant issued
+// for them by Unlock. public reports whether any request may follow the
+// link; redirects of other links must not be cached by browsers.
+func (service *PasswordService) Resolve(ctx context.Context, id, token string) (target domain.Target, public bool, err error) {
+	public = true
+	target, err = service.links.Resolve(ctx, id, func(link domain.Link) error {
+		public = false
+		if link.Protected() && !service.valid(link, token, time.Now()) {
+			return &domain.PasswordRequiredError{LinkID: id}
+		}
+		return nil
+	})
+	return target, public, err
+}
+
+// Unlock checks password, entered from ip, against the link id. On
+// success it returns the target and an access grant for the link;
+// the grant is empty for links without a password. Wrong passwords fail
+// with a rejected *domain.PasswordRequiredError, and attempts over the
+// limit with domain.ErrRateLimited before the password is checked.
+func (service *PasswordService) Unlock(ctx context.Context, id, password, ip string) (domain.Target, domain.LinkAccess, error) {
+	var access domain.LinkAccess
+	target, err := service.links.Resolve(ctx, id, func(link domain.Link) error {
+		if !link.Protected() {
+			return nil
+		}
+		if err := service.checkAttempts(ctx, link, ip); err != nil {
+			return err
+		}
+		err := bcrypt.CompareHashAndPassword([]byte(link.PasswordHash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return &domain.PasswordRequiredError{LinkID: id, Rejected: true}
+		}
+		if err != nil {
+			return fmt.Errorf("failed to check password of link '%s': %w", id, err)
+		}
+		expires := time.Now().Add(service.ttl)
+		access = domain.LinkAccess{Token: service.sign(link, expires), Expires: expires}
+		return nil
+	})
+	return target, access, err
+}
+
+// checkAttempts takes an attempt at the password of link from ip, first
+// from the bucket of ip and then from the one of the link.
+func (service *PasswordService) checkAttempts(ctx context.Context, link domain.Link, ip string) error {
+	if service.limiter == nil {
+		return nil
+	}
+	key := "password:" + cacheKey(link.WorkspaceID, link.Domain, link.Id)
+	for _, bucket := range []struct {
+		key   string
+		limit domain.RateLimit
+	}{{key + ":" + ip, service.attempts}, {key, service.linkAttempts}} {
+		if !bucket.limit.Enabled() {
+			continue
+		}
+		d, err := service.limiter.Take(ctx, bucket.key, bucket.limit)
+		if err != nil {
+			return fmt.Errorf("failed to count password attempts: %w", err)
+		}
+		if !d.Allowed {
+			wait := max(d.RetryAfter.Round(time.Second), time.Second)
+			return fmt.Errorf("too many password attempts, try again in %s: %w", wait, domain.ErrRateLimited)
+		}
+	}
+	return nil
+}
+
+// sign returns an access grant for link valid until expires. The
+// signature covers the password hash, so changing the password revokes
+// every grant.
+func (service *PasswordService) sign(link domain.Link, expires time.Time) string {
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	mac := hmac.New(sha256.New, service.secret)
+	mac.Write([]byte(strings.Join([]string{link.WorkspaceID, link.Domain, link.Id, exp, link.PasswordHash}, "\x00")))
+	return exp + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// valid reports whether token is an unexpired access grant for link.
+func (service *PasswordService) valid(link domain.Link, token string, now time.Time) bool {
+	exp, _, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+	unix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || !now.Before(time.Unix(unix, 0)) {
+		return false
+	}
+	return hmac.Equal([]byte(token), []byte(service.sign(link, time.Unix(unix, 0))))
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/itsbaivab/url-shortener/internal/core/ports"
+)
+
+// RateLimitService enforces request rate limits per client IP and per
+// credential. Budgets are shared by every replica through the shared
+// limiter; while it is unreachable each replica falls back to its local
+// one rather than failing or letting every request through.
+type RateLimitService struct {
+	shared ports.RateLimiter
+	local  ports.RateLimiter
+	perIP  domain.RateLimit
+	perKey domain.RateLimit
+
+	degraded atomic.Bool
+}
+
+func NewRateLimitService(shared, local ports.RateLimiter) *RateLimitService {
+	return &RateLimitService{shared: shared, local: local}
+}
+
+// WithLimits sets the limits of Check. Disabled limits are not enforced.
+func (service *RateLimitService) WithLimits(perIP, perKey domain.RateLimit) *RateLimitService {
+	service.perIP = perIP
+	service.perKey = perKey
+	return service
+}
+
+// rateBucket is a token bucket Check takes from.
+type rateBucket struct {
+	key   string
+	limit domain.RateLimit
+}
+
+// Check takes a token for a request from ip and, when the request is
+// authenticated, for its credential. It returns the decision of the
+// emptiest bucket; when a bucket is empty the error wraps
+// domain.ErrRateLimited. ok is false when no limit applies.
+func (service *RateLimitService) Check(ctx context.Context, ip string) (decision domain.RateDecision, ok bool, err error) {
+	buckets := []rateBucket{service.ipBucket(ip)}
+	if key, authenticated := service.keyBucket(ctx); authenticated {
+		buckets = append(buckets, key)
+	}
+	return service.take(ctx, buckets)
+}
+
+// CheckIP is Check for the client IP only. It goes before
+// authentication, so failed attempts count too.
+func (service *RateLimitService) CheckIP(ctx context.Context, ip string) (domain.RateDecision, bool, error) {
+	return service.take(ctx, []rateBucket{service.ipBucket(ip)})
+}
+
+// CheckKey is Check for the credential of an authenticated request
+// only. ok is false for requests without one.
+func (service *RateLimitService) CheckKey(ctx context.Context) (domain.RateDecision, bool, error) {
+	key, authenticated := service.keyBucket(ctx)
+	if !authenticated {
+		return domain.RateDecision{}, false, nil
+	}
+	return service.take(ctx, []rateBucket{key})
+}
+
+func (service *RateLimitService) ipBucket(ip string) rateBucket {
+	return rateBucket{"ip:" + ip, service.perIP}
+}
+
+func (service *RateLimitService) keyBucket(ctx context.Context) (rateBucket, bool) {
+	p, authenticated := domain.PrincipalFrom(ctx)
+	return rateBucket{"key:" + credentialKey(p), service.perKey}, authenticated
+}
+
+// take takes a token from each bucket in turn and reports the emptiest.
+func (service *RateLimitService) take(ctx context.Context, buckets []rateBucket) (decision domain.RateDecision, ok bool, err error) {
+	for _, b := range buckets {
+		if !b.limit.Enabled() {
+			continue
+		}
+		d, err := service.Take(ctx, b.key, b.limit)
+		if err != nil {
+			return domain.RateDecision{}, false, err
+		}
+		if !ok || !d.Allowed || d.Remaining < decision.Remaining {
+			decision, ok = d, true
+		}
+		if !d.Allowed {
+			return decision, true, fmt.Errorf("limit of %d requests per %s reached: %w", d.Limit, d.Window, domain.ErrRateLimited)
+		}
+	}
+	return decision, ok, nil
+}
+
+// Take removes a token from the bucket key.
+func (service *RateLimitService) Take(ctx context.Context, key string, limit domain.RateLimit) (domain.RateDecision, error) {
+	d, err := service.shared.Take(ctx, key, limit)
+	if service.fallback(ctx, err) {
+		return service.local.Take(ctx, key, limit)
+	}
+	return d, err
+}
+
+// Increment adds one to the counter key.
+func (service *RateLimitService) Increment(ctx context.Context, key string, expireAt time.Time) (int64, error) {
+	n, err := service.shared.Increment(ctx, key, expireAt)
+	if service.fallback(ctx, err) {
+		return service.local.Increment(ctx, key, expireAt)
+	}
+	return n, err
+}
+
+// fallback reports whether err calls for the local limiter, logging when
+// the shared one becomes unreachable and when it recovers.
+func (service *RateLimitService) fallback(ctx context.Context, err error) bool {
+	if errors.Is(err, domain.ErrUnavailable) {
+		if !service.degraded.Swap(true) {
+			slog.WarnContext(ctx, "shared rate limiter unavailable; limiting per replica", "error", err)
+		}
+		return true
+	}
+	if err == nil && service.degraded.Swap(false) {
+		slog.InfoContext(ctx, "shared rate limiter recovered")
+	}
+	return false
+}
+
+// credentialKey identifies the API key or bearer token of p.
+func credentialKey(p domain.Principal) string {
+	if p.KeyID != "" {
+		return p.KeyID
+	}
+	return p.Workspace() + ":" + p.Subject
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/mail"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/itsbaivab/url-shortener/internal/core/ports"
+)
+
+const reportIDLength = 16
+
+// AbuseService takes abuse reports from the public and lets operators
+// review them: disable the reported link, restore it, or dismiss the
+// report. Every decision is recorded on the report and, with WithAudit, in
+// the audit log; changes to links are recorded by links.
+type AbuseService struct {
+	port  ports.AbuseReportPort
+	links *LinkService
+	audit *AuditService
+}
+
+func NewAbuseService(p ports.AbuseReportPort, links *LinkService) *AbuseService {
+	return &AbuseService{port: p, links: links}
+}
+
+// WithAudit records review decisions in a.
+func (service *AbuseService) WithAudit(a *AuditService) *AbuseService {
+	service.audit = a
+	return service
+}
+
+// Report files report against the link report.LinkID of the workspace and
+// custom domain of ctx. The link must exist; reporter fields are stored as
+// given.
+func (service *AbuseService) Report(ctx context.Context, report domain.AbuseReport) (domain.AbuseReport, error) {
+	report.Reason = strings.ToLower(strings.TrimSpace(report.Reason))
+	report.Details = strings.TrimSpace(report.Details)
+	report.ReporterEmail = strings.TrimSpace(report.ReporterEmail)
+
+	if !slices.Contains(domain.ReportReasons, report.Reason) {
+		return domain.AbuseReport{}, &domain.ValidationError{Field: "reason", Reason: fmt.Sprintf("reason must be one of %s", strings.Join(domain.ReportReasons, ", "))}
+	}
+	if len(report.Details) > domain.MaxReportDetails {
+		return domain.AbuseReport{}, &domain.ValidationError{Field: "details", Reason: fmt.Sprintf("details must be at most %d characters long", domain.MaxReportDetails)}
+	}
+	if report.ReporterEmail != "" {
+		if _, err := mail.ParseAddress(report.ReporterEmail); err != nil {
+			return domain.AbuseReport{}, &domain.ValidationError{Field: "email", Reason: "email is not a valid address"}
+		}
+	}
+
+	link, err := service.links.port.Get(ctx, domain.WorkspaceOf(ctx), domain.LinkDomainOf(ctx), report.LinkID)
+	if err != nil {
+		return domain.AbuseReport{}, fmt.Errorf("failed to report link '%s': %w", report.LinkID, err)
+	}
+
+	report.Id, err = randomString(reportIDLength)
+	if err != nil {
+		return domain.AbuseReport{}, err
+	}
+	report.WorkspaceID, report.Domain = link.WorkspaceID, link.Domain
+	report.Status = domain.ReportOpen
+	report.CreatedAt = time.Now()
+	report.Resolution = nil
+
+	if err := service.port.Create(ctx, report); err != nil {
+		return domain.AbuseReport{}, fmt.Errorf("failed to store abuse report: %w", err)
+	}
+	slog.InfoContext(ctx, "abuse report filed", "report_id", report.Id, "link_id", report.LinkID, "workspace_id", report.WorkspaceID, "reason", report.Reason)
+	return report, nil
+}
+
+// Queue returns the reports in status, or every report when status is
+// empty, oldest first. Only operators may review reports.
+func (service *AbuseService) Queue(ctx context.Context, status string) ([]domain.AbuseReport, error) {
+	if err := requireOperator(ctx); err != nil {
+		return nil, err
+	}
+	if status != "" && !slices.Contains([]string{domain.ReportOpen, domain.ReportDisabled, domain.ReportRestored, domain.ReportDismissed}, status) {
+		return nil, &domain.ValidationError{Field: "status", Reason: fmt.Sprintf("unknown status %q", status)}
+	}
+	reports, err := service.port.All(ctx, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get abuse reports: %w", err)
+	}
+	return reports, nil
+}
+
+// Resolve closes an open report with decision: domain.ReportDisabled
+// disables the link, domain.ReportRestored restores it and
+// domain.ReportDismissed leaves it as it is. Disabling or restoring closes
+// the other open reports of the link too. note, the reason for the
+// decision, is required. Only operators may resolve reports.
+func (service *AbuseService) Resolve(ctx context.Context, id, decision, note string) (domain.AbuseReport, error) {
+	if err := requireOperator(ctx); err != nil {
+		return domain.AbuseReport{}, err
+	}
+	note = strings.TrimSpace(note)
+	if note == "" {
+		return domain.AbuseReport{}, &domain.ValidationError{Field: "note", Reason: "a note explaining the decision is required"}
+	}
+
+	report, err := service.port.Get(ctx, id)
+	if err != nil {
+		return domain.AbuseReport{}, fmt.Errorf("failed to get abuse report '%s': %w", id, err)
+	}
+	if report.Status != domain.ReportOpen {
+		return domain.AbuseReport{}, fmt.Errorf("abuse report %q is already %s: %w", id, report.Status, domain.ErrConflict)
+	}
+
+	switch decision {
+	case domain.ReportDisabled:
+		err = service.setDisabled(ctx, report.WorkspaceID, report.Domain, report.LinkID, true, note)
+	case domain.ReportRestored:
+		err = service.setDisabled(ctx, report.WorkspaceID, report.Domain, report.LinkID, false, note)
+	case domain.ReportDismissed:
+	default:
+		return domain.AbuseReport{}, &domain.ValidationError{Field: "decision", Reason: fmt.Sprintf("decision must be one of %s, %s, %s", domain.ReportDisabled, domain.ReportRestored, domain.ReportDismissed)}
+	}
+	if err != nil {
+		return domain.AbuseReport{}, err
+	}
+
+	resolution := domain.ReportResolution{Status: decision, Note: note, ResolvedBy: actorOf(ctx), ResolvedAt: time.Now()}
+	if err := service.port.Resolve(ctx, id, resolution); err != nil {
+		return domain.AbuseReport{}, fmt.Errorf("failed to resolve abuse report '%s': %w", id, err)
+	}
+	resolved := report
+	resolved.Status, resolved.Resolution = decision, &resolution
+	service.audit.Record(ctx, domain.AuditEntry{Action: domain.AuditAbuseReportResolved, WorkspaceID: report.WorkspaceID, ResourceID: id, Note: note}, report, resolved)
+	if decision != domain.ReportDismissed {
+		service.resolveOthers(ctx, report, resolution)
+	}
+	return resolved, nil
+}
+
+// DisableLink takes a link down without a report. Only operators may
+// disable links this way.
+func (service *AbuseService) DisableLink(ctx context.Context, workspaceID, host, id, note string) error {
+	return service.decideLink(ctx, workspaceID, host, id, true, note)
+}
+
+// RestoreLink lets a disabled link redirect again. Only operators may
+// restore links.
+func (service *AbuseService) RestoreLink(ctx context.Context, workspaceID, host, id, note string) error {
+	return service.decideLink(ctx, workspaceID, host, id, false, note)
+}
+
+func (service *AbuseService) decideLink(ctx context.Context, workspaceID, host, id string, disable bool, note string) error {
+	if err := requireOperator(ctx); err != nil {
+		return err
+	}
+	note = strings.TrimSpace(note)
+	if note == "" {
+		return &domain.ValidationError{Field: "note", Reason: "a note explaining the decision is required"}
+	}
+	return service.setDisabled(ctx, workspaceID, host, id, disable, note)
+}
+
+func (service *AbuseService) setDisabled(ctx context.Context, workspaceID, host, id string, disable bool, note string) error {
+	link, err := service.links.port.Get(ctx, workspaceID, host, id)
+	if err != nil {
+		return fmt.Errorf("failed to get link '%s': %w", id, err)
+	}
+	if disable {
+		return service.links.Disable(ctx, link, note)
+	}
+	return service.links.Restore(ctx, link, note)
+}
+
+// resolveOthers closes the other open reports of the link of report with
+// resolution. Failures are logged; the reports stay in the queue.
+func (service *AbuseService) resolveOthers(ctx context.Context, report domain.AbuseReport, resolution domain.ReportResolution) {
+	open, err := service.port.All(ctx, domain.ReportOpen)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to close related abuse reports", "report_id", report.Id, "error", err)
+		return
+	}
+	resolution.Note = fmt.Sprintf("%s (with report %s)", resolution.Note, report.Id)
+	for _, other := range open {
+		if other.Id == report.Id || other.WorkspaceID != report.WorkspaceID || other.Domain != report.Domain || other.LinkID != report.LinkID {
+			continue
+		}
+		if err := service.port.Resolve(ctx, other.Id, resolution); err != nil {
+			slog.WarnContext(ctx, "failed to close related abuse report", "report_id", other.Id, "error", err)
+			continue
+		}
+		resolved := other
+		resolved.Status, resolved.Resolution = resolution.Status, &resolution
+		service.audit.Record(ctx, domain.AuditEntry{Action: domain.AuditAbuseReportResolved, WorkspaceID: other.WorkspaceID, ResourceID: other.Id, Note: resolution.Note}, other, resolved)
+	}
+}
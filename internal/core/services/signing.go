@@ -0,0 +1,79 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+)
+
+// SigningService signs the short URLs of links that require a signature
+// and verifies them. The first key signs; every key verifies, so keys are
+// rotated by putting a new one first and dropping the old one once the
+// URLs it signed have expired.
+type SigningService struct {
+	keys       [][]byte
+	defaultTTL time.Duration
+	maxTTL     time.Duration
+}
+
+func NewSigningService(keys ...[]byte) *SigningService {
+	return &SigningService{keys: keys, defaultTTL: domain.DefaultSignedURLTTL, maxTTL: domain.MaxSignedURLTTL}
+}
+
+// WithTTL sets how long signed URLs are valid when the caller does not
+// say, and how long they may be valid at most.
+func (service *SigningService) WithTTL(defaultTTL, maxTTL time.Duration) *SigningService {
+	service.defaultTTL = defaultTTL
+	service.maxTTL = maxTTL
+	return service
+}
+
+// Enabled reports whether URLs can be signed.
+func (service *SigningService) Enabled() bool {
+	return service != nil && len(service.keys) > 0
+}
+
+// Sign signs a URL for link valid for ttl from now, or for the default
+// lifetime when ttl is zero.
+func (service *SigningService) Sign(link domain.Link, ttl time.Duration) (domain.URLSignature, error) {
+	if !service.Enabled() {
+		return domain.URLSignature{}, &domain.ValidationError{Field: "id", Reason: "signed URLs are not enabled"}
+	}
+	if ttl == 0 {
+		ttl = service.defaultTTL
+	}
+	if ttl < time.Second || ttl > service.maxTTL {
+		return domain.URLSignature{}, &domain.ValidationError{Field: "expires_in", Reason: fmt.Sprintf("signed URLs must be valid for between 1s and %s", service.maxTTL)}
+	}
+	expires := time.Now().Add(ttl).Truncate(time.Second)
+	return domain.URLSignature{Expires: expires, Signature: sign(service.keys[0], link, expires)}, nil
+}
+
+// Verify checks that s was signed for link by one of the keys and has not
+// expired at now. It fails with a *domain.SignatureError.
+func (service *SigningService) Verify(link domain.Link, s domain.URLSignature, now time.Time) error {
+	if service.Enabled() && s.Signature != "" && !s.Expires.IsZero() {
+		for _, key := range service.keys {
+			if !hmac.Equal([]byte(s.Signature), []byte(sign(key, link, s.Expires))) {
+				continue
+			}
+			if !now.Before(s.Expires) {
+				return &domain.SignatureError{LinkID: link.Id, Expired: true}
+			}
+			return nil
+		}
+	}
+	return &domain.SignatureError{LinkID: link.Id}
+}
+
+// sign returns the signature of a URL for link expiring at expires.
+func sign(key []byte, link domain.Link, expires time.Time) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(strings.Join([]string{link.WorkspaceID, link.Domain, link.Id, domain.URLSignature{Expires: expires}.Exp()}, "\x00")))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
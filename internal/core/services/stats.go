@@ -3,22 +3,30 @@ package services
 import (
 	"context"
 	"fmt"
+	"sort"
 
 	"github.com/itsbaivab/url-shortener/internal/core/domain"
 	"github.com/itsbaivab/url-shortener/internal/core/ports"
 )
 
 type StatsService struct {
-	port  ports.StatsPort
-	cache ports.Cache
+	port    ports.StatsPort
+	cache   ports.Cache
+	metrics ports.Metrics
 }
 
 func NewStatsService(p ports.StatsPort, c ports.Cache) *StatsService {
-	return &StatsService{port: p, cache: c}
+	return &StatsService{port: p, cache: c, metrics: ports.NopMetrics{}}
+}
+
+// WithMetrics reports service events to m.
+func (service *StatsService) WithMetrics(m ports.Metrics) *StatsService {
+	service.metrics = m
+	return service
 }
 
 func (service *StatsService) All(ctx context.Context) ([]domain.Stats, error) {
-	stats, err := service.port.All(ctx)
+	stats, err := service.port.All(ctx, domain.WorkspaceOf(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all stats: %w", err)
 	}
@@ -26,31 +34,137 @@ func (service *StatsService) All(ctx context.Context) ([]domain.Stats, error) {
 }
 
 func (service *StatsService) Get(ctx context.Context, statsID string) (domain.Stats, error) {
-	stats, err := service.port.Get(ctx, statsID)
+	stats, err := service.port.Get(ctx, domain.WorkspaceOf(ctx), statsID)
 	if err != nil {
 		return domain.Stats{}, fmt.Errorf("failed to get stats for identifier '%s': %w", statsID, err)
 	}
 	return stats, nil
 }
 
+// Delete removes the stats of a link of the custom domain of ctx.
 func (service *StatsService) Delete(ctx context.Context, linkID string) error {
-	if err := service.port.Delete(ctx, linkID); err != nil {
+	if err := service.port.Delete(ctx, domain.WorkspaceOf(ctx), domain.LinkDomainOf(ctx), linkID); err != nil {
 		return fmt.Errorf("failed to delete stats for identifier '%s': %w", linkID, err)
 	}
 	return nil
 }
 
+// Create records data in its workspace, or in the caller's when data
+// does not name one.
 func (service *StatsService) Create(ctx context.Context, data domain.Stats) error {
+	if data.WorkspaceID == "" {
+		data.WorkspaceID = domain.WorkspaceOf(ctx)
+	}
 	if err := service.port.Create(ctx, data); err != nil {
+		service.metrics.StatsWriteFailed()
 		return fmt.Errorf("failed to create stats: %w", err)
 	}
 	return nil
 }
 
+// GetStatsByLinkID returns the stats of a link of the custom domain of
+// ctx.
 func (service *StatsService) GetStatsByLinkID(ctx context.Context, linkID string) ([]domain.Stats, error) {
-	stats, err := service.port.GetStatsByLinkID(ctx, linkID)
+	stats, err := service.port.GetStatsByLinkID(ctx, domain.WorkspaceOf(ctx), domain.LinkDomainOf(ctx), linkID)
 	if err != nil {
 		return []domain.Stats{}, fmt.Errorf("failed to get stats for identifier '%s': %w", linkID, err)
 	}
 	return stats, nil
 }
+
+// CampaignStats adds up the clicks recorded for links of a campaign of
+// the caller's workspace, per link and per platform. Unless visible is
+// nil, clicks on links it rejects are left out, so callers who may not
+// see every link of the workspace only count their own. It is called
+// once per link.
+func (service *StatsService) CampaignStats(ctx context.Context, campaignID string, visible func(linkID, linkDomain string) bool) (domain.CampaignStats, error) {
+	stats, err := service.port.GetStatsByCampaign(ctx, domain.WorkspaceOf(ctx), campaignID)
+	if err != nil {
+		return domain.CampaignStats{}, fmt.Errorf("failed to get stats for campaign '%s': %w", campaignID, err)
+	}
+
+	summary := domain.CampaignStats{CampaignID: campaignID, Links: []domain.LinkClicks{}, Platforms: map[string]int{}}
+	links := map[domain.LinkClicks]int{}
+	hidden := map[domain.LinkClicks]bool{}
+	for _, stat := range stats {
+		if stat.PasswordAttempt == domain.PasswordRejected {
+			continue
+		}
+		key := domain.LinkClicks{LinkID: stat.LinkID, Domain: stat.Domain}
+		if visible != nil {
+			if _, decided := hidden[key]; !decided {
+				hidden[key] = !visible(stat.LinkID, stat.Domain)
+			}
+			if hidden[key] {
+				continue
+			}
+		}
+		summary.Clicks++
+		summary.Platforms[stat.Platform.Name()]++
+
+		if i, ok := links[key]; ok {
+			summary.Links[i].Clicks++
+			continue
+		}
+		links[key] = len(summary.Links)
+		key.Clicks = 1
+		summary.Links = append(summary.Links, key)
+	}
+	sort.SliceStable(summary.Links, func(i, j int) bool {
+		return summary.Links[i].Clicks > summary.Links[j].Clicks
+	})
+	return summary, nil
+}
+
+// VariantClicks counts the clicks on each variant of link, in the order
+// of its variants, from the stats of the custom domain of ctx.
+func (service *StatsService) VariantClicks(ctx context.Context, link domain.Link) ([]domain.VariantClicks, error) {
+	stats, err := service.GetStatsByLinkID(ctx, link.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	clicks := make([]domain.VariantClicks, len(link.Variants))
+	index := map[string]int{}
+	for i, v := range link.Variants {
+		clicks[i] = domain.VariantClicks{Variant: v.ID, URL: v.URL}
+		index[v.ID] = i
+	}
+	for _, stat := range stats {
+		if stat.PasswordAttempt == domain.PasswordRejected {
+			continue
+		}
+		if i, ok := index[stat.Variant]; ok {
+			clicks[i].Clicks++
+		}
+	}
+	return clicks, nil
+}
+
+// RoutingStats counts the clicks sent to each routing rule of link, in
+// the order of its rules, and those that matched none, from the stats of
+// the custom domain of ctx.
+func (service *StatsService) RoutingStats(ctx context.Context, link domain.Link) (domain.RoutingStats, error) {
+	stats, err := service.GetStatsByLinkID(ctx, link.Id)
+	if err != nil {
+		return domain.RoutingStats{}, err
+	}
+
+	summary := domain.RoutingStats{Rules: make([]domain.RuleClicks, len(link.Rules))}
+	index := map[domain.DeviceMatch]int{}
+	for i, rule := range link.Rules {
+		summary.Rules[i] = domain.RuleClicks{Device: rule.Device, URL: rule.URL}
+		index[rule.Device] = i
+	}
+	for _, stat := range stats {
+		if stat.PasswordAttempt == domain.PasswordRejected {
+			continue
+		}
+		if i, ok := index[stat.Rule]; ok {
+			summary.Rules[i].Clicks++
+		} else if stat.Rule == "" {
+			summary.Fallback++
+		}
+	}
+	return summary, nil
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/itsbaivab/url-shortener/internal/core/ports"
+)
+
+// Generated IDs shorter than minShortIDLength are too easy to enumerate.
+const (
+	minShortIDLength = 4
+	maxShortIDLength = 32
+)
+
+var workspaceIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,62}$`)
+
+type WorkspaceService struct {
+	port  ports.WorkspacePort
+	audit *AuditService
+}
+
+func NewWorkspaceService(p ports.WorkspacePort) *WorkspaceService {
+	return &WorkspaceService{port: p}
+}
+
+// WithAudit records created and updated workspaces in a.
+func (service *WorkspaceService) WithAudit(a *AuditService) *WorkspaceService {
+	service.audit = a
+	return service
+}
+
+// Current returns the workspace of the caller.
+func (service *WorkspaceService) Current(ctx context.Context) (domain.Workspace, error) {
+	id := domain.WorkspaceOf(ctx)
+	workspace, err := service.port.Get(ctx, id)
+	if err != nil {
+		return domain.Workspace{}, fmt.Errorf("failed to get workspace '%s': %w", id, err)
+	}
+	return workspace, nil
+}
+
+func (service *WorkspaceService) All(ctx context.Context) ([]domain.Workspace, error) {
+	if err := requireOperator(ctx); err != nil {
+		return nil, err
+	}
+	workspaces, err := service.port.All(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workspaces: %w", err)
+	}
+	return workspaces, nil
+}
+
+// Create adds a workspace. Only operators may create workspaces.
+func (service *WorkspaceService) Create(ctx context.Context, workspace domain.Workspace) (domain.Workspace, error) {
+	if err := requireOperator(ctx); err != nil {
+		return domain.Workspace{}, err
+	}
+	workspace, err := normalizeWorkspace(workspace)
+	if err != nil {
+		return domain.Workspace{}, err
+	}
+	workspace.CreatedAt = time.Now()
+
+	if err := service.port.Create(ctx, workspace); err != nil {
+		return domain.Workspace{}, fmt.Errorf("failed to create workspace '%s': %w", workspace.Id, err)
+	}
+	service.audit.Record(ctx, domain.AuditEntry{Action: domain.AuditWorkspaceCreated, WorkspaceID: workspace.Id, ResourceID: workspace.Id}, nil, workspace)
+	return workspace, nil
+}
+
+// Update replaces the name, quotas and settings of a workspace.
+// Only operators may change them.
+func (service *WorkspaceService) Update(ctx context.Context, workspace domain.Workspace) (domain.Workspace, error) {
+	if err := requireOperator(ctx); err != nil {
+		return domain.Workspace{}, err
+	}
+	workspace, err := normalizeWorkspace(workspace)
+	if err != nil {
+		return domain.Workspace{}, err
+	}
+	before, err := service.port.Get(ctx, workspace.Id)
+	if err != nil {
+		return domain.Workspace{}, fmt.Errorf("failed to get workspace '%s': %w", workspace.Id, err)
+	}
+
+	if err := service.port.Update(ctx, workspace); err != nil {
+		return domain.Workspace{}, fmt.Errorf("failed to update workspace '%s': %w", workspace.Id, err)
+	}
+
+	updated, err := service.port.Get(ctx, workspace.Id)
+	if err != nil {
+		return domain.Workspace{}, fmt.Errorf("failed to get workspace '%s': %w", workspace.Id, err)
+	}
+	service.audit.Record(ctx, domain.AuditEntry{Action: domain.AuditWorkspaceUpdated, WorkspaceID: workspace.Id, ResourceID: workspace.Id}, before, updated)
+	return updated, nil
+}
+
+// requireOperator allows trusted callers without a principal and admins
+// of the default workspace.
+func requireOperator(ctx context.Context) error {
+	if p, ok := domain.PrincipalFrom(ctx); ok && !p.IsOperator() {
+		return fmt.Errorf("workspace management requires the %q scope in the %q workspace: %w",
+			domain.ScopeAdmin, domain.DefaultWorkspaceID, domain.ErrForbidden)
+	}
+	return nil
+}
+
+func normalizeWorkspace(workspace domain.Workspace) (domain.Workspace, error) {
+	if !workspaceIDPattern.MatchString(workspace.Id) {
+		return domain.Workspace{}, &domain.ValidationError{Field: "id", Reason: "id must be 2-63 lowercase letters, digits or dashes"}
+	}
+	if workspace.Name == "" {
+		workspace.Name = workspace.Id
+	}
+	if workspace.Quotas.MaxLinks < 0 {
+		return domain.Workspace{}, &domain.ValidationError{Field: "quotas.max_links", Reason: "max_links must not be negative"}
+	}
+	if n := workspace.Settings.ShortIDLength; n != 0 && (n < minShortIDLength || n > maxShortIDLength) {
+		return domain.Workspace{}, &domain.ValidationError{
+			Field:  "settings.short_id_length",
+			Reason: fmt.Sprintf("short_id_length must be between %d and %d", minShortIDLength, maxShortIDLength),
+		}
+	}
+	return workspace, nil
+}
@@ -0,0 +1,148 @@
+// Package health runs dependency checks for the readiness probe. Checks
+// run concurrently with a per-check timeout, and results are cached
+// briefly so frequent probes do not hammer the dependencies.
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// Checker reports whether a dependency is usable.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc adapts a function to Checker.
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Result is the outcome of one check.
+type Result struct {
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	DurationMS int64     `json:"duration_ms"`
+	CheckedAt  time.Time `json:"checked_at"`
+}
+
+// Report is the outcome of every registered check.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+// Healthy reports whether every check passed.
+func (r Report) Healthy() bool {
+	return r.Status == StatusOK
+}
+
+type check struct {
+	name    string
+	checker Checker
+}
+
+// Registry holds the checks of one service.
+type Registry struct {
+	timeout time.Duration
+	ttl     time.Duration
+
+	mu     sync.Mutex
+	checks []check
+	cached map[string]Result
+}
+
+// NewRegistry returns a registry that gives each check timeout to finish
+// and reuses results for ttl.
+func NewRegistry(timeout, ttl time.Duration) *Registry {
+	return &Registry{timeout: timeout, ttl: ttl, cached: map[string]Result{}}
+}
+
+// Register adds a named check.
+func (r *Registry) Register(name string, c Checker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks = append(r.checks, check{name: name, checker: c})
+	sort.Slice(r.checks, func(i, j int) bool { return r.checks[i].name < r.checks[j].name })
+}
+
+// Run executes every check whose cached result is stale and returns the
+// combined report.
+func (r *Registry) Run(ctx context.Context) Report {
+	r.mu.Lock()
+	checks := append([]check(nil), r.checks...)
+	r.mu.Unlock()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(checks))}
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for _, c := range checks {
+		wg.Add(1)
+		go func(c check) {
+			defer wg.Done()
+			result := r.result(ctx, c)
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[c.name] = result
+			if result.Status != StatusOK {
+				report.Status = StatusFail
+			}
+		}(c)
+	}
+	wg.Wait()
+
+	return report
+}
+
+func (r *Registry) result(ctx context.Context, c check) Result {
+	r.mu.Lock()
+	cached, ok := r.cached[c.name]
+	r.mu.Unlock()
+	if ok && time.Since(cached.CheckedAt) < r.ttl {
+		return cached
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := runWithTimeout(ctx, c.checker)
+	result := Result{
+		Status:     StatusOK,
+		DurationMS: time.Since(start).Milliseconds(),
+		CheckedAt:  start,
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+
+	r.mu.Lock()
+	r.cached[c.name] = result
+	r.mu.Unlock()
+	return result
+}
+
+// runWithTimeout returns when the check finishes or ctx expires, so a
+// checker that ignores its context cannot stall the probe.
+func runWithTimeout(ctx context.Context, c Checker) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Check(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,137 @@
+// Package logging configures log/slog for every binary. Records are JSON
+// by default and carry the attributes stored in their context, such as
+// the request ID, link ID and trace ID.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+var level = new(slog.LevelVar)
+
+// Setup installs the default slog logger writing to stdout. format is
+// "json" or "text"; levelName is any level ParseLevel accepts. The
+// standard log package is routed through the same handler.
+func Setup(levelName, format string) error {
+	if err := SetLevel(levelName); err != nil {
+		return err
+	}
+	handler, err := newHandler(os.Stdout, format)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
+
+func newHandler(w io.Writer, format string) (slog.Handler, error) {
+	opts := &slog.HandlerOptions{Level: level}
+	switch format {
+	case "", "json":
+		return contextHandler{slog.NewJSONHandler(w, opts)}, nil
+	case "text":
+		return contextHandler{slog.NewTextHandler(w, opts)}, nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q (want json or text)", format)
+	}
+}
+
+// NewLogger returns a logger writing JSON to w at the shared level, with
+// context attributes. Useful in tests.
+func NewLogger(w io.Writer) *slog.Logger {
+	handler, _ := newHandler(w, "json")
+	return slog.New(handler)
+}
+
+// Level returns the current minimum level.
+func Level() slog.Level {
+	return level.Level()
+}
+
+// SetLevel changes the minimum level of every logger at runtime.
+func SetLevel(name string) error {
+	l, err := ParseLevel(name)
+	if err != nil {
+		return err
+	}
+	level.Set(l)
+	return nil
+}
+
+// ParseLevel accepts debug, info, warn or error in any case.
+func ParseLevel(name string) (slog.Level, error) {
+	var l slog.Level
+	if name == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := l.UnmarshalText([]byte(strings.ToUpper(name))); err != nil {
+		return 0, fmt.Errorf("unknown log level %q (want debug, info, warn or error)", name)
+	}
+	return l, nil
+}
+
+// Fatal logs msg at error level and exits.
+func Fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+type attrsKey struct{}
+
+// With returns ctx with args added to the attributes logged with it.
+// args are key-value pairs or slog.Attr values, as for slog.Logger.With.
+func With(ctx context.Context, args ...any) context.Context {
+	existing, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	r := slog.Record{}
+	r.Add(args...)
+	attrs := make([]slog.Attr, len(existing), len(existing)+r.NumAttrs())
+	copy(attrs, existing)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	return context.WithValue(ctx, attrsKey{}, attrs)
+}
+
+// Inherit returns ctx with the log attributes of parent, for work that
+// outlives the request it belongs to.
+func Inherit(ctx, parent context.Context) context.Context {
+	if attrs, ok := parent.Value(attrsKey{}).([]slog.Attr); ok {
+		return context.WithValue(ctx, attrsKey{}, attrs)
+	}
+	return ctx
+}
+
+// contextHandler adds the attributes stored by With and the current trace
+// and span IDs to every record logged with a context.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if attrs, ok := ctx.Value(attrsKey{}).([]slog.Attr); ok {
+		r.AddAttrs(attrs...)
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
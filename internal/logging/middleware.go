@@ -0,0 +1,87 @@
+package logging
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/itsbaivab/url-shortener/internal/adapters/problem"
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+)
+
+// RequestIDHeader is read from incoming requests and echoed on responses.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds client supplied IDs before they reach logs.
+const maxRequestIDLength = 128
+
+// RequestID reuses the caller's X-Request-ID, or creates one, returns it
+// on the response and adds it to the request context's log attributes.
+// The ID and client IP are also stored as the request's domain.Origin for
+// audit entries.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if id == "" || len(id) > maxRequestIDLength {
+			id = uuid.NewString()
+		}
+		c.Header(RequestIDHeader, id)
+		Annotate(c, "request_id", id)
+		c.Request = c.Request.WithContext(domain.WithOrigin(c.Request.Context(), domain.Origin{RequestID: id, SourceIP: c.ClientIP()}))
+		c.Next()
+	}
+}
+
+// Annotate adds args to the log attributes of the request, so later log
+// lines for it, including the access log, carry them.
+func Annotate(c *gin.Context, args ...any) {
+	c.Request = c.Request.WithContext(With(c.Request.Context(), args...))
+}
+
+// AccessLog logs one line per request, replacing gin's default logger.
+// Server errors are logged at error level, client errors at warn level.
+func AccessLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		lvl := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			lvl = slog.LevelError
+		case status >= http.StatusBadRequest:
+			lvl = slog.LevelWarn
+		}
+		slog.Log(c.Request.Context(), lvl, "request",
+			"method", c.Request.Method,
+			"route", c.FullPath(),
+			"path", c.Request.URL.Path,
+			"status", status,
+			"duration_ms", time.Since(start).Milliseconds(),
+			"client_ip", c.ClientIP(),
+		)
+	}
+}
+
+// LevelHandler reports the log level on GET and changes it on PUT with a
+// body like {"level":"debug"}.
+func LevelHandler(c *gin.Context) {
+	if c.Request.Method == http.MethodPut {
+		var body struct {
+			Level string `json:"level" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			problem.Abort(c, &domain.ValidationError{Field: "level", Reason: "level is required"})
+			return
+		}
+		if err := SetLevel(body.Level); err != nil {
+			problem.Abort(c, &domain.ValidationError{Field: "level", Reason: err.Error()})
+			return
+		}
+		slog.InfoContext(c.Request.Context(), "log level changed", "level", Level().String())
+	}
+	c.JSON(http.StatusOK, gin.H{"level": Level().String()})
+}
@@ -0,0 +1,80 @@
+// Package ratelimit enforces the limits of services.RateLimitService on
+// gin requests and describes them with the RateLimit-* headers of the IETF
+// httpapi draft, so clients can pace themselves before they get a 429.
+package ratelimit
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/itsbaivab/url-shortener/internal/adapters/problem"
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/itsbaivab/url-shortener/internal/core/services"
+)
+
+// decisionKey holds the decision of PerIP in the gin context, so PerKey
+// can report the emptier bucket.
+const decisionKey = "ratelimit.decision"
+
+// PerIP limits requests per client IP. It goes before auth.Middleware so
+// requests with wrong credentials are limited too. Refused requests get
+// a 429 problem with Retry-After.
+func PerIP(limits *services.RateLimitService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		decision, ok, err := limits.CheckIP(c.Request.Context(), c.ClientIP())
+		if ok {
+			c.Set(decisionKey, decision)
+		}
+		respond(c, decision, ok, err)
+	}
+}
+
+// PerKey limits requests per API key or token. It goes after
+// auth.Middleware and lets unauthenticated requests through.
+func PerKey(limits *services.RateLimitService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		decision, ok, err := limits.CheckKey(c.Request.Context())
+		if previous, set := c.Get(decisionKey); set && err == nil {
+			if ip := previous.(domain.RateDecision); !ok || ip.Remaining < decision.Remaining {
+				decision, ok = ip, true
+			}
+		}
+		respond(c, decision, ok, err)
+	}
+}
+
+// respond describes decision in the headers when ok and aborts with err.
+func respond(c *gin.Context, decision domain.RateDecision, ok bool, err error) {
+	if ok {
+		for name, value := range Headers(decision) {
+			c.Header(name, value)
+		}
+	}
+	if err != nil {
+		problem.Abort(c, err)
+		return
+	}
+	c.Next()
+}
+
+// Headers returns the RateLimit-* headers describing d, and Retry-After
+// when d refused the request.
+func Headers(d domain.RateDecision) map[string]string {
+	headers := map[string]string{
+		"RateLimit-Limit":     strconv.Itoa(d.Limit),
+		"RateLimit-Remaining": strconv.Itoa(d.Remaining),
+		"RateLimit-Reset":     seconds(d.Reset),
+		"RateLimit-Policy":    strconv.Itoa(d.Limit) + ";w=" + seconds(d.Window),
+	}
+	if !d.Allowed {
+		headers["Retry-After"] = seconds(max(d.RetryAfter, time.Second))
+	}
+	return headers
+}
+
+// seconds rounds d up to whole seconds.
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
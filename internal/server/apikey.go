@@ -0,0 +1,82 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/itsbaivab/url-shortener/internal/core/services"
+)
+
+// runAPIKeyCommand implements the `apikey` subcommand, used to issue the
+// first admin key before any key can call the admin endpoints:
+//
+//	apikey issue <owner-id> [name] [--admin] [--workspace <id>]
+//	                                           issue a key and print it once
+//	apikey list                                list keys
+//	apikey revoke <id>                         revoke a key
+//
+// Keys are issued in the default workspace unless --workspace names
+// another.
+func runAPIKeyCommand(ctx context.Context, keys *services.APIKeyService, workspaces *services.WorkspaceService, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: apikey issue <owner-id> [name] [--admin] [--workspace <id>] | apikey list | apikey revoke <id>")
+	}
+
+	switch args[0] {
+	case "issue":
+		var scopes, rest []string
+		for i := 1; i < len(args); i++ {
+			switch args[i] {
+			case "--admin":
+				scopes = append(scopes, domain.ScopeAdmin)
+			case "--workspace":
+				if i+1 >= len(args) {
+					return errors.New("apikey issue: --workspace needs a workspace id")
+				}
+				i++
+				ctx = domain.WithWorkspace(ctx, args[i])
+			default:
+				rest = append(rest, args[i])
+			}
+		}
+		if len(rest) == 0 {
+			return errors.New("apikey issue: owner id is required")
+		}
+		name := strings.Join(rest[1:], " ")
+		if _, err := workspaces.Current(ctx); err != nil {
+			return err
+		}
+
+		token, key, err := keys.Issue(ctx, rest[0], name, scopes)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Issued key %s for %s in workspace %s. Store it now; it cannot be shown again:\n%s\n",
+			key.Id, key.OwnerID, key.WorkspaceID, token)
+		return nil
+	case "list":
+		all, err := keys.All(ctx)
+		if err != nil {
+			return err
+		}
+		for _, key := range all {
+			status := "active"
+			if key.Revoked() {
+				status = "revoked " + key.RevokedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%s\t%s\t%s\t%s\t%s\t%s\n", key.Id, key.WorkspaceID, key.OwnerID, strings.Join(key.Scopes, ","), key.Name, status)
+		}
+		return nil
+	case "revoke":
+		if len(args) < 2 {
+			return errors.New("apikey revoke: key id is required")
+		}
+		return keys.Revoke(ctx, args[1])
+	default:
+		return fmt.Errorf("unknown apikey action %q (want issue, list or revoke)", args[0])
+	}
+}
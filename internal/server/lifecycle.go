@@ -0,0 +1,166 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Component is a dependency with a start and stop hook. Either hook may be
+// nil. Components start in registration order and stop in reverse.
+type Component struct {
+	Name  string
+	Start func(ctx context.Context) error
+	Stop  func(ctx context.Context) error
+}
+
+// Every returns a component that calls fn every interval while it runs.
+// Failures are logged and retried at the next tick. Stop cancels the
+// context of a call in progress and waits for it to return.
+func Every(name string, interval time.Duration, fn func(ctx context.Context) error) Component {
+	var cancel context.CancelFunc
+	done := make(chan struct{})
+	return Component{
+		Name: name,
+		Start: func(context.Context) error {
+			var ctx context.Context
+			ctx, cancel = context.WithCancel(context.Background())
+			go func() {
+				defer close(done)
+				ticker := time.NewTicker(interval)
+				defer ticker.Stop()
+				for {
+					select {
+					case <-ctx.Done():
+						return
+					case <-ticker.C:
+						if err := fn(ctx); err != nil && ctx.Err() == nil {
+							slog.Warn("periodic task failed", "task", name, "error", err)
+						}
+					}
+				}
+			}()
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	}
+}
+
+// Lifecycle starts and stops components, tracks background workers and
+// reports readiness. The zero value is not usable; call NewLifecycle.
+type Lifecycle struct {
+	mu         sync.Mutex
+	components []Component
+	started    int
+
+	workers      sync.WaitGroup
+	workerCtx    context.Context
+	cancelWorker context.CancelFunc
+
+	ready atomic.Bool
+}
+
+func NewLifecycle() *Lifecycle {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Lifecycle{workerCtx: ctx, cancelWorker: cancel}
+}
+
+// Register adds c. Components registered after Start are not started.
+func (l *Lifecycle) Register(c Component) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.components = append(l.components, c)
+}
+
+// Start runs every start hook in order and marks the lifecycle ready. If a
+// hook fails, the components already started are stopped again.
+func (l *Lifecycle) Start(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, c := range l.components[l.started:] {
+		if c.Start != nil {
+			if err := c.Start(ctx); err != nil {
+				stopErr := l.stopStarted(ctx)
+				return errors.Join(fmt.Errorf("failed to start %s: %w", c.Name, err), stopErr)
+			}
+		}
+		l.started++
+	}
+
+	l.ready.Store(true)
+	return nil
+}
+
+// Ready reports whether every component started and the lifecycle is not
+// draining.
+func (l *Lifecycle) Ready() bool {
+	return l.ready.Load()
+}
+
+// Drain marks the lifecycle not ready so load balancers stop sending
+// traffic, without stopping anything yet.
+func (l *Lifecycle) Drain() {
+	l.ready.Store(false)
+}
+
+// Go runs fn as a tracked background worker. Stop waits for it to return.
+// The context passed to fn is cancelled only if Stop runs out of time.
+func (l *Lifecycle) Go(fn func(ctx context.Context)) {
+	l.workers.Add(1)
+	go func() {
+		defer l.workers.Done()
+		fn(l.workerCtx)
+	}()
+}
+
+// Stop drains, waits for background workers and then runs every stop hook
+// in reverse start order. Workers still running when ctx expires are
+// cancelled.
+func (l *Lifecycle) Stop(ctx context.Context) error {
+	l.Drain()
+
+	done := make(chan struct{})
+	go func() {
+		l.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		slog.Warn("background workers did not finish in time; cancelling them")
+		l.cancelWorker()
+		<-done
+	}
+	l.cancelWorker()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stopStarted(ctx)
+}
+
+func (l *Lifecycle) stopStarted(ctx context.Context) error {
+	var errs []error
+	for ; l.started > 0; l.started-- {
+		c := l.components[l.started-1]
+		if c.Stop == nil {
+			continue
+		}
+		if err := c.Stop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop %s: %w", c.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
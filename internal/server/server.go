@@ -0,0 +1,341 @@
+// Package server is the bootstrap shared by the gin microservices: config,
+// postgres, redis, migrations, health and metrics endpoints, and graceful
+// shutdown. Each service only registers its routes and dependencies.
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/itsbaivab/url-shortener/internal/adapters/cache"
+	"github.com/itsbaivab/url-shortener/internal/adapters/dns"
+	"github.com/itsbaivab/url-shortener/internal/adapters/geoip"
+	"github.com/itsbaivab/url-shortener/internal/adapters/messaging/rabbitmq"
+	"github.com/itsbaivab/url-shortener/internal/adapters/metrics"
+	"github.com/itsbaivab/url-shortener/internal/adapters/repository/postgres"
+	"github.com/itsbaivab/url-shortener/internal/auth"
+	"github.com/itsbaivab/url-shortener/internal/config"
+	"github.com/itsbaivab/url-shortener/internal/core/ports"
+	"github.com/itsbaivab/url-shortener/internal/core/services"
+	"github.com/itsbaivab/url-shortener/internal/health"
+	"github.com/itsbaivab/url-shortener/internal/logging"
+	"github.com/itsbaivab/url-shortener/internal/ratelimit"
+	"github.com/itsbaivab/url-shortener/internal/tenant"
+	"github.com/itsbaivab/url-shortener/internal/tracing"
+	_ "github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Options describe a service.
+type Options struct {
+	// Name is used in log lines, e.g. "Link Service".
+	Name string
+	// DefaultPort is used when no port is configured.
+	DefaultPort string
+}
+
+// topLinks is how many of the most clicked links are exported as
+// per-link click metrics.
+const topLinks = 50
+
+// Server holds the dependencies shared by every service. Setup code adds
+// routes to Router, lifecycle hooks through the embedded Lifecycle,
+// readiness checks to Health and passes Metrics to the core services.
+// Routes are scoped to a workspace and custom domain by Tenant, which
+// resolves the Host header through Domains, and protected by Auth, which
+// accepts API keys and, when configured, JWT bearer tokens. RateLimit
+// limits requests per client IP and goes before Auth, so failed attempts
+// at credentials count too; KeyLimit limits per credential and goes
+// after it.
+// Audit records the changes the shared services make; services pass it
+// to their own. Components only some services need are built on first
+// use of Blocklist, Signing or GeoIP, so a service only loads and reloads
+// what it asks for.
+type Server struct {
+	*Lifecycle
+	Config     *config.Config
+	DB         *sql.DB
+	Cache      *cache.RedisCache
+	Router     *gin.Engine
+	Health     *health.Registry
+	Metrics    *metrics.Prometheus
+	APIKeys    *services.APIKeyService
+	Workspaces *services.WorkspaceService
+	Domains    *services.DomainService
+	RateLimits *services.RateLimitService
+	Audit      *services.AuditService
+	Tenant     gin.HandlerFunc
+	Auth       gin.HandlerFunc
+	RateLimit  gin.HandlerFunc
+	KeyLimit   gin.HandlerFunc
+
+	blocklist *services.BlocklistService
+	signing   *services.SigningService
+	geo       ports.GeoIP
+}
+
+// Run bootstraps the service, calls setup to register routes and
+// components, serves until SIGINT or SIGTERM and then shuts down in order:
+// drain, stop accepting requests, wait for background workers, stop
+// components in reverse, close redis and postgres.
+//
+// Invoked as `<service> migrate ...`, Run applies migrations and exits;
+// `<service> apikey ...` manages API keys and exits.
+func Run(opts Options, setup func(s *Server) error) {
+	cfg := config.NewConfig(config.Database, config.Redis)
+	slog.SetDefault(slog.Default().With("service", serviceName(opts.Name)))
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing, serviceName(opts.Name))
+	if err != nil {
+		logging.Fatal("failed to set up tracing", "error", err)
+	}
+
+	db, err := openDB(cfg.Database)
+	if err != nil {
+		logging.Fatal("failed to connect to database", "error", err)
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err := postgres.RunMigrateCommand(context.Background(), db, os.Args[2:])
+		db.Close()
+		if err != nil {
+			logging.Fatal("migration failed", "error", err)
+		}
+		return
+	}
+	if cfg.Database.AutoMigrate {
+		if err := postgres.RunMigrateCommand(context.Background(), db, []string{"up"}); err != nil {
+			logging.Fatal("migration failed", "error", err)
+		}
+	}
+
+	audit := services.NewAuditService(postgres.NewPostgresAuditRepository(db)).WithHashChain(cfg.Audit.HashChain)
+	apiKeys := services.NewAPIKeyService(postgres.NewPostgresAPIKeyRepository(db)).WithAudit(audit)
+	workspaces := services.NewWorkspaceService(postgres.NewPostgresWorkspaceRepository(db)).WithAudit(audit)
+	domains := services.NewDomainService(postgres.NewPostgresDomainRepository(db),
+		dns.NewResolver(cfg.DNS.Server, cfg.DNS.Timeout)).WithAudit(audit)
+	if len(os.Args) > 1 && os.Args[1] == "apikey" {
+		err := runAPIKeyCommand(context.Background(), apiKeys, workspaces, os.Args[2:])
+		db.Close()
+		if err != nil {
+			logging.Fatal("apikey command failed", "error", err)
+		}
+		return
+	}
+
+	authenticators := []auth.Authenticator{apiKeys}
+	if cfg.JWT.Enabled() {
+		authenticators = append(authenticators, auth.NewJWTVerifier(cfg.JWT))
+	}
+
+	redisAddress, redisPassword, redisDB := cfg.GetRedisParams()
+	redisCache := cache.NewRedisCache(redisAddress, redisPassword, redisDB)
+	rateLimits := services.NewRateLimitService(redisCache, cache.NewMemoryLimiter()).
+		WithLimits(cfg.RateLimit.PerIPLimit(), cfg.RateLimit.PerKeyLimit())
+
+	router, err := NewRouter(cfg.TrustedProxies)
+	if err != nil {
+		logging.Fatal("failed to set up router", "error", err)
+	}
+
+	s := &Server{
+		Lifecycle:  NewLifecycle(),
+		Config:     cfg,
+		DB:         db,
+		Cache:      redisCache,
+		Router:     router,
+		Health:     health.NewRegistry(cfg.Health.Timeout, cfg.Health.CacheTTL),
+		Metrics:    metrics.NewPrometheus(prometheus.DefaultRegisterer, topLinks),
+		APIKeys:    apiKeys,
+		Workspaces: workspaces,
+		Domains:    domains,
+		RateLimits: rateLimits,
+		Audit:      audit,
+		Tenant:     tenant.Middleware(domains),
+		Auth:       auth.Middleware(authenticators...),
+		RateLimit:  ratelimit.PerIP(rateLimits),
+		KeyLimit:   ratelimit.PerKey(rateLimits),
+	}
+	s.Router.Use(
+		tracing.Middleware(),
+		logging.RequestID(),
+		logging.AccessLog(),
+		gin.Recovery(),
+		metrics.Middleware(prometheus.DefaultRegisterer),
+	)
+	// Registered first so buffered spans are flushed last.
+	s.Register(Component{Name: "tracing", Stop: shutdownTracing})
+	s.Register(Component{Name: "postgres", Stop: func(context.Context) error { return db.Close() }})
+	s.Register(Component{Name: "redis", Stop: func(context.Context) error { return s.Cache.Close() }})
+
+	s.Health.Register("postgres", health.CheckerFunc(db.PingContext))
+	s.Health.Register("redis", health.CheckerFunc(s.Cache.Ping))
+	if cfg.RabbitMQURL != "" {
+		s.Health.Register("rabbitmq", health.CheckerFunc(rabbitmq.NewRabbitMQ(cfg.RabbitMQURL).Ping))
+	}
+
+	// Health checks: /livez only says the process is serving; /readyz
+	// also checks dependencies and fails while starting or draining.
+	// /health is kept as an alias of /readyz for existing probes.
+	s.Router.GET("/livez", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": health.StatusOK})
+	})
+	s.Router.GET("/readyz", s.readyz)
+	s.Router.GET("/health", s.readyz)
+
+	// Metrics endpoint
+	s.Router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
+	// Runtime log level of the process, for operators; not routed by the
+	// gateway, but pods may be reached without it.
+	debug := s.Router.Group("/debug", s.RateLimit, s.Auth, s.KeyLimit, auth.RequireOperator())
+	debug.GET("/log-level", logging.LevelHandler)
+	debug.PUT("/log-level", logging.LevelHandler)
+
+	if err := setup(s); err != nil {
+		logging.Fatal("failed to set up service", "error", err)
+	}
+
+	if err := s.Start(context.Background()); err != nil {
+		logging.Fatal("failed to start service", "error", err)
+	}
+
+	port := cfg.ServicePort(opts.DefaultPort)
+	srv := &http.Server{
+		Addr:    ":" + port,
+		Handler: s.Router,
+	}
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logging.Fatal("failed to start server", "error", err)
+		}
+	}()
+
+	slog.Info("service started", "port", port)
+
+	// Wait for interrupt signal
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	slog.Info("shutting down")
+	s.Drain()
+	time.Sleep(cfg.Health.DrainDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		slog.Warn("server forced to shut down", "error", err)
+	}
+	if err := s.Stop(ctx); err != nil {
+		slog.Error("shutdown finished with errors", "error", err)
+	}
+
+	slog.Info("service stopped")
+}
+
+// NewRouter returns a gin engine that only takes the client IP from
+// X-Forwarded-For and X-Real-IP on requests from trustedProxies. Anyone
+// else could pick the IP their requests are limited and recorded under.
+func NewRouter(trustedProxies []string) (*gin.Engine, error) {
+	router := gin.New()
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		return nil, fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+	return router, nil
+}
+
+// Blocklist returns the block rules of the service. The first call
+// registers components that load them at start and reload them
+// periodically, so it must happen in setup.
+func (s *Server) Blocklist() *services.BlocklistService {
+	if s.blocklist == nil {
+		s.blocklist = services.NewBlocklistService(postgres.NewPostgresBlocklistRepository(s.DB)).
+			WithFiles(s.Config.Blocklist.Files...).
+			WithAudit(s.Audit)
+		s.Register(Component{Name: "blocklist", Start: s.blocklist.Reload})
+		s.Register(Every("blocklist reload", s.Config.Blocklist.ReloadInterval, s.blocklist.Reload))
+	}
+	return s.blocklist
+}
+
+// Signing returns the service that signs and verifies the URLs of links
+// that require a signature.
+func (s *Server) Signing() *services.SigningService {
+	if s.signing == nil {
+		s.signing = services.NewSigningService(s.Config.Signing.SigningKeys()...).
+			WithTTL(s.Config.Signing.DefaultTTL, s.Config.Signing.MaxTTL)
+	}
+	return s.signing
+}
+
+// GeoIP returns the database that locates visitors for the geo rules of
+// links, loaded on the first call. Without a configured file it locates
+// no one.
+func (s *Server) GeoIP() (ports.GeoIP, error) {
+	if s.geo != nil {
+		return s.geo, nil
+	}
+	if s.Config.GeoIP.Database == "" {
+		s.geo = &geoip.Database{}
+		return s.geo, nil
+	}
+	geo, err := geoip.Open(s.Config.GeoIP.Database)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load GeoIP database: %w", err)
+	}
+	slog.Info("loaded GeoIP database", "path", s.Config.GeoIP.Database, "ranges", geo.Len())
+	s.geo = geo
+	return geo, nil
+}
+
+func (s *Server) readyz(c *gin.Context) {
+	if !s.Ready() {
+		c.JSON(http.StatusServiceUnavailable, health.Report{Status: "draining"})
+		return
+	}
+
+	report := s.Health.Run(c.Request.Context())
+	if !report.Healthy() {
+		c.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// serviceName turns "Link Service" into "link-service".
+func serviceName(name string) string {
+	return strings.ReplaceAll(strings.ToLower(name), " ", "-")
+}
+
+// openDB opens postgres with the configured pool limits and checks that
+// it is reachable.
+func openDB(cfg config.DatabaseConfig) (*sql.DB, error) {
+	db, err := sql.Open("postgres", cfg.DSN())
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
@@ -0,0 +1,33 @@
+// Package tenant scopes gin requests to the workspace that verified the
+// requested host as a custom domain. Authenticated routes are further
+// scoped to the caller's workspace by auth.Middleware.
+package tenant
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/itsbaivab/url-shortener/internal/adapters/problem"
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/itsbaivab/url-shortener/internal/core/services"
+	"github.com/itsbaivab/url-shortener/internal/logging"
+)
+
+// Middleware resolves the Host header to a workspace and custom domain,
+// so links are looked up among that domain's links. Hosts no workspace
+// verified are left unscoped and serve the default workspace's links on
+// the shared domain. When the lookup fails the request is rejected rather
+// than served from the wrong workspace.
+func Middleware(domains *services.DomainService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		workspaceID, name, ok, err := domains.Resolve(c.Request.Context(), c.Request.Host)
+		if err != nil {
+			problem.Abort(c, err)
+			return
+		}
+		if ok {
+			ctx := domain.WithLinkDomain(domain.WithWorkspace(c.Request.Context(), workspaceID), name)
+			c.Request = c.Request.WithContext(ctx)
+			logging.Annotate(c, "workspace_id", workspaceID, "domain", name)
+		}
+		c.Next()
+	}
+}
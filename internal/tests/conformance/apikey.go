@@ -0,0 +1,75 @@
+package conformance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/itsbaivab/url-shortener/internal/core/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// APIKeyPort runs the ports.APIKeyPort suite. newPort is called once per
+// subtest.
+func APIKeyPort(t *testing.T, newPort func(t *testing.T) ports.APIKeyPort) {
+	ctx := context.Background()
+	newKey := func() domain.APIKey {
+		return domain.APIKey{
+			Id:          uniqueID("key"),
+			WorkspaceID: otherWorkspace,
+			OwnerID:     uniqueID("owner"),
+			Name:        "ci",
+			Hash:        "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+			Scopes:      []string{domain.ScopeAdmin},
+			CreatedAt:   at(0),
+		}
+	}
+
+	t.Run("CreateThenGet", func(t *testing.T) {
+		repo := newPort(t)
+		key := newKey()
+		require.NoError(t, repo.Create(ctx, key))
+
+		got, err := repo.Get(ctx, key.Id)
+		require.NoError(t, err)
+		assert.Equal(t, key.WorkspaceID, got.WorkspaceID)
+		assert.Equal(t, key.OwnerID, got.OwnerID)
+		assert.Equal(t, key.Hash, got.Hash)
+		assert.Equal(t, key.Scopes, got.Scopes)
+		assert.False(t, got.Revoked())
+	})
+
+	t.Run("GetMissingFails", func(t *testing.T) {
+		repo := newPort(t)
+		_, err := repo.Get(ctx, uniqueID("missing"))
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("DuplicateIDFails", func(t *testing.T) {
+		repo := newPort(t)
+		key := newKey()
+		require.NoError(t, repo.Create(ctx, key))
+		assert.ErrorIs(t, repo.Create(ctx, key), domain.ErrConflict)
+	})
+
+	t.Run("RevokeKeepsFirstRevocation", func(t *testing.T) {
+		repo := newPort(t)
+		key := newKey()
+		require.NoError(t, repo.Create(ctx, key))
+
+		require.NoError(t, repo.Revoke(ctx, key.Id, at(time.Hour)))
+		require.NoError(t, repo.Revoke(ctx, key.Id, at(2*time.Hour)))
+
+		got, err := repo.Get(ctx, key.Id)
+		require.NoError(t, err)
+		require.True(t, got.Revoked())
+		assert.WithinDuration(t, at(time.Hour), *got.RevokedAt, time.Millisecond)
+	})
+
+	t.Run("RevokeMissingFails", func(t *testing.T) {
+		repo := newPort(t)
+		assert.ErrorIs(t, repo.Revoke(ctx, uniqueID("missing"), at(0)), domain.ErrNotFound)
+	})
+}
@@ -0,0 +1,150 @@
+package conformance
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/itsbaivab/url-shortener/internal/core/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// AuditPort runs the ports.AuditPort suite. The log cannot be emptied, so
+// every subtest works on entries of a fresh resource ID.
+func AuditPort(t *testing.T, newPort func(t *testing.T) ports.AuditPort) {
+	ctx := context.Background()
+	newEntry := func(resourceID, action string, offset time.Duration) domain.AuditEntry {
+		return domain.AuditEntry{
+			Action:      action,
+			Actor:       "root (key k1)",
+			WorkspaceID: workspace,
+			ResourceID:  resourceID,
+			RequestID:   uniqueID("request"),
+			SourceIP:    "203.0.113.7",
+			Before:      json.RawMessage(`{"id":"abc","original_url":"https://example.com/"}`),
+			After:       json.RawMessage(`{"id":"abc","original_url":"https://example.org/"}`),
+			CreatedAt:   at(offset),
+		}
+	}
+	appendAll := func(t *testing.T, repo ports.AuditPort, chain bool, entries ...domain.AuditEntry) []domain.AuditEntry {
+		t.Helper()
+		var appended []domain.AuditEntry
+		for _, entry := range entries {
+			got, err := repo.Append(ctx, entry, chain)
+			require.NoError(t, err)
+			appended = append(appended, got)
+		}
+		return appended
+	}
+	seqs := func(entries []domain.AuditEntry) []int64 {
+		var out []int64
+		for _, entry := range entries {
+			out = append(out, entry.Seq)
+		}
+		return out
+	}
+
+	t.Run("AppendThenFind", func(t *testing.T) {
+		repo := newPort(t)
+		resource := uniqueID("link")
+		entry := newEntry(resource, domain.AuditLinkCreated, 0)
+		entry.Before = nil
+		appended := appendAll(t, repo, false, entry)[0]
+		assert.Positive(t, appended.Seq)
+		assert.Empty(t, appended.Hash, "entries are only hashed when chained")
+
+		got, err := repo.Find(ctx, domain.AuditFilter{ResourceID: resource, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+		assert.WithinDuration(t, entry.CreatedAt, got[0].CreatedAt, time.Microsecond)
+		got[0].CreatedAt = appended.CreatedAt
+		assert.Equal(t, appended, got[0])
+	})
+
+	t.Run("FindReturnsNewestFirstAndPages", func(t *testing.T) {
+		repo := newPort(t)
+		resource := uniqueID("link")
+		appended := appendAll(t, repo, false,
+			newEntry(resource, domain.AuditLinkCreated, 0),
+			newEntry(resource, domain.AuditLinkDisabled, time.Minute),
+			newEntry(resource, domain.AuditLinkRestored, 2*time.Minute),
+		)
+		assert.Less(t, appended[0].Seq, appended[1].Seq)
+		assert.Less(t, appended[1].Seq, appended[2].Seq)
+
+		page, err := repo.Find(ctx, domain.AuditFilter{ResourceID: resource, Limit: 2})
+		require.NoError(t, err)
+		assert.Equal(t, []int64{appended[2].Seq, appended[1].Seq}, seqs(page))
+
+		page, err = repo.Find(ctx, domain.AuditFilter{ResourceID: resource, Limit: 2, Before: page[1].Seq})
+		require.NoError(t, err)
+		assert.Equal(t, []int64{appended[0].Seq}, seqs(page))
+	})
+
+	t.Run("FindFilters", func(t *testing.T) {
+		repo := newPort(t)
+		resource := uniqueID("link")
+		created := newEntry(resource, domain.AuditLinkCreated, 0)
+		other := newEntry(resource, domain.AuditLinkDeleted, time.Hour)
+		other.Actor, other.WorkspaceID = "someone", otherWorkspace
+		appended := appendAll(t, repo, false, created, other)
+
+		for name, tt := range map[string]struct {
+			filter domain.AuditFilter
+			want   []int64
+		}{
+			"workspace":       {domain.AuditFilter{WorkspaceID: otherWorkspace}, []int64{appended[1].Seq}},
+			"actor":           {domain.AuditFilter{Actor: "root (key k1)"}, []int64{appended[0].Seq}},
+			"action":          {domain.AuditFilter{Action: domain.AuditLinkDeleted}, []int64{appended[1].Seq}},
+			"action prefix":   {domain.AuditFilter{Action: "link."}, []int64{appended[1].Seq, appended[0].Seq}},
+			"other resources": {domain.AuditFilter{Action: "api_key."}, nil},
+			"since":           {domain.AuditFilter{Since: at(time.Minute)}, []int64{appended[1].Seq}},
+			"until":           {domain.AuditFilter{Until: at(time.Minute)}, []int64{appended[0].Seq}},
+		} {
+			tt.filter.ResourceID, tt.filter.Limit = resource, 10
+			got, err := repo.Find(ctx, tt.filter)
+			require.NoError(t, err, name)
+			assert.Equal(t, tt.want, seqs(got), name)
+		}
+	})
+
+	t.Run("ChainedAppendsLinkHashes", func(t *testing.T) {
+		repo := newPort(t)
+		resource := uniqueID("link")
+		appended := appendAll(t, repo, true,
+			newEntry(resource, domain.AuditLinkCreated, 0),
+			newEntry(resource, domain.AuditLinkDeleted, time.Minute),
+		)
+		for _, entry := range appended {
+			assert.Equal(t, entry.ComputeHash(), entry.Hash)
+		}
+		assert.Equal(t, appended[0].Hash, appended[1].PrevHash)
+
+		stored, err := repo.Find(ctx, domain.AuditFilter{ResourceID: resource, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, stored, 2)
+		for _, entry := range stored {
+			assert.Equal(t, entry.Hash, entry.ComputeHash(), "hashes survive the round trip")
+		}
+	})
+
+	t.Run("ScanPagesOldestFirst", func(t *testing.T) {
+		repo := newPort(t)
+		resource := uniqueID("link")
+		appended := appendAll(t, repo, false,
+			newEntry(resource, domain.AuditLinkCreated, 0),
+			newEntry(resource, domain.AuditLinkDeleted, time.Minute),
+		)
+
+		page, err := repo.Scan(ctx, appended[0].Seq-1, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{appended[0].Seq}, seqs(page))
+
+		page, err = repo.Scan(ctx, appended[0].Seq, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{appended[1].Seq}, seqs(page))
+	})
+}
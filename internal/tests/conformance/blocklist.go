@@ -0,0 +1,89 @@
+package conformance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/itsbaivab/url-shortener/internal/core/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// BlocklistPort runs the ports.BlocklistPort suite. newPort is called
+// once per subtest; rules are created under fresh IDs.
+func BlocklistPort(t *testing.T, newPort func(t *testing.T) ports.BlocklistPort) {
+	ctx := context.Background()
+	newRule := func(offset time.Duration) domain.BlockRule {
+		return domain.BlockRule{
+			Id:        uniqueID("rule"),
+			Kind:      domain.BlockDomain,
+			Pattern:   uniqueID("evil") + ".example.com",
+			Reason:    "phishing",
+			CreatedAt: at(offset),
+		}
+	}
+	ruleIDs := func(rules []domain.BlockRule, want ...string) []string {
+		wanted := map[string]bool{}
+		for _, id := range want {
+			wanted[id] = true
+		}
+		var ids []string
+		for _, rule := range rules {
+			if wanted[rule.Id] {
+				ids = append(ids, rule.Id)
+			}
+		}
+		return ids
+	}
+
+	t.Run("CreateThenAll", func(t *testing.T) {
+		repo := newPort(t)
+		rule := newRule(0)
+		require.NoError(t, repo.Create(ctx, rule))
+
+		all, err := repo.All(ctx)
+		require.NoError(t, err)
+		var got domain.BlockRule
+		for _, r := range all {
+			if r.Id == rule.Id {
+				got = r
+			}
+		}
+		assert.Equal(t, rule.Kind, got.Kind)
+		assert.Equal(t, rule.Pattern, got.Pattern)
+		assert.Equal(t, rule.Reason, got.Reason)
+		assert.WithinDuration(t, rule.CreatedAt, got.CreatedAt, time.Millisecond)
+	})
+
+	t.Run("AllReturnsOldestFirst", func(t *testing.T) {
+		repo := newPort(t)
+		newer, older := newRule(time.Hour), newRule(0)
+		require.NoError(t, repo.Create(ctx, newer))
+		require.NoError(t, repo.Create(ctx, older))
+
+		all, err := repo.All(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{older.Id, newer.Id}, ruleIDs(all, older.Id, newer.Id))
+	})
+
+	t.Run("DuplicateIDFails", func(t *testing.T) {
+		repo := newPort(t)
+		rule := newRule(0)
+		require.NoError(t, repo.Create(ctx, rule))
+		assert.ErrorIs(t, repo.Create(ctx, rule), domain.ErrConflict)
+	})
+
+	t.Run("DeleteRemovesRule", func(t *testing.T) {
+		repo := newPort(t)
+		rule := newRule(0)
+		require.NoError(t, repo.Create(ctx, rule))
+		require.NoError(t, repo.Delete(ctx, rule.Id))
+		assert.ErrorIs(t, repo.Delete(ctx, rule.Id), domain.ErrNotFound)
+
+		all, err := repo.All(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, ruleIDs(all, rule.Id))
+	})
+}
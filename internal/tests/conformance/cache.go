@@ -0,0 +1,91 @@
+package conformance
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/itsbaivab/url-shortener/internal/core/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Cache runs the ports.Cache suite. A miss is not an error: Get returns an
+// empty string, and deleting a missing key succeeds.
+func Cache(t *testing.T, newCache func(t *testing.T) ports.Cache) {
+	ctx := context.Background()
+
+	t.Run("SetThenGet", func(t *testing.T) {
+		c := newCache(t)
+		key := uniqueID("key")
+		require.NoError(t, c.Set(ctx, key, "https://example.com"))
+
+		val, err := c.Get(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.com", val)
+	})
+
+	t.Run("GetMissingIsEmpty", func(t *testing.T) {
+		c := newCache(t)
+		val, err := c.Get(ctx, uniqueID("missing"))
+		require.NoError(t, err)
+		assert.Empty(t, val)
+	})
+
+	t.Run("SetOverwrites", func(t *testing.T) {
+		c := newCache(t)
+		key := uniqueID("key")
+		require.NoError(t, c.Set(ctx, key, "first"))
+		require.NoError(t, c.Set(ctx, key, "second"))
+
+		val, err := c.Get(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, "second", val)
+	})
+
+	t.Run("DeleteRemovesKey", func(t *testing.T) {
+		c := newCache(t)
+		key := uniqueID("key")
+		require.NoError(t, c.Set(ctx, key, "value"))
+		require.NoError(t, c.Delete(ctx, key))
+
+		val, err := c.Get(ctx, key)
+		require.NoError(t, err)
+		assert.Empty(t, val)
+	})
+
+	t.Run("DeleteMissingSucceeds", func(t *testing.T) {
+		c := newCache(t)
+		assert.NoError(t, c.Delete(ctx, uniqueID("missing")))
+	})
+
+	t.Run("ConcurrentAccess", func(t *testing.T) {
+		c := newCache(t)
+		keys := make([]string, concurrency)
+		for i := range keys {
+			keys[i] = uniqueID("conc")
+		}
+
+		var wg sync.WaitGroup
+		errs := make([]error, concurrency)
+		for i, key := range keys {
+			wg.Add(1)
+			go func(i int, key string) {
+				defer wg.Done()
+				if err := c.Set(ctx, key, key); err != nil {
+					errs[i] = err
+					return
+				}
+				_, errs[i] = c.Get(ctx, key)
+			}(i, key)
+		}
+		wg.Wait()
+
+		for i, key := range keys {
+			require.NoError(t, errs[i])
+			val, err := c.Get(ctx, key)
+			require.NoError(t, err)
+			assert.Equal(t, key, val)
+		}
+	})
+}
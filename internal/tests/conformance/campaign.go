@@ -0,0 +1,112 @@
+package conformance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/itsbaivab/url-shortener/internal/core/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// CampaignPort runs the ports.CampaignPort suite. newPort is called once
+// per subtest; campaigns are created under fresh IDs.
+func CampaignPort(t *testing.T, newPort func(t *testing.T) ports.CampaignPort) {
+	ctx := context.Background()
+	newCampaign := func(workspaceID string, offset time.Duration) domain.Campaign {
+		return domain.Campaign{
+			Id:          uniqueID("camp"),
+			WorkspaceID: workspaceID,
+			Name:        "Spring sale",
+			UTM: domain.UTMTemplate{
+				Defaults:  domain.UTM{Source: "newsletter", Medium: "email", Campaign: "spring"},
+				Platforms: map[string]domain.UTM{"instagram": {Source: "instagram", Medium: "social"}},
+			},
+			CreatedAt: at(offset),
+		}
+	}
+	campaignIDs := func(campaigns []domain.Campaign, want ...string) []string {
+		wanted := map[string]bool{}
+		for _, id := range want {
+			wanted[id] = true
+		}
+		var ids []string
+		for _, campaign := range campaigns {
+			if wanted[campaign.Id] {
+				ids = append(ids, campaign.Id)
+			}
+		}
+		return ids
+	}
+
+	t.Run("CreateThenGet", func(t *testing.T) {
+		repo := newPort(t)
+		campaign := newCampaign(workspace, 0)
+		require.NoError(t, repo.Create(ctx, campaign))
+
+		got, err := repo.Get(ctx, workspace, campaign.Id)
+		require.NoError(t, err)
+		assert.Equal(t, campaign.Name, got.Name)
+		assert.Equal(t, campaign.UTM, got.UTM)
+		assert.WithinDuration(t, campaign.CreatedAt, got.CreatedAt, time.Millisecond)
+	})
+
+	t.Run("GetMissingFails", func(t *testing.T) {
+		repo := newPort(t)
+		_, err := repo.Get(ctx, workspace, uniqueID("missing"))
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("DuplicateIDFails", func(t *testing.T) {
+		repo := newPort(t)
+		campaign := newCampaign(workspace, 0)
+		require.NoError(t, repo.Create(ctx, campaign))
+		assert.ErrorIs(t, repo.Create(ctx, campaign), domain.ErrConflict)
+	})
+
+	t.Run("AllReturnsOldestFirstPerWorkspace", func(t *testing.T) {
+		repo := newPort(t)
+		newer, older, theirs := newCampaign(workspace, time.Hour), newCampaign(workspace, 0), newCampaign(otherWorkspace, 0)
+		for _, campaign := range []domain.Campaign{newer, older, theirs} {
+			require.NoError(t, repo.Create(ctx, campaign))
+		}
+
+		all, err := repo.All(ctx, workspace)
+		require.NoError(t, err)
+		assert.Equal(t, []string{older.Id, newer.Id}, campaignIDs(all, older.Id, newer.Id, theirs.Id))
+
+		_, err = repo.Get(ctx, workspace, theirs.Id)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("UpdateReplacesTemplate", func(t *testing.T) {
+		repo := newPort(t)
+		campaign := newCampaign(workspace, 0)
+		require.NoError(t, repo.Create(ctx, campaign))
+
+		campaign.Name = "Summer sale"
+		campaign.UTM = domain.UTMTemplate{Defaults: domain.UTM{Campaign: "summer"}}
+		require.NoError(t, repo.Update(ctx, campaign))
+
+		got, err := repo.Get(ctx, workspace, campaign.Id)
+		require.NoError(t, err)
+		assert.Equal(t, "Summer sale", got.Name)
+		assert.Equal(t, campaign.UTM, got.UTM)
+
+		missing := newCampaign(workspace, 0)
+		assert.ErrorIs(t, repo.Update(ctx, missing), domain.ErrNotFound)
+	})
+
+	t.Run("DeleteRemovesCampaign", func(t *testing.T) {
+		repo := newPort(t)
+		campaign := newCampaign(workspace, 0)
+		require.NoError(t, repo.Create(ctx, campaign))
+		require.NoError(t, repo.Delete(ctx, workspace, campaign.Id))
+
+		_, err := repo.Get(ctx, workspace, campaign.Id)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+		assert.ErrorIs(t, repo.Delete(ctx, workspace, campaign.Id), domain.ErrNotFound)
+	})
+}
@@ -0,0 +1,36 @@
+// Package conformance holds behaviour suites shared by every adapter of a
+// port. An adapter passes when it runs the suite against a fresh instance
+// and every subtest succeeds.
+package conformance
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+)
+
+// concurrency is the number of goroutines used by the concurrency checks.
+// It stays below the number of links the DynamoDB adapter returns from All.
+const concurrency = 10
+
+// The suites write to workspace and check isolation against
+// otherWorkspace, and between the shared domain and customDomain.
+const (
+	workspace      = domain.DefaultWorkspaceID
+	otherWorkspace = "conformance-other"
+	customDomain   = "go.conformance.example.com"
+)
+
+// uniqueID returns an identifier that does not collide with data left
+// behind by earlier runs against a shared database.
+func uniqueID(prefix string) string {
+	return prefix + "-" + strings.ReplaceAll(uuid.NewString(), "-", "")[:12]
+}
+
+// at returns a timestamp offset from a fixed base, rounded to the
+// microsecond precision every backend can store.
+func at(offset time.Duration) time.Time {
+	return time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC).Add(offset)
+}
@@ -0,0 +1,206 @@
+package conformance
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/itsbaivab/url-shortener/internal/adapters/cache"
+	"github.com/itsbaivab/url-shortener/internal/adapters/repository"
+	"github.com/itsbaivab/url-shortener/internal/adapters/repository/postgres"
+	"github.com/itsbaivab/url-shortener/internal/core/ports"
+	"github.com/itsbaivab/url-shortener/internal/tests/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// The real adapters only run when their backend is configured. Each one
+// must point at a scratch database or table: the suite deletes every row
+// before each subtest.
+//
+//	CONFORMANCE_POSTGRES_DSN          postgres DSN, migrated with `migrate up`
+//	CONFORMANCE_DYNAMODB_LINK_TABLE   DynamoDB links table (AWS_ENDPOINT_URL for local)
+//	CONFORMANCE_DYNAMODB_STATS_TABLE  DynamoDB stats table
+//	CONFORMANCE_REDIS_ADDR            redis host:port
+
+func TestMockConformance(t *testing.T) {
+	t.Run("LinkPort", func(t *testing.T) {
+		LinkPort(t, func(t *testing.T) ports.LinkPort {
+			return &mock.MockLinkRepo{}
+		})
+	})
+	t.Run("StatsPort", func(t *testing.T) {
+		StatsPort(t, func(t *testing.T) StatsFixture {
+			return StatsFixture{Links: &mock.MockLinkRepo{}, Stats: &mock.MockStatsRepo{}}
+		})
+	})
+	t.Run("Cache", func(t *testing.T) {
+		Cache(t, func(t *testing.T) ports.Cache {
+			return mock.NewMockRedisCache()
+		})
+	})
+	t.Run("APIKeyPort", func(t *testing.T) {
+		APIKeyPort(t, func(t *testing.T) ports.APIKeyPort {
+			return mock.NewMockAPIKeyRepo()
+		})
+	})
+	t.Run("WorkspacePort", func(t *testing.T) {
+		WorkspacePort(t, func(t *testing.T) ports.WorkspacePort {
+			return mock.NewMockWorkspaceRepo()
+		})
+	})
+	t.Run("DomainPort", func(t *testing.T) {
+		DomainPort(t, func(t *testing.T) ports.DomainPort {
+			return mock.NewMockDomainRepo()
+		})
+	})
+	t.Run("BlocklistPort", func(t *testing.T) {
+		BlocklistPort(t, func(t *testing.T) ports.BlocklistPort {
+			return mock.NewMockBlocklistRepo()
+		})
+	})
+	t.Run("AbuseReportPort", func(t *testing.T) {
+		AbuseReportPort(t, func(t *testing.T) ports.AbuseReportPort {
+			return mock.NewMockAbuseReportRepo()
+		})
+	})
+	t.Run("AuditPort", func(t *testing.T) {
+		AuditPort(t, func(t *testing.T) ports.AuditPort {
+			return mock.NewMockAuditRepo()
+		})
+	})
+	t.Run("CampaignPort", func(t *testing.T) {
+		CampaignPort(t, func(t *testing.T) ports.CampaignPort {
+			return mock.NewMockCampaignRepo()
+		})
+	})
+}
+
+func TestPostgresConformance(t *testing.T) {
+	dsn := os.Getenv("CONFORMANCE_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("CONFORMANCE_POSTGRES_DSN not set")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, db.Ping())
+
+	fixture := func(t *testing.T) StatsFixture {
+		f := StatsFixture{
+			Links: postgres.NewPostgresLinkRepository(db),
+			Stats: postgres.NewPostgresStatsRepository(db),
+		}
+		reset(t, f)
+		return f
+	}
+
+	t.Run("LinkPort", func(t *testing.T) {
+		LinkPort(t, func(t *testing.T) ports.LinkPort { return fixture(t).Links })
+	})
+	t.Run("StatsPort", func(t *testing.T) {
+		StatsPort(t, fixture)
+	})
+	t.Run("APIKeyPort", func(t *testing.T) {
+		APIKeyPort(t, func(t *testing.T) ports.APIKeyPort {
+			return postgres.NewPostgresAPIKeyRepository(db)
+		})
+	})
+	t.Run("WorkspacePort", func(t *testing.T) {
+		WorkspacePort(t, func(t *testing.T) ports.WorkspacePort {
+			return postgres.NewPostgresWorkspaceRepository(db)
+		})
+	})
+	t.Run("DomainPort", func(t *testing.T) {
+		DomainPort(t, func(t *testing.T) ports.DomainPort {
+			return postgres.NewPostgresDomainRepository(db)
+		})
+	})
+	t.Run("BlocklistPort", func(t *testing.T) {
+		BlocklistPort(t, func(t *testing.T) ports.BlocklistPort {
+			return postgres.NewPostgresBlocklistRepository(db)
+		})
+	})
+	t.Run("AbuseReportPort", func(t *testing.T) {
+		AbuseReportPort(t, func(t *testing.T) ports.AbuseReportPort {
+			return postgres.NewPostgresAbuseReportRepository(db)
+		})
+	})
+	t.Run("AuditPort", func(t *testing.T) {
+		AuditPort(t, func(t *testing.T) ports.AuditPort {
+			return postgres.NewPostgresAuditRepository(db)
+		})
+	})
+	t.Run("CampaignPort", func(t *testing.T) {
+		CampaignPort(t, func(t *testing.T) ports.CampaignPort {
+			return postgres.NewPostgresCampaignRepository(db)
+		})
+	})
+}
+
+func TestDynamoDBConformance(t *testing.T) {
+	linkTable := os.Getenv("CONFORMANCE_DYNAMODB_LINK_TABLE")
+	statsTable := os.Getenv("CONFORMANCE_DYNAMODB_STATS_TABLE")
+	if linkTable == "" || statsTable == "" {
+		t.Skip("CONFORMANCE_DYNAMODB_LINK_TABLE and CONFORMANCE_DYNAMODB_STATS_TABLE not set")
+	}
+
+	fixture := func(t *testing.T) StatsFixture {
+		f := StatsFixture{
+			Links: repository.NewLinkRepository(context.Background(), linkTable),
+			Stats: repository.NewStatsRepository(context.Background(), statsTable),
+		}
+		reset(t, f)
+		return f
+	}
+
+	t.Run("LinkPort", func(t *testing.T) {
+		LinkPort(t, func(t *testing.T) ports.LinkPort { return fixture(t).Links })
+	})
+	t.Run("StatsPort", func(t *testing.T) {
+		StatsPort(t, fixture)
+	})
+}
+
+func TestRedisConformance(t *testing.T) {
+	addr := os.Getenv("CONFORMANCE_REDIS_ADDR")
+	if addr == "" {
+		t.Skip("CONFORMANCE_REDIS_ADDR not set")
+	}
+
+	Cache(t, func(t *testing.T) ports.Cache {
+		return cache.NewRedisCache(addr, "", 0)
+	})
+}
+
+// reset empties both adapters through the port itself, so it works the
+// same way for every backend.
+func reset(t *testing.T, f StatsFixture) {
+	t.Helper()
+	ctx := context.Background()
+
+	for _, workspaceID := range []string{workspace, otherWorkspace} {
+		for {
+			stats, err := f.Stats.All(ctx, workspaceID)
+			require.NoError(t, err)
+			if len(stats) == 0 {
+				break
+			}
+			for _, stat := range stats {
+				require.NoError(t, f.Stats.Delete(ctx, workspaceID, stat.Domain, stat.LinkID))
+			}
+		}
+
+		for {
+			links, err := f.Links.All(ctx, workspaceID)
+			require.NoError(t, err)
+			if len(links) == 0 {
+				break
+			}
+			for _, link := range links {
+				require.NoError(t, f.Links.Delete(ctx, workspaceID, link.Domain, link.Id))
+			}
+		}
+	}
+}
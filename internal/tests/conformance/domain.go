@@ -0,0 +1,113 @@
+package conformance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/itsbaivab/url-shortener/internal/core/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// DomainPort runs the ports.DomainPort suite. newPort is called once per
+// subtest. Domains are registered in the default workspace, which every
+// backend has, under fresh names.
+func DomainPort(t *testing.T, newPort func(t *testing.T) ports.DomainPort) {
+	ctx := context.Background()
+	newDomain := func(offset time.Duration) domain.CustomDomain {
+		return domain.CustomDomain{
+			Name:        uniqueID("go") + ".example.com",
+			WorkspaceID: workspace,
+			Token:       uniqueID("token"),
+			CreatedAt:   at(offset),
+		}
+	}
+
+	t.Run("CreateThenGet", func(t *testing.T) {
+		repo := newPort(t)
+		d := newDomain(0)
+		require.NoError(t, repo.Create(ctx, d))
+
+		got, err := repo.Get(ctx, d.Name)
+		require.NoError(t, err)
+		assert.Equal(t, d.WorkspaceID, got.WorkspaceID)
+		assert.Equal(t, d.Token, got.Token)
+		assert.WithinDuration(t, d.CreatedAt, got.CreatedAt, time.Millisecond)
+		assert.False(t, got.Verified())
+	})
+
+	t.Run("GetMissingFails", func(t *testing.T) {
+		repo := newPort(t)
+		_, err := repo.Get(ctx, uniqueID("missing")+".example.com")
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("NamesAreUnique", func(t *testing.T) {
+		repo := newPort(t)
+		d := newDomain(0)
+		require.NoError(t, repo.Create(ctx, d))
+
+		clash := d
+		clash.Token = uniqueID("token")
+		assert.ErrorIs(t, repo.Create(ctx, clash), domain.ErrConflict)
+
+		got, err := repo.Get(ctx, d.Name)
+		require.NoError(t, err)
+		assert.Equal(t, d.Token, got.Token)
+	})
+
+	t.Run("AllReturnsOldestFirst", func(t *testing.T) {
+		repo := newPort(t)
+		newer, older := newDomain(time.Hour), newDomain(0)
+		require.NoError(t, repo.Create(ctx, newer))
+		require.NoError(t, repo.Create(ctx, older))
+
+		all, err := repo.All(ctx, workspace)
+		require.NoError(t, err)
+		var names []string
+		for _, d := range all {
+			if d.Name == newer.Name || d.Name == older.Name {
+				names = append(names, d.Name)
+			}
+		}
+		assert.Equal(t, []string{older.Name, newer.Name}, names)
+
+		all, err = repo.All(ctx, otherWorkspace)
+		require.NoError(t, err)
+		assert.Empty(t, all)
+	})
+
+	t.Run("VerifyKeepsFirstTime", func(t *testing.T) {
+		repo := newPort(t)
+		d := newDomain(0)
+		require.NoError(t, repo.Create(ctx, d))
+
+		require.NoError(t, repo.Verify(ctx, d.Name, at(time.Minute)))
+		require.NoError(t, repo.Verify(ctx, d.Name, at(time.Hour)))
+
+		got, err := repo.Get(ctx, d.Name)
+		require.NoError(t, err)
+		require.True(t, got.Verified())
+		assert.WithinDuration(t, at(time.Minute), *got.VerifiedAt, time.Millisecond)
+	})
+
+	t.Run("VerifyMissingFails", func(t *testing.T) {
+		repo := newPort(t)
+		assert.ErrorIs(t, repo.Verify(ctx, uniqueID("missing")+".example.com", at(0)), domain.ErrNotFound)
+	})
+
+	t.Run("DeleteIsScopedToWorkspace", func(t *testing.T) {
+		repo := newPort(t)
+		d := newDomain(0)
+		require.NoError(t, repo.Create(ctx, d))
+
+		assert.ErrorIs(t, repo.Delete(ctx, otherWorkspace, d.Name), domain.ErrNotFound)
+		require.NoError(t, repo.Delete(ctx, workspace, d.Name))
+
+		_, err := repo.Get(ctx, d.Name)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+		assert.NoError(t, repo.Create(ctx, d), "a removed domain can be registered again")
+	})
+}
@@ -0,0 +1,405 @@
+package conformance
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/itsbaivab/url-shortener/internal/core/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// LinkPort runs the ports.LinkPort suite. newPort is called once per
+// subtest and must return an adapter with no links created by the suite.
+func LinkPort(t *testing.T, newPort func(t *testing.T) ports.LinkPort) {
+	ctx := context.Background()
+
+	t.Run("CreateThenGet", func(t *testing.T) {
+		repo := newPort(t)
+		link := domain.Link{WorkspaceID: workspace, Id: uniqueID("get"), OriginalURL: "https://example.com/get", CreatedAt: at(0)}
+		require.NoError(t, repo.Create(ctx, link))
+
+		got, err := repo.Get(ctx, workspace, "", link.Id)
+		require.NoError(t, err)
+		assert.Equal(t, link.Id, got.Id)
+		assert.Equal(t, link.OriginalURL, got.OriginalURL)
+		assert.WithinDuration(t, link.CreatedAt, got.CreatedAt, time.Millisecond)
+	})
+
+	t.Run("GetMissingFails", func(t *testing.T) {
+		repo := newPort(t)
+		_, err := repo.Get(ctx, workspace, "", uniqueID("missing"))
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("DuplicateIDFails", func(t *testing.T) {
+		repo := newPort(t)
+		link := domain.Link{WorkspaceID: workspace, Id: uniqueID("dup"), OriginalURL: "https://example.com/first", CreatedAt: at(0)}
+		require.NoError(t, repo.Create(ctx, link))
+
+		clash := link
+		clash.OriginalURL = "https://example.com/second"
+		assert.ErrorIs(t, repo.Create(ctx, clash), domain.ErrConflict)
+
+		got, err := repo.Get(ctx, workspace, "", link.Id)
+		require.NoError(t, err)
+		assert.Equal(t, link.OriginalURL, got.OriginalURL, "a rejected duplicate must not overwrite the original")
+	})
+
+	t.Run("AllReturnsNewestFirst", func(t *testing.T) {
+		repo := newPort(t)
+		oldest := domain.Link{WorkspaceID: workspace, Id: uniqueID("old"), OriginalURL: "https://example.com/old", CreatedAt: at(0)}
+		newest := domain.Link{WorkspaceID: workspace, Id: uniqueID("new"), OriginalURL: "https://example.com/new", CreatedAt: at(2 * time.Hour)}
+		middle := domain.Link{WorkspaceID: workspace, Id: uniqueID("mid"), OriginalURL: "https://example.com/mid", CreatedAt: at(time.Hour)}
+		for _, link := range []domain.Link{oldest, newest, middle} {
+			require.NoError(t, repo.Create(ctx, link))
+		}
+
+		links, err := repo.All(ctx, workspace)
+		require.NoError(t, err)
+		assert.Equal(t, []string{newest.Id, middle.Id, oldest.Id}, linkIDs(links, newest.Id, middle.Id, oldest.Id))
+	})
+
+	t.Run("AllByOwnerReturnsOnlyThatOwner", func(t *testing.T) {
+		repo := newPort(t)
+		owner := uniqueID("owner")
+		older := domain.Link{WorkspaceID: workspace, Id: uniqueID("own1"), OriginalURL: "https://example.com/1", CreatedAt: at(0), OwnerID: owner}
+		newer := domain.Link{WorkspaceID: workspace, Id: uniqueID("own2"), OriginalURL: "https://example.com/2", CreatedAt: at(time.Hour), OwnerID: owner}
+		other := domain.Link{WorkspaceID: workspace, Id: uniqueID("other"), OriginalURL: "https://example.com/3", CreatedAt: at(0), OwnerID: uniqueID("owner")}
+		for _, link := range []domain.Link{older, newer, other} {
+			require.NoError(t, repo.Create(ctx, link))
+		}
+
+		links, err := repo.AllByOwner(ctx, workspace, owner)
+		require.NoError(t, err)
+		assert.Equal(t, []string{newer.Id, older.Id}, linkIDs(links, newer.Id, older.Id, other.Id))
+		for _, link := range links {
+			assert.Equal(t, owner, link.OwnerID)
+		}
+
+		got, err := repo.Get(ctx, workspace, "", older.Id)
+		require.NoError(t, err)
+		assert.Equal(t, owner, got.OwnerID)
+	})
+
+	t.Run("DeleteRemovesLink", func(t *testing.T) {
+		repo := newPort(t)
+		link := domain.Link{WorkspaceID: workspace, Id: uniqueID("del"), OriginalURL: "https://example.com/del", CreatedAt: at(0)}
+		require.NoError(t, repo.Create(ctx, link))
+		require.NoError(t, repo.Delete(ctx, workspace, "", link.Id))
+
+		_, err := repo.Get(ctx, workspace, "", link.Id)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("DeleteMissingFails", func(t *testing.T) {
+		repo := newPort(t)
+		assert.ErrorIs(t, repo.Delete(ctx, workspace, "", uniqueID("missing")), domain.ErrNotFound)
+	})
+
+	t.Run("WorkspacesAreIsolated", func(t *testing.T) {
+		repo := newPort(t)
+		id := uniqueID("tenant")
+		mine := domain.Link{WorkspaceID: workspace, Id: id, OriginalURL: "https://example.com/mine", CreatedAt: at(0), OwnerID: "owner"}
+		theirs := domain.Link{WorkspaceID: otherWorkspace, Id: id, OriginalURL: "https://example.com/theirs", CreatedAt: at(0), OwnerID: "owner"}
+		require.NoError(t, repo.Create(ctx, mine))
+		require.NoError(t, repo.Create(ctx, theirs), "IDs only need to be unique within a workspace")
+
+		got, err := repo.Get(ctx, otherWorkspace, "", id)
+		require.NoError(t, err)
+		assert.Equal(t, theirs.OriginalURL, got.OriginalURL)
+		assert.Equal(t, otherWorkspace, got.WorkspaceID)
+
+		links, err := repo.All(ctx, otherWorkspace)
+		require.NoError(t, err)
+		require.Len(t, links, 1)
+		assert.Equal(t, theirs.OriginalURL, links[0].OriginalURL)
+
+		links, err = repo.AllByOwner(ctx, otherWorkspace, "owner")
+		require.NoError(t, err)
+		require.Len(t, links, 1)
+		assert.Equal(t, otherWorkspace, links[0].WorkspaceID)
+
+		require.NoError(t, repo.Delete(ctx, otherWorkspace, "", id))
+		assert.ErrorIs(t, repo.Delete(ctx, otherWorkspace, "", id), domain.ErrNotFound)
+
+		got, err = repo.Get(ctx, workspace, "", id)
+		require.NoError(t, err)
+		assert.Equal(t, mine.OriginalURL, got.OriginalURL)
+	})
+
+	t.Run("IDsAreUniquePerDomain", func(t *testing.T) {
+		repo := newPort(t)
+		id := uniqueID("domain")
+		shared := domain.Link{WorkspaceID: workspace, Id: id, OriginalURL: "https://example.com/shared", CreatedAt: at(0)}
+		branded := domain.Link{WorkspaceID: workspace, Domain: customDomain, Id: id, OriginalURL: "https://example.com/branded", CreatedAt: at(0)}
+		require.NoError(t, repo.Create(ctx, shared))
+		require.NoError(t, repo.Create(ctx, branded), "IDs only need to be unique within a domain")
+		assert.ErrorIs(t, repo.Create(ctx, branded), domain.ErrConflict)
+
+		got, err := repo.Get(ctx, workspace, customDomain, id)
+		require.NoError(t, err)
+		assert.Equal(t, branded.OriginalURL, got.OriginalURL)
+		assert.Equal(t, customDomain, got.Domain)
+		assert.Equal(t, id, got.Id)
+
+		_, err = repo.Get(ctx, workspace, "other."+customDomain, id)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+
+		links, err := repo.All(ctx, workspace)
+		require.NoError(t, err)
+		var domains []string
+		for _, link := range links {
+			if link.Id == id {
+				domains = append(domains, link.Domain)
+			}
+		}
+		assert.ElementsMatch(t, []string{"", customDomain}, domains)
+
+		require.NoError(t, repo.Delete(ctx, workspace, customDomain, id))
+		got, err = repo.Get(ctx, workspace, "", id)
+		require.NoError(t, err)
+		assert.Equal(t, shared.OriginalURL, got.OriginalURL)
+		assert.Empty(t, got.Domain)
+	})
+
+	t.Run("DisableKeepsLink", func(t *testing.T) {
+		repo := newPort(t)
+		link := domain.Link{WorkspaceID: workspace, Domain: customDomain, Id: uniqueID("off"), OriginalURL: "https://example.com/off", CreatedAt: at(0)}
+		require.NoError(t, repo.Create(ctx, link))
+		require.NoError(t, repo.Disable(ctx, workspace, customDomain, link.Id, "phishing", at(time.Hour)))
+
+		got, err := repo.Get(ctx, workspace, customDomain, link.Id)
+		require.NoError(t, err)
+		require.True(t, got.Disabled())
+		assert.WithinDuration(t, at(time.Hour), *got.DisabledAt, time.Millisecond)
+		assert.Equal(t, "phishing", got.DisabledReason)
+		assert.Equal(t, link.OriginalURL, got.OriginalURL)
+
+		links, err := repo.All(ctx, workspace)
+		require.NoError(t, err)
+		require.Len(t, links, 1)
+		assert.True(t, links[0].Disabled())
+	})
+
+	t.Run("PasswordHashRoundTrips", func(t *testing.T) {
+		repo := newPort(t)
+		link := domain.Link{WorkspaceID: workspace, Id: uniqueID("locked"), OriginalURL: "https://example.com/locked", CreatedAt: at(0),
+			PasswordHash: "$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy"}
+		require.NoError(t, repo.Create(ctx, link))
+
+		got, err := repo.Get(ctx, workspace, "", link.Id)
+		require.NoError(t, err)
+		assert.True(t, got.Protected())
+		assert.Equal(t, link.PasswordHash, got.PasswordHash)
+	})
+
+	t.Run("RequireSignatureRoundTrips", func(t *testing.T) {
+		repo := newPort(t)
+		link := domain.Link{WorkspaceID: workspace, Id: uniqueID("signed"), OriginalURL: "https://example.com/signed", CreatedAt: at(0), RequireSignature: true}
+		require.NoError(t, repo.Create(ctx, link))
+
+		got, err := repo.Get(ctx, workspace, "", link.Id)
+		require.NoError(t, err)
+		assert.True(t, got.RequireSignature)
+		assert.False(t, got.Public())
+	})
+
+	t.Run("RedirectOptionsRoundTrip", func(t *testing.T) {
+		repo := newPort(t)
+		link := domain.Link{WorkspaceID: workspace, Id: uniqueID("options"), OriginalURL: "https://example.com/options", CreatedAt: at(0),
+			RedirectType: domain.RedirectTemporary, ForwardQuery: true, ForwardPath: true, CampaignID: uniqueID("camp")}
+		require.NoError(t, repo.Create(ctx, link))
+
+		got, err := repo.Get(ctx, workspace, "", link.Id)
+		require.NoError(t, err)
+		assert.Equal(t, link.Target(), got.Target())
+	})
+
+	t.Run("VariantsRoundTrip", func(t *testing.T) {
+		repo := newPort(t)
+		link := domain.Link{WorkspaceID: workspace, Id: uniqueID("split"), OriginalURL: "https://example.com/a", CreatedAt: at(0),
+			Variants: []domain.Variant{{ID: "a", URL: "https://example.com/a", Weight: 3}, {ID: "b", URL: "https://example.com/b", Weight: 1}}}
+		require.NoError(t, repo.Create(ctx, link))
+
+		got, err := repo.Get(ctx, workspace, "", link.Id)
+		require.NoError(t, err)
+		assert.Equal(t, link.Variants, got.Variants)
+	})
+
+	t.Run("RoutingRulesRoundTrip", func(t *testing.T) {
+		repo := newPort(t)
+		link := domain.Link{WorkspaceID: workspace, Id: uniqueID("app"), OriginalURL: "https://example.com/", CreatedAt: at(0),
+			Rules: []domain.RoutingRule{
+				{Device: domain.MatchIOS, URL: "https://apps.apple.com/app/id123456789"},
+				{Device: domain.MatchAndroid, URL: "intent://open/#Intent;scheme=example;package=com.example.app;end"},
+			}}
+		require.NoError(t, repo.Create(ctx, link))
+
+		got, err := repo.Get(ctx, workspace, "", link.Id)
+		require.NoError(t, err)
+		assert.Equal(t, link.Rules, got.Rules)
+	})
+
+	t.Run("GeoRulesRoundTrip", func(t *testing.T) {
+		repo := newPort(t)
+		link := domain.Link{WorkspaceID: workspace, Id: uniqueID("geo"), OriginalURL: "https://example.com/", CreatedAt: at(0),
+			GeoRules: []domain.GeoRule{
+				{Countries: []string{"DE", "AT"}, URL: "https://example.com/de"},
+				{Regions: []string{"europe"}, URL: "https://example.com/eu"},
+			}}
+		require.NoError(t, repo.Create(ctx, link))
+
+		got, err := repo.Get(ctx, workspace, "", link.Id)
+		require.NoError(t, err)
+		assert.Equal(t, link.GeoRules, got.GeoRules)
+	})
+
+	t.Run("EnableRestoresLink", func(t *testing.T) {
+		repo := newPort(t)
+		link := domain.Link{WorkspaceID: workspace, Id: uniqueID("on"), OriginalURL: "https://example.com/on", CreatedAt: at(0)}
+		require.NoError(t, repo.Create(ctx, link))
+		require.NoError(t, repo.Disable(ctx, workspace, "", link.Id, "spam", at(time.Hour)))
+		require.NoError(t, repo.Enable(ctx, workspace, "", link.Id))
+
+		got, err := repo.Get(ctx, workspace, "", link.Id)
+		require.NoError(t, err)
+		assert.False(t, got.Disabled())
+		assert.Empty(t, got.DisabledReason)
+
+		assert.NoError(t, repo.Enable(ctx, workspace, "", link.Id), "enabling an enabled link is not an error")
+		assert.ErrorIs(t, repo.Enable(ctx, workspace, "", uniqueID("missing")), domain.ErrNotFound)
+	})
+
+	t.Run("DisableMissingFails", func(t *testing.T) {
+		repo := newPort(t)
+		link := domain.Link{WorkspaceID: workspace, Id: uniqueID("off"), OriginalURL: "https://example.com/off", CreatedAt: at(0)}
+		require.NoError(t, repo.Create(ctx, link))
+
+		assert.ErrorIs(t, repo.Disable(ctx, workspace, "", uniqueID("missing"), "spam", at(0)), domain.ErrNotFound)
+		assert.ErrorIs(t, repo.Disable(ctx, otherWorkspace, "", link.Id, "spam", at(0)), domain.ErrNotFound)
+
+		got, err := repo.Get(ctx, workspace, "", link.Id)
+		require.NoError(t, err)
+		assert.False(t, got.Disabled())
+	})
+
+	t.Run("ScanVisitsEveryLinkOnce", func(t *testing.T) {
+		repo := newPort(t)
+		var want []string
+		for i, workspaceID := range []string{workspace, otherWorkspace, workspace, otherWorkspace, workspace} {
+			link := domain.Link{WorkspaceID: workspaceID, Id: uniqueID("scan"), OriginalURL: "https://example.com/scan", CreatedAt: at(time.Duration(i) * time.Second)}
+			if i%2 == 0 {
+				link.Domain = customDomain
+			}
+			require.NoError(t, repo.Create(ctx, link))
+			want = append(want, link.WorkspaceID+"/"+link.Domain+"/"+link.Id)
+		}
+
+		seen := map[string]int{}
+		var after domain.Link
+		for pages := 0; ; pages++ {
+			require.Less(t, pages, 1000, "scan does not terminate")
+			page, err := repo.Scan(ctx, after, 2)
+			require.NoError(t, err)
+			if len(page) == 0 {
+				break
+			}
+			assert.LessOrEqual(t, len(page), 2)
+			for _, link := range page {
+				seen[link.WorkspaceID+"/"+link.Domain+"/"+link.Id]++
+			}
+			after = page[len(page)-1]
+		}
+		for _, key := range want {
+			assert.Equal(t, 1, seen[key], key)
+		}
+	})
+
+	t.Run("CountIsPerWorkspace", func(t *testing.T) {
+		repo := newPort(t)
+		for i, workspaceID := range []string{workspace, workspace, otherWorkspace} {
+			require.NoError(t, repo.Create(ctx, domain.Link{WorkspaceID: workspaceID, Id: uniqueID("count"), OriginalURL: "https://example.com/count", CreatedAt: at(time.Duration(i) * time.Second)}))
+		}
+
+		count, err := repo.Count(ctx, workspace)
+		require.NoError(t, err)
+		assert.Equal(t, 2, count)
+
+		count, err = repo.Count(ctx, otherWorkspace)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("ConcurrentCreates", func(t *testing.T) {
+		repo := newPort(t)
+		ids := make([]string, concurrency)
+		for i := range ids {
+			ids[i] = uniqueID("conc")
+		}
+
+		var wg sync.WaitGroup
+		errs := make([]error, concurrency)
+		for i, id := range ids {
+			wg.Add(1)
+			go func(i int, id string) {
+				defer wg.Done()
+				errs[i] = repo.Create(ctx, domain.Link{WorkspaceID: workspace, Id: id, OriginalURL: "https://example.com/" + id, CreatedAt: at(time.Duration(i) * time.Second)})
+			}(i, id)
+		}
+		wg.Wait()
+
+		for i, id := range ids {
+			require.NoError(t, errs[i])
+			got, err := repo.Get(ctx, workspace, "", id)
+			require.NoError(t, err)
+			assert.Equal(t, "https://example.com/"+id, got.OriginalURL)
+		}
+	})
+
+	t.Run("ConcurrentDuplicateCreatesHaveOneWinner", func(t *testing.T) {
+		repo := newPort(t)
+		id := uniqueID("race")
+
+		var wg sync.WaitGroup
+		errs := make([]error, concurrency)
+		for i := 0; i < concurrency; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				errs[i] = repo.Create(ctx, domain.Link{WorkspaceID: workspace, Id: id, OriginalURL: "https://example.com/race", CreatedAt: at(0)})
+			}(i)
+		}
+		wg.Wait()
+
+		succeeded := 0
+		for _, err := range errs {
+			if err == nil {
+				succeeded++
+				continue
+			}
+			assert.ErrorIs(t, err, domain.ErrConflict)
+		}
+		assert.Equal(t, 1, succeeded)
+	})
+}
+
+// linkIDs returns the IDs of links that appear in want, in the order the
+// adapter returned them. Other rows in a shared table are ignored.
+func linkIDs(links []domain.Link, want ...string) []string {
+	wanted := make(map[string]bool, len(want))
+	for _, id := range want {
+		wanted[id] = true
+	}
+
+	var ids []string
+	for _, link := range links {
+		if wanted[link.Id] {
+			ids = append(ids, link.Id)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,106 @@
+package conformance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/itsbaivab/url-shortener/internal/core/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// AbuseReportPort runs the ports.AbuseReportPort suite. newPort is called
+// once per subtest; reports are created under fresh IDs.
+func AbuseReportPort(t *testing.T, newPort func(t *testing.T) ports.AbuseReportPort) {
+	ctx := context.Background()
+	newReport := func(offset time.Duration) domain.AbuseReport {
+		return domain.AbuseReport{
+			Id:            uniqueID("report"),
+			WorkspaceID:   workspace,
+			Domain:        customDomain,
+			LinkID:        uniqueID("link"),
+			Reason:        domain.ReportPhishing,
+			Details:       "asks for my bank password",
+			ReporterEmail: "reporter@example.com",
+			ReporterIP:    "203.0.113.7",
+			UserAgent:     "Mozilla/5.0",
+			Status:        domain.ReportOpen,
+			CreatedAt:     at(offset),
+		}
+	}
+	reportIDs := func(reports []domain.AbuseReport, want ...string) []string {
+		wanted := map[string]bool{}
+		for _, id := range want {
+			wanted[id] = true
+		}
+		var ids []string
+		for _, report := range reports {
+			if wanted[report.Id] {
+				ids = append(ids, report.Id)
+			}
+		}
+		return ids
+	}
+
+	t.Run("CreateThenGet", func(t *testing.T) {
+		repo := newPort(t)
+		report := newReport(0)
+		require.NoError(t, repo.Create(ctx, report))
+
+		got, err := repo.Get(ctx, report.Id)
+		require.NoError(t, err)
+		assert.WithinDuration(t, report.CreatedAt, got.CreatedAt, time.Millisecond)
+		got.CreatedAt = report.CreatedAt
+		assert.Equal(t, report, got)
+	})
+
+	t.Run("GetMissingFails", func(t *testing.T) {
+		repo := newPort(t)
+		_, err := repo.Get(ctx, uniqueID("missing"))
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("AllFiltersByStatusOldestFirst", func(t *testing.T) {
+		repo := newPort(t)
+		newer, older, closed := newReport(time.Hour), newReport(0), newReport(time.Minute)
+		for _, report := range []domain.AbuseReport{newer, older, closed} {
+			require.NoError(t, repo.Create(ctx, report))
+		}
+		require.NoError(t, repo.Resolve(ctx, closed.Id, domain.ReportResolution{Status: domain.ReportDismissed, Note: "not abusive", ResolvedBy: "root", ResolvedAt: at(2 * time.Hour)}))
+
+		open, err := repo.All(ctx, domain.ReportOpen)
+		require.NoError(t, err)
+		assert.Equal(t, []string{older.Id, newer.Id}, reportIDs(open, older.Id, newer.Id, closed.Id))
+
+		all, err := repo.All(ctx, "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{older.Id, closed.Id, newer.Id}, reportIDs(all, older.Id, newer.Id, closed.Id))
+	})
+
+	t.Run("ResolveOnce", func(t *testing.T) {
+		repo := newPort(t)
+		report := newReport(0)
+		require.NoError(t, repo.Create(ctx, report))
+
+		resolution := domain.ReportResolution{Status: domain.ReportDisabled, Note: "confirmed phishing", ResolvedBy: "root", ResolvedAt: at(time.Hour)}
+		require.NoError(t, repo.Resolve(ctx, report.Id, resolution))
+		assert.ErrorIs(t, repo.Resolve(ctx, report.Id, domain.ReportResolution{Status: domain.ReportDismissed, ResolvedAt: at(2 * time.Hour)}), domain.ErrConflict)
+
+		got, err := repo.Get(ctx, report.Id)
+		require.NoError(t, err)
+		assert.Equal(t, domain.ReportDisabled, got.Status)
+		require.NotNil(t, got.Resolution)
+		assert.Equal(t, resolution.Note, got.Resolution.Note)
+		assert.Equal(t, resolution.ResolvedBy, got.Resolution.ResolvedBy)
+		assert.Equal(t, domain.ReportDisabled, got.Resolution.Status)
+		assert.WithinDuration(t, resolution.ResolvedAt, got.Resolution.ResolvedAt, time.Millisecond)
+	})
+
+	t.Run("ResolveMissingFails", func(t *testing.T) {
+		repo := newPort(t)
+		err := repo.Resolve(ctx, uniqueID("missing"), domain.ReportResolution{Status: domain.ReportDismissed, ResolvedAt: at(0)})
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+}
@@ -0,0 +1,239 @@
+package conformance
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/itsbaivab/url-shortener/internal/core/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// StatsFixture pairs a stats adapter with the link adapter that backs it.
+// Stats always refer to an existing link, since the postgres schema
+// enforces that with a foreign key.
+type StatsFixture struct {
+	Links ports.LinkPort
+	Stats ports.StatsPort
+}
+
+// StatsPort runs the ports.StatsPort suite. newFixture is called once per
+// subtest and must return adapters with no data created by the suite.
+func StatsPort(t *testing.T, newFixture func(t *testing.T) StatsFixture) {
+	ctx := context.Background()
+
+	newLinkIn := func(t *testing.T, f StatsFixture, workspaceID, id string) string {
+		t.Helper()
+		require.NoError(t, f.Links.Create(ctx, domain.Link{WorkspaceID: workspaceID, Id: id, OriginalURL: "https://example.com/" + id, CreatedAt: at(0)}))
+		return id
+	}
+	newLink := func(t *testing.T, f StatsFixture) string {
+		t.Helper()
+		return newLinkIn(t, f, workspace, uniqueID("link"))
+	}
+
+	t.Run("CreateThenGet", func(t *testing.T) {
+		f := newFixture(t)
+		stat := domain.Stats{WorkspaceID: workspace, Id: uniqueID("stat"), LinkID: newLink(t, f), Platform: domain.PlatformYouTube, CreatedAt: at(0),
+			PasswordAttempt: domain.PasswordRejected, CampaignID: uniqueID("camp"), Variant: "b", Rule: domain.MatchAndroid}
+		require.NoError(t, f.Stats.Create(ctx, stat))
+
+		got, err := f.Stats.Get(ctx, workspace, stat.Id)
+		require.NoError(t, err)
+		assert.Equal(t, stat.Id, got.Id)
+		assert.Equal(t, stat.LinkID, got.LinkID)
+		assert.Equal(t, stat.Platform, got.Platform)
+		assert.Equal(t, stat.PasswordAttempt, got.PasswordAttempt)
+		assert.Equal(t, stat.CampaignID, got.CampaignID)
+		assert.Equal(t, stat.Variant, got.Variant)
+		assert.Equal(t, stat.Rule, got.Rule)
+		assert.WithinDuration(t, stat.CreatedAt, got.CreatedAt, time.Millisecond)
+	})
+
+	t.Run("GetMissingFails", func(t *testing.T) {
+		f := newFixture(t)
+		_, err := f.Stats.Get(ctx, workspace, uniqueID("missing"))
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("DuplicateIDFails", func(t *testing.T) {
+		f := newFixture(t)
+		stat := domain.Stats{WorkspaceID: workspace, Id: uniqueID("dup"), LinkID: newLink(t, f), CreatedAt: at(0)}
+		require.NoError(t, f.Stats.Create(ctx, stat))
+		assert.ErrorIs(t, f.Stats.Create(ctx, stat), domain.ErrConflict)
+	})
+
+	t.Run("GetStatsByLinkIDReturnsNewestFirst", func(t *testing.T) {
+		f := newFixture(t)
+		linkID := newLink(t, f)
+		otherID := newLink(t, f)
+
+		oldest := domain.Stats{WorkspaceID: workspace, Id: uniqueID("old"), LinkID: linkID, CreatedAt: at(0)}
+		newest := domain.Stats{WorkspaceID: workspace, Id: uniqueID("new"), LinkID: linkID, CreatedAt: at(2 * time.Hour)}
+		middle := domain.Stats{WorkspaceID: workspace, Id: uniqueID("mid"), LinkID: linkID, CreatedAt: at(time.Hour)}
+		other := domain.Stats{WorkspaceID: workspace, Id: uniqueID("other"), LinkID: otherID, CreatedAt: at(time.Hour)}
+		for _, stat := range []domain.Stats{oldest, newest, other, middle} {
+			require.NoError(t, f.Stats.Create(ctx, stat))
+		}
+
+		stats, err := f.Stats.GetStatsByLinkID(ctx, workspace, "", linkID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{newest.Id, middle.Id, oldest.Id}, statIDs(stats))
+	})
+
+	t.Run("GetStatsByCampaignReturnsNewestFirst", func(t *testing.T) {
+		f := newFixture(t)
+		campaignID := uniqueID("camp")
+		linkID, otherID := newLink(t, f), newLink(t, f)
+
+		older := domain.Stats{WorkspaceID: workspace, Id: uniqueID("old"), LinkID: linkID, CampaignID: campaignID, CreatedAt: at(0)}
+		newer := domain.Stats{WorkspaceID: workspace, Id: uniqueID("new"), LinkID: otherID, CampaignID: campaignID, CreatedAt: at(time.Hour)}
+		outside := domain.Stats{WorkspaceID: workspace, Id: uniqueID("out"), LinkID: linkID, CreatedAt: at(time.Hour)}
+		for _, stat := range []domain.Stats{older, newer, outside} {
+			require.NoError(t, f.Stats.Create(ctx, stat))
+		}
+
+		stats, err := f.Stats.GetStatsByCampaign(ctx, workspace, campaignID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{newer.Id, older.Id}, statIDs(stats))
+
+		stats, err = f.Stats.GetStatsByCampaign(ctx, otherWorkspace, campaignID)
+		require.NoError(t, err)
+		assert.Empty(t, stats)
+	})
+
+	t.Run("GetStatsByLinkIDWithoutStatsIsEmpty", func(t *testing.T) {
+		f := newFixture(t)
+		stats, err := f.Stats.GetStatsByLinkID(ctx, workspace, "", newLink(t, f))
+		require.NoError(t, err)
+		assert.Empty(t, stats)
+	})
+
+	t.Run("AllReturnsNewestFirst", func(t *testing.T) {
+		f := newFixture(t)
+		linkID := newLink(t, f)
+		oldest := domain.Stats{WorkspaceID: workspace, Id: uniqueID("old"), LinkID: linkID, CreatedAt: at(0)}
+		newest := domain.Stats{WorkspaceID: workspace, Id: uniqueID("new"), LinkID: linkID, CreatedAt: at(time.Hour)}
+		for _, stat := range []domain.Stats{oldest, newest} {
+			require.NoError(t, f.Stats.Create(ctx, stat))
+		}
+
+		stats, err := f.Stats.All(ctx, workspace)
+		require.NoError(t, err)
+		var ids []string
+		for _, stat := range stats {
+			if stat.LinkID == linkID {
+				ids = append(ids, stat.Id)
+			}
+		}
+		assert.Equal(t, []string{newest.Id, oldest.Id}, ids)
+	})
+
+	t.Run("DeleteCascadesToEveryStatOfTheLink", func(t *testing.T) {
+		f := newFixture(t)
+		linkID := newLink(t, f)
+		otherID := newLink(t, f)
+		for i := 0; i < 3; i++ {
+			require.NoError(t, f.Stats.Create(ctx, domain.Stats{WorkspaceID: workspace, Id: uniqueID("gone"), LinkID: linkID, CreatedAt: at(time.Duration(i) * time.Minute)}))
+		}
+		kept := domain.Stats{WorkspaceID: workspace, Id: uniqueID("kept"), LinkID: otherID, CreatedAt: at(0)}
+		require.NoError(t, f.Stats.Create(ctx, kept))
+
+		require.NoError(t, f.Stats.Delete(ctx, workspace, "", linkID))
+
+		stats, err := f.Stats.GetStatsByLinkID(ctx, workspace, "", linkID)
+		require.NoError(t, err)
+		assert.Empty(t, stats)
+
+		stats, err = f.Stats.GetStatsByLinkID(ctx, workspace, "", otherID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{kept.Id}, statIDs(stats))
+	})
+
+	t.Run("DeleteWithoutStatsSucceeds", func(t *testing.T) {
+		f := newFixture(t)
+		assert.NoError(t, f.Stats.Delete(ctx, workspace, "", newLink(t, f)))
+	})
+
+	t.Run("WorkspacesAreIsolated", func(t *testing.T) {
+		f := newFixture(t)
+		linkID := newLink(t, f)
+		newLinkIn(t, f, otherWorkspace, linkID)
+
+		mine := domain.Stats{WorkspaceID: workspace, Id: uniqueID("mine"), LinkID: linkID, CreatedAt: at(0)}
+		theirs := domain.Stats{WorkspaceID: otherWorkspace, Id: uniqueID("theirs"), LinkID: linkID, CreatedAt: at(0)}
+		require.NoError(t, f.Stats.Create(ctx, mine))
+		require.NoError(t, f.Stats.Create(ctx, theirs))
+
+		stats, err := f.Stats.GetStatsByLinkID(ctx, otherWorkspace, "", linkID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{theirs.Id}, statIDs(stats))
+
+		_, err = f.Stats.Get(ctx, workspace, theirs.Id)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+
+		all, err := f.Stats.All(ctx, otherWorkspace)
+		require.NoError(t, err)
+		assert.Equal(t, []string{theirs.Id}, statIDs(all))
+
+		require.NoError(t, f.Stats.Delete(ctx, otherWorkspace, "", linkID))
+		stats, err = f.Stats.GetStatsByLinkID(ctx, workspace, "", linkID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{mine.Id}, statIDs(stats))
+	})
+
+	t.Run("DomainsAreSeparate", func(t *testing.T) {
+		f := newFixture(t)
+		linkID := newLink(t, f)
+		require.NoError(t, f.Links.Create(ctx, domain.Link{WorkspaceID: workspace, Domain: customDomain, Id: linkID, OriginalURL: "https://example.com/branded", CreatedAt: at(0)}))
+
+		shared := domain.Stats{WorkspaceID: workspace, Id: uniqueID("shared"), LinkID: linkID, CreatedAt: at(0)}
+		branded := domain.Stats{WorkspaceID: workspace, Domain: customDomain, Id: uniqueID("branded"), LinkID: linkID, CreatedAt: at(0)}
+		require.NoError(t, f.Stats.Create(ctx, shared))
+		require.NoError(t, f.Stats.Create(ctx, branded))
+
+		stats, err := f.Stats.GetStatsByLinkID(ctx, workspace, customDomain, linkID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{branded.Id}, statIDs(stats))
+		assert.Equal(t, customDomain, stats[0].Domain)
+
+		require.NoError(t, f.Stats.Delete(ctx, workspace, customDomain, linkID))
+		stats, err = f.Stats.GetStatsByLinkID(ctx, workspace, "", linkID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{shared.Id}, statIDs(stats))
+	})
+
+	t.Run("ConcurrentCreates", func(t *testing.T) {
+		f := newFixture(t)
+		linkID := newLink(t, f)
+
+		var wg sync.WaitGroup
+		errs := make([]error, concurrency)
+		for i := 0; i < concurrency; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				errs[i] = f.Stats.Create(ctx, domain.Stats{WorkspaceID: workspace, Id: uniqueID("conc"), LinkID: linkID, CreatedAt: at(time.Duration(i) * time.Second)})
+			}(i)
+		}
+		wg.Wait()
+
+		for _, err := range errs {
+			require.NoError(t, err)
+		}
+		stats, err := f.Stats.GetStatsByLinkID(ctx, workspace, "", linkID)
+		require.NoError(t, err)
+		assert.Len(t, stats, concurrency)
+	})
+}
+
+func statIDs(stats []domain.Stats) []string {
+	var ids []string
+	for _, stat := range stats {
+		ids = append(ids, stat.Id)
+	}
+	return ids
+}
@@ -0,0 +1,85 @@
+package conformance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/itsbaivab/url-shortener/internal/core/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// WorkspacePort runs the ports.WorkspacePort suite. newPort is called once
+// per subtest. Workspaces cannot be deleted, so every subtest uses fresh
+// IDs.
+func WorkspacePort(t *testing.T, newPort func(t *testing.T) ports.WorkspacePort) {
+	ctx := context.Background()
+	newWorkspace := func() domain.Workspace {
+		id := uniqueID("ws")
+		return domain.Workspace{
+			Id:        id,
+			Name:      "Conformance",
+			Quotas:    domain.WorkspaceQuotas{MaxLinks: 10},
+			Settings:  domain.WorkspaceSettings{ShortIDLength: 6},
+			CreatedAt: at(0),
+		}
+	}
+
+	t.Run("DefaultWorkspaceExists", func(t *testing.T) {
+		repo := newPort(t)
+		got, err := repo.Get(ctx, domain.DefaultWorkspaceID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.DefaultWorkspaceID, got.Id)
+	})
+
+	t.Run("CreateThenGet", func(t *testing.T) {
+		repo := newPort(t)
+		workspace := newWorkspace()
+		require.NoError(t, repo.Create(ctx, workspace))
+
+		got, err := repo.Get(ctx, workspace.Id)
+		require.NoError(t, err)
+		assert.Equal(t, workspace.Name, got.Name)
+		assert.Equal(t, workspace.Quotas, got.Quotas)
+		assert.Equal(t, workspace.Settings, got.Settings)
+		assert.WithinDuration(t, workspace.CreatedAt, got.CreatedAt, time.Millisecond)
+
+		all, err := repo.All(ctx)
+		require.NoError(t, err)
+		var ids []string
+		for _, w := range all {
+			ids = append(ids, w.Id)
+		}
+		assert.Contains(t, ids, workspace.Id)
+	})
+
+	t.Run("GetMissingFails", func(t *testing.T) {
+		repo := newPort(t)
+		_, err := repo.Get(ctx, uniqueID("missing"))
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("UpdateReplacesSettings", func(t *testing.T) {
+		repo := newPort(t)
+		workspace := newWorkspace()
+		require.NoError(t, repo.Create(ctx, workspace))
+
+		workspace.Name = "Renamed"
+		workspace.Quotas.MaxLinks = 0
+		workspace.Settings.ShortIDLength = 12
+		require.NoError(t, repo.Update(ctx, workspace))
+
+		got, err := repo.Get(ctx, workspace.Id)
+		require.NoError(t, err)
+		assert.Equal(t, "Renamed", got.Name)
+		assert.Equal(t, workspace.Quotas, got.Quotas)
+		assert.Equal(t, workspace.Settings, got.Settings)
+	})
+
+	t.Run("UpdateMissingFails", func(t *testing.T) {
+		repo := newPort(t)
+		assert.ErrorIs(t, repo.Update(ctx, newWorkspace()), domain.ErrNotFound)
+	})
+}
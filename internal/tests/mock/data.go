@@ -3,13 +3,13 @@ package mock
 import "github.com/itsbaivab/url-shortener/internal/core/domain"
 
 var MockLinkData []domain.Link = []domain.Link{
-	{Id: "testid1", OriginalURL: "https://example.com/link1"},
-	{Id: "testid2", OriginalURL: "https://example.com/link2"},
-	{Id: "testid3", OriginalURL: "https://example.com/link3"},
+	{Id: "testid1", OriginalURL: "https://example.com/link1", WorkspaceID: domain.DefaultWorkspaceID},
+	{Id: "testid2", OriginalURL: "https://example.com/link2", WorkspaceID: domain.DefaultWorkspaceID},
+	{Id: "testid3", OriginalURL: "https://example.com/link3", WorkspaceID: domain.DefaultWorkspaceID},
 }
 
 var MockStatsData []domain.Stats = []domain.Stats{
-	{Id: "abcdefg1", Platform: domain.PlatformUnknown, LinkID: "testid1"},
-	{Id: "abcdefg2", Platform: domain.PlatformInstagram, LinkID: "testid2"},
-	{Id: "abcdefg3", Platform: domain.PlatformTwitter, LinkID: "testid3"},
+	{Id: "abcdefg1", Platform: domain.PlatformUnknown, LinkID: "testid1", WorkspaceID: domain.DefaultWorkspaceID},
+	{Id: "abcdefg2", Platform: domain.PlatformInstagram, LinkID: "testid2", WorkspaceID: domain.DefaultWorkspaceID},
+	{Id: "abcdefg3", Platform: domain.PlatformTwitter, LinkID: "testid3", WorkspaceID: domain.DefaultWorkspaceID},
 }
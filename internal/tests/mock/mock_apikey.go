@@ -0,0 +1,73 @@
+package mock
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+)
+
+type MockAPIKeyRepo struct {
+	mu   sync.Mutex
+	Keys []domain.APIKey
+}
+
+func NewMockAPIKeyRepo() *MockAPIKeyRepo {
+	return &MockAPIKeyRepo{}
+}
+
+func (m *MockAPIKeyRepo) All(ctx context.Context) ([]domain.APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := append([]domain.APIKey(nil), m.Keys...)
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.After(keys[j].CreatedAt)
+	})
+	return keys, nil
+}
+
+func (m *MockAPIKeyRepo) Get(ctx context.Context, id string) (domain.APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, key := range m.Keys {
+		if key.Id == id {
+			return key, nil
+		}
+	}
+
+	return domain.APIKey{}, fmt.Errorf("api key %q: %w", id, domain.ErrNotFound)
+}
+
+func (m *MockAPIKeyRepo) Create(ctx context.Context, key domain.APIKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.Keys {
+		if existing.Id == key.Id {
+			return fmt.Errorf("api key %q: %w", key.Id, domain.ErrConflict)
+		}
+	}
+	m.Keys = append(m.Keys, key)
+	return nil
+}
+
+func (m *MockAPIKeyRepo) Revoke(ctx context.Context, id string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, key := range m.Keys {
+		if key.Id == id {
+			if key.RevokedAt == nil {
+				m.Keys[i].RevokedAt = &at
+			}
+			return nil
+		}
+	}
+
+	return fmt.Errorf("api key %q: %w", id, domain.ErrNotFound)
+}
@@ -0,0 +1,74 @@
+package mock
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+)
+
+type MockAuditRepo struct {
+	mu      sync.Mutex
+	Entries []domain.AuditEntry
+}
+
+func NewMockAuditRepo() *MockAuditRepo {
+	return &MockAuditRepo{}
+}
+
+func (m *MockAuditRepo) Append(ctx context.Context, entry domain.AuditEntry, chain bool) (domain.AuditEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry.PrevHash, entry.Hash = "", ""
+	if chain {
+		for i := len(m.Entries) - 1; i >= 0; i-- {
+			if m.Entries[i].Hash != "" {
+				entry.PrevHash = m.Entries[i].Hash
+				break
+			}
+		}
+		entry.Hash = entry.ComputeHash()
+	}
+	entry.Seq = int64(len(m.Entries) + 1)
+	m.Entries = append(m.Entries, entry)
+	return entry, nil
+}
+
+func (m *MockAuditRepo) Find(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var entries []domain.AuditEntry
+	for i := len(m.Entries) - 1; i >= 0 && len(entries) < filter.Limit; i-- {
+		entry := m.Entries[i]
+		prefix, byResource := strings.CutSuffix(filter.Action, ".")
+		switch {
+		case filter.WorkspaceID != "" && entry.WorkspaceID != filter.WorkspaceID,
+			filter.Actor != "" && entry.Actor != filter.Actor,
+			byResource && !strings.HasPrefix(entry.Action, prefix+"."),
+			!byResource && filter.Action != "" && entry.Action != filter.Action,
+			filter.ResourceID != "" && entry.ResourceID != filter.ResourceID,
+			!filter.Since.IsZero() && entry.CreatedAt.Before(filter.Since),
+			!filter.Until.IsZero() && !entry.CreatedAt.Before(filter.Until),
+			filter.Before > 0 && entry.Seq >= filter.Before:
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func (m *MockAuditRepo) Scan(ctx context.Context, after int64, limit int) ([]domain.AuditEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var entries []domain.AuditEntry
+	for _, entry := range m.Entries {
+		if entry.Seq > after && len(entries) < limit {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,56 @@
+package mock
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+)
+
+type MockBlocklistRepo struct {
+	mu    sync.Mutex
+	Rules []domain.BlockRule
+}
+
+func NewMockBlocklistRepo() *MockBlocklistRepo {
+	return &MockBlocklistRepo{}
+}
+
+func (m *MockBlocklistRepo) All(ctx context.Context) ([]domain.BlockRule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rules := append([]domain.BlockRule(nil), m.Rules...)
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].CreatedAt.Before(rules[j].CreatedAt)
+	})
+	return rules, nil
+}
+
+func (m *MockBlocklistRepo) Create(ctx context.Context, rule domain.BlockRule) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.Rules {
+		if existing.Id == rule.Id {
+			return fmt.Errorf("blocklist rule %q: %w", rule.Id, domain.ErrConflict)
+		}
+	}
+	m.Rules = append(m.Rules, rule)
+	return nil
+}
+
+func (m *MockBlocklistRepo) Delete(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, rule := range m.Rules {
+		if rule.Id == id {
+			m.Rules = append(m.Rules[:i], m.Rules[i+1:]...)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("blocklist rule %q: %w", id, domain.ErrNotFound)
+}
@@ -2,11 +2,14 @@ package mock
 
 import (
 	"context"
-	"errors"
+	"sync"
 	"time"
 )
 
+// MockRedisCache mirrors cache.RedisCache: a miss or an expired key reads
+// as an empty string, and deleting a missing key is not an error.
 type MockRedisCache struct {
+	mu    sync.Mutex
 	Store map[string]string
 	TTL   map[string]time.Time
 }
@@ -19,29 +22,34 @@ func NewMockRedisCache() *MockRedisCache {
 }
 
 func (m *MockRedisCache) Set(ctx context.Context, key string, val string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	m.Store[key] = val
 	m.TTL[key] = time.Now().Add(time.Minute)
 	return nil
 }
 
 func (m *MockRedisCache) Get(ctx context.Context, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	val, ok := m.Store[key]
 	if !ok {
-		return "", errors.New("key not found")
+		return "", nil
 	}
 	if time.Now().After(m.TTL[key]) {
 		delete(m.Store, key)
 		delete(m.TTL, key)
-		return "", errors.New("key expired")
+		return "", nil
 	}
 	return val, nil
 }
 
 func (m *MockRedisCache) Delete(ctx context.Context, key string) error {
-	_, ok := m.Store[key]
-	if !ok {
-		return errors.New("key not found")
-	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	delete(m.Store, key)
 	delete(m.TTL, key)
 	return nil
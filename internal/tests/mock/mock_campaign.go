@@ -0,0 +1,87 @@
+package mock
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+)
+
+type MockCampaignRepo struct {
+	mu        sync.Mutex
+	Campaigns []domain.Campaign
+}
+
+func NewMockCampaignRepo() *MockCampaignRepo {
+	return &MockCampaignRepo{}
+}
+
+func (m *MockCampaignRepo) All(ctx context.Context, workspaceID string) ([]domain.Campaign, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var campaigns []domain.Campaign
+	for _, campaign := range m.Campaigns {
+		if campaign.WorkspaceID == workspaceID {
+			campaigns = append(campaigns, campaign)
+		}
+	}
+	sort.SliceStable(campaigns, func(i, j int) bool {
+		return campaigns[i].CreatedAt.Before(campaigns[j].CreatedAt)
+	})
+	return campaigns, nil
+}
+
+func (m *MockCampaignRepo) Get(ctx context.Context, workspaceID, id string) (domain.Campaign, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, campaign := range m.Campaigns {
+		if campaign.WorkspaceID == workspaceID && campaign.Id == id {
+			return campaign, nil
+		}
+	}
+	return domain.Campaign{}, fmt.Errorf("campaign %q: %w", id, domain.ErrNotFound)
+}
+
+func (m *MockCampaignRepo) Create(ctx context.Context, campaign domain.Campaign) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.Campaigns {
+		if existing.WorkspaceID == campaign.WorkspaceID && existing.Id == campaign.Id {
+			return fmt.Errorf("campaign %q: %w", campaign.Id, domain.ErrConflict)
+		}
+	}
+	m.Campaigns = append(m.Campaigns, campaign)
+	return nil
+}
+
+func (m *MockCampaignRepo) Update(ctx context.Context, campaign domain.Campaign) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, existing := range m.Campaigns {
+		if existing.WorkspaceID == campaign.WorkspaceID && existing.Id == campaign.Id {
+			campaign.CreatedAt = existing.CreatedAt
+			m.Campaigns[i] = campaign
+			return nil
+		}
+	}
+	return fmt.Errorf("campaign %q: %w", campaign.Id, domain.ErrNotFound)
+}
+
+func (m *MockCampaignRepo) Delete(ctx context.Context, workspaceID, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, campaign := range m.Campaigns {
+		if campaign.WorkspaceID == workspaceID && campaign.Id == id {
+			m.Campaigns = append(m.Campaigns[:i], m.Campaigns[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("campaign %q: %w", id, domain.ErrNotFound)
+}
@@ -0,0 +1,45 @@
+package mock
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"sync"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+)
+
+// MockIPResolver serves address records from memory instead of DNS.
+type MockIPResolver struct {
+	mu    sync.Mutex
+	Addrs map[string][]netip.Addr
+	// Err, when set, fails every lookup as an unreachable DNS server would.
+	Err error
+}
+
+func NewMockIPResolver() *MockIPResolver {
+	return &MockIPResolver{Addrs: map[string][]netip.Addr{}}
+}
+
+// Add publishes addrs for host.
+func (m *MockIPResolver) Add(host string, addrs ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, addr := range addrs {
+		m.Addrs[host] = append(m.Addrs[host], netip.MustParseAddr(addr))
+	}
+}
+
+func (m *MockIPResolver) LookupIP(ctx context.Context, host string) ([]netip.Addr, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	addrs, ok := m.Addrs[host]
+	if !ok {
+		return nil, fmt.Errorf("addresses of %q: %w", host, domain.ErrNotFound)
+	}
+	return append([]netip.Addr(nil), addrs...), nil
+}
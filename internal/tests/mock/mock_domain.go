@@ -0,0 +1,125 @@
+package mock
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+)
+
+type MockDomainRepo struct {
+	mu      sync.Mutex
+	Domains []domain.CustomDomain
+}
+
+func NewMockDomainRepo() *MockDomainRepo {
+	return &MockDomainRepo{}
+}
+
+func (m *MockDomainRepo) All(ctx context.Context, workspaceID string) ([]domain.CustomDomain, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var domains []domain.CustomDomain
+	for _, d := range m.Domains {
+		if d.WorkspaceID == workspaceID {
+			domains = append(domains, d)
+		}
+	}
+	sort.SliceStable(domains, func(i, j int) bool {
+		return domains[i].CreatedAt.Before(domains[j].CreatedAt)
+	})
+	return domains, nil
+}
+
+func (m *MockDomainRepo) Get(ctx context.Context, name string) (domain.CustomDomain, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, d := range m.Domains {
+		if d.Name == name {
+			return d, nil
+		}
+	}
+
+	return domain.CustomDomain{}, fmt.Errorf("domain %q: %w", name, domain.ErrNotFound)
+}
+
+func (m *MockDomainRepo) Create(ctx context.Context, d domain.CustomDomain) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.Domains {
+		if existing.Name == d.Name {
+			return fmt.Errorf("domain %q: %w", d.Name, domain.ErrConflict)
+		}
+	}
+	m.Domains = append(m.Domains, d)
+	return nil
+}
+
+func (m *MockDomainRepo) Verify(ctx context.Context, name string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, d := range m.Domains {
+		if d.Name == name {
+			if d.VerifiedAt == nil {
+				m.Domains[i].VerifiedAt = &at
+			}
+			return nil
+		}
+	}
+
+	return fmt.Errorf("domain %q: %w", name, domain.ErrNotFound)
+}
+
+func (m *MockDomainRepo) Delete(ctx context.Context, workspaceID, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, d := range m.Domains {
+		if d.WorkspaceID == workspaceID && d.Name == name {
+			m.Domains = append(m.Domains[:i], m.Domains[i+1:]...)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("domain %q: %w", name, domain.ErrNotFound)
+}
+
+// MockTXTResolver serves TXT records from memory instead of DNS.
+type MockTXTResolver struct {
+	mu      sync.Mutex
+	Records map[string][]string
+	// Err, when set, fails every lookup as an unreachable DNS server would.
+	Err error
+}
+
+func NewMockTXTResolver() *MockTXTResolver {
+	return &MockTXTResolver{Records: map[string][]string{}}
+}
+
+// Publish adds a TXT record at name.
+func (m *MockTXTResolver) Publish(name, value string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Records[name] = append(m.Records[name], value)
+}
+
+func (m *MockTXTResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	records, ok := m.Records[name]
+	if !ok {
+		return nil, fmt.Errorf("TXT records of %q: %w", name, domain.ErrNotFound)
+	}
+	return append([]string(nil), records...), nil
+}
@@ -2,48 +2,153 @@ package mock
 
 import (
 	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/itsbaivab/url-shortener/internal/core/domain"
 )
 
 type MockLinkRepo struct {
+	mu    sync.Mutex
 	Links []domain.Link
 	Stats []domain.Stats
 }
 
 func NewMockLinkRepo() *MockLinkRepo {
 	return &MockLinkRepo{
-		Links: MockLinkData,
-		Stats: MockStatsData,
+		Links: append([]domain.Link(nil), MockLinkData...),
+		Stats: append([]domain.Stats(nil), MockStatsData...),
 	}
 }
 
-func (m *MockLinkRepo) All(ctx context.Context) ([]domain.Link, error) {
-	return m.Links, nil
+func (m *MockLinkRepo) All(ctx context.Context, workspaceID string) ([]domain.Link, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var links []domain.Link
+	for _, link := range m.Links {
+		if link.WorkspaceID == workspaceID {
+			links = append(links, link)
+		}
+	}
+	sort.SliceStable(links, func(i, j int) bool {
+		return links[i].CreatedAt.After(links[j].CreatedAt)
+	})
+	return links, nil
+}
+
+func (m *MockLinkRepo) AllByOwner(ctx context.Context, workspaceID, ownerID string) ([]domain.Link, error) {
+	links, _ := m.All(ctx, workspaceID)
+
+	owned := links[:0]
+	for _, link := range links {
+		if link.OwnerID == ownerID {
+			owned = append(owned, link)
+		}
+	}
+	return owned, nil
+}
+
+func (m *MockLinkRepo) Count(ctx context.Context, workspaceID string) (int, error) {
+	links, _ := m.All(ctx, workspaceID)
+	return len(links), nil
 }
 
-func (m *MockLinkRepo) Get(ctx context.Context, id string) (domain.Link, error) {
+func (m *MockLinkRepo) Get(ctx context.Context, workspaceID, host, id string) (domain.Link, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	for _, link := range m.Links {
-		if link.Id == id {
+		if link.WorkspaceID == workspaceID && link.Domain == host && link.Id == id {
 			return link, nil
 		}
 	}
 
-	return domain.Link{}, nil
+	return domain.Link{}, fmt.Errorf("link %q: %w", id, domain.ErrNotFound)
 }
 
 func (m *MockLinkRepo) Create(ctx context.Context, link domain.Link) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.Links {
+		if existing.WorkspaceID == link.WorkspaceID && existing.Domain == link.Domain && existing.Id == link.Id {
+			return fmt.Errorf("link %q: %w", link.Id, domain.ErrConflict)
+		}
+	}
 	m.Links = append(m.Links, link)
 	return nil
 }
 
-func (m *MockLinkRepo) Delete(ctx context.Context, id string) error {
+func (m *MockLinkRepo) Delete(ctx context.Context, workspaceID, host, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	for i, link := range m.Links {
-		if link.Id == id {
+		if link.WorkspaceID == workspaceID && link.Domain == host && link.Id == id {
 			m.Links = append(m.Links[:i], m.Links[i+1:]...)
 			return nil
 		}
 	}
 
-	return nil
+	return fmt.Errorf("link %q: %w", id, domain.ErrNotFound)
+}
+
+func (m *MockLinkRepo) Disable(ctx context.Context, workspaceID, host, id, reason string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, link := range m.Links {
+		if link.WorkspaceID == workspaceID && link.Domain == host && link.Id == id {
+			m.Links[i].DisabledAt = &at
+			m.Links[i].DisabledReason = reason
+			return nil
+		}
+	}
+
+	return fmt.Errorf("link %q: %w", id, domain.ErrNotFound)
+}
+
+func (m *MockLinkRepo) Enable(ctx context.Context, workspaceID, host, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, link := range m.Links {
+		if link.WorkspaceID == workspaceID && link.Domain == host && link.Id == id {
+			m.Links[i].DisabledAt = nil
+			m.Links[i].DisabledReason = ""
+			return nil
+		}
+	}
+
+	return fmt.Errorf("link %q: %w", id, domain.ErrNotFound)
+}
+
+func (m *MockLinkRepo) Scan(ctx context.Context, after domain.Link, limit int) ([]domain.Link, error) {
+	m.mu.Lock()
+	links := append([]domain.Link(nil), m.Links...)
+	m.mu.Unlock()
+
+	slices.SortFunc(links, compareLinkKeys)
+	var page []domain.Link
+	for _, link := range links {
+		if compareLinkKeys(link, after) > 0 && len(page) < limit {
+			page = append(page, link)
+		}
+	}
+	return page, nil
+}
+
+func compareLinkKeys(a, b domain.Link) int {
+	if c := strings.Compare(a.WorkspaceID, b.WorkspaceID); c != 0 {
+		return c
+	}
+	if c := strings.Compare(a.Domain, b.Domain); c != 0 {
+		return c
+	}
+	return strings.Compare(a.Id, b.Id)
 }
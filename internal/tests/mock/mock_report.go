@@ -0,0 +1,80 @@
+package mock
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+)
+
+type MockAbuseReportRepo struct {
+	mu      sync.Mutex
+	Reports []domain.AbuseReport
+}
+
+func NewMockAbuseReportRepo() *MockAbuseReportRepo {
+	return &MockAbuseReportRepo{}
+}
+
+func (m *MockAbuseReportRepo) Create(ctx context.Context, report domain.AbuseReport) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.Reports {
+		if existing.Id == report.Id {
+			return fmt.Errorf("abuse report %q: %w", report.Id, domain.ErrConflict)
+		}
+	}
+	m.Reports = append(m.Reports, report)
+	return nil
+}
+
+func (m *MockAbuseReportRepo) Get(ctx context.Context, id string) (domain.AbuseReport, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, report := range m.Reports {
+		if report.Id == id {
+			return report, nil
+		}
+	}
+
+	return domain.AbuseReport{}, fmt.Errorf("abuse report %q: %w", id, domain.ErrNotFound)
+}
+
+func (m *MockAbuseReportRepo) All(ctx context.Context, status string) ([]domain.AbuseReport, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var reports []domain.AbuseReport
+	for _, report := range m.Reports {
+		if status == "" || report.Status == status {
+			reports = append(reports, report)
+		}
+	}
+	sort.SliceStable(reports, func(i, j int) bool {
+		return reports[i].CreatedAt.Before(reports[j].CreatedAt)
+	})
+	return reports, nil
+}
+
+func (m *MockAbuseReportRepo) Resolve(ctx context.Context, id string, resolution domain.ReportResolution) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, report := range m.Reports {
+		if report.Id != id {
+			continue
+		}
+		if report.Status != domain.ReportOpen {
+			return fmt.Errorf("abuse report %q is already resolved: %w", id, domain.ErrConflict)
+		}
+		m.Reports[i].Status = resolution.Status
+		m.Reports[i].Resolution = &resolution
+		return nil
+	}
+
+	return fmt.Errorf("abuse report %q: %w", id, domain.ErrNotFound)
+}
@@ -2,55 +2,109 @@ package mock
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"sync"
 
 	"github.com/itsbaivab/url-shortener/internal/core/domain"
 )
 
 type MockStatsRepo struct {
+	mu    sync.Mutex
 	Stats []domain.Stats
 }
 
 func NewMockStatsRepo() *MockStatsRepo {
 	return &MockStatsRepo{
-		Stats: MockStatsData,
+		Stats: append([]domain.Stats(nil), MockStatsData...),
 	}
 }
 
-func (m *MockStatsRepo) Get(ctx context.Context, id string) (domain.Stats, error) {
+func (m *MockStatsRepo) Get(ctx context.Context, workspaceID, id string) (domain.Stats, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	for _, stats := range m.Stats {
-		if stats.Id == id {
+		if stats.WorkspaceID == workspaceID && stats.Id == id {
 			return stats, nil
 		}
 	}
-	return domain.Stats{}, nil
+	return domain.Stats{}, fmt.Errorf("stats %q: %w", id, domain.ErrNotFound)
 }
 
-func (m *MockStatsRepo) All(ctx context.Context) ([]domain.Stats, error) {
-	return m.Stats, nil
+func (m *MockStatsRepo) All(ctx context.Context, workspaceID string) ([]domain.Stats, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var stats []domain.Stats
+	for _, stat := range m.Stats {
+		if stat.WorkspaceID == workspaceID {
+			stats = append(stats, stat)
+		}
+	}
+	return newestFirst(stats), nil
 }
 
 func (m *MockStatsRepo) Create(ctx context.Context, stats domain.Stats) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.Stats {
+		if existing.Id == stats.Id {
+			return fmt.Errorf("stats %q: %w", stats.Id, domain.ErrConflict)
+		}
+	}
 	m.Stats = append(m.Stats, stats)
 	return nil
 }
 
-func (m *MockStatsRepo) Delete(ctx context.Context, id string) error {
-	for i, stats := range m.Stats {
-		if stats.Id == id {
-			m.Stats = append(m.Stats[:i], m.Stats[i+1:]...)
-			return nil
+// Delete removes every stats entry recorded for linkID, matching the
+// postgres adapter.
+func (m *MockStatsRepo) Delete(ctx context.Context, workspaceID, host, linkID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	kept := m.Stats[:0]
+	for _, stats := range m.Stats {
+		if stats.WorkspaceID != workspaceID || stats.Domain != host || stats.LinkID != linkID {
+			kept = append(kept, stats)
 		}
 	}
+	m.Stats = kept
 
 	return nil
 }
 
-func (m *MockStatsRepo) GetStatsByLinkID(ctx context.Context, linkID string) ([]domain.Stats, error) {
+func (m *MockStatsRepo) GetStatsByLinkID(ctx context.Context, workspaceID, host, linkID string) ([]domain.Stats, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	var stats []domain.Stats
 	for _, stat := range m.Stats {
-		if stat.LinkID == linkID {
+		if stat.WorkspaceID == workspaceID && stat.Domain == host && stat.LinkID == linkID {
 			stats = append(stats, stat)
 		}
 	}
-	return stats, nil
+	return newestFirst(stats), nil
+}
+
+func (m *MockStatsRepo) GetStatsByCampaign(ctx context.Context, workspaceID, campaignID string) ([]domain.Stats, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var stats []domain.Stats
+	for _, stat := range m.Stats {
+		if stat.WorkspaceID == workspaceID && stat.CampaignID == campaignID {
+			stats = append(stats, stat)
+		}
+	}
+	return newestFirst(stats), nil
+}
+
+func newestFirst(stats []domain.Stats) []domain.Stats {
+	sorted := append([]domain.Stats(nil), stats...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt.After(sorted[j].CreatedAt)
+	})
+	return sorted
 }
@@ -0,0 +1,75 @@
+package mock
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+)
+
+type MockWorkspaceRepo struct {
+	mu         sync.Mutex
+	Workspaces []domain.Workspace
+}
+
+// NewMockWorkspaceRepo returns a repository holding the default
+// workspace, as the postgres migration creates it.
+func NewMockWorkspaceRepo() *MockWorkspaceRepo {
+	return &MockWorkspaceRepo{
+		Workspaces: []domain.Workspace{{Id: domain.DefaultWorkspaceID, Name: "Default"}},
+	}
+}
+
+func (m *MockWorkspaceRepo) All(ctx context.Context) ([]domain.Workspace, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	workspaces := append([]domain.Workspace(nil), m.Workspaces...)
+	sort.SliceStable(workspaces, func(i, j int) bool {
+		return workspaces[i].Id < workspaces[j].Id
+	})
+	return workspaces, nil
+}
+
+func (m *MockWorkspaceRepo) Get(ctx context.Context, id string) (domain.Workspace, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, workspace := range m.Workspaces {
+		if workspace.Id == id {
+			return workspace, nil
+		}
+	}
+
+	return domain.Workspace{}, fmt.Errorf("workspace %q: %w", id, domain.ErrNotFound)
+}
+
+func (m *MockWorkspaceRepo) Create(ctx context.Context, workspace domain.Workspace) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.Workspaces {
+		if existing.Id == workspace.Id {
+			return fmt.Errorf("workspace %q: %w", workspace.Id, domain.ErrConflict)
+		}
+	}
+	m.Workspaces = append(m.Workspaces, workspace)
+	return nil
+}
+
+func (m *MockWorkspaceRepo) Update(ctx context.Context, workspace domain.Workspace) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, existing := range m.Workspaces {
+		if existing.Id == workspace.Id {
+			workspace.CreatedAt = existing.CreatedAt
+			m.Workspaces[i] = workspace
+			return nil
+		}
+	}
+
+	return fmt.Errorf("workspace %q: %w", workspace.Id, domain.ErrNotFound)
+}
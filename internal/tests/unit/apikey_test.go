@@ -0,0 +1,171 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/itsbaivab/url-shortener/internal/auth"
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/itsbaivab/url-shortener/internal/core/services"
+	"github.com/itsbaivab/url-shortener/internal/tests/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeys(t *testing.T) {
+	ctx := context.Background()
+	keys := services.NewAPIKeyService(mock.NewMockAPIKeyRepo())
+
+	t.Run("Issued keys authenticate their owner", func(t *testing.T) {
+		token, key, err := keys.Issue(ctx, "alice", "laptop", nil)
+		require.NoError(t, err)
+		assert.NotContains(t, key.Hash, token)
+
+		principal, err := keys.Authenticate(ctx, token)
+		require.NoError(t, err)
+		assert.Equal(t, "alice", principal.OwnerID)
+		assert.False(t, principal.HasScope(domain.ScopeAdmin))
+	})
+
+	t.Run("Wrong, malformed and revoked keys are rejected", func(t *testing.T) {
+		token, key, err := keys.Issue(ctx, "bob", "", nil)
+		require.NoError(t, err)
+
+		for _, bad := range []string{"", "nonsense", token + "x", "usk_" + key.Id + "_wrong"} {
+			_, err := keys.Authenticate(ctx, bad)
+			assert.ErrorIs(t, err, domain.ErrUnauthorized, bad)
+		}
+
+		require.NoError(t, keys.Revoke(ctx, key.Id))
+		_, err = keys.Authenticate(ctx, token)
+		assert.ErrorIs(t, err, domain.ErrUnauthorized)
+	})
+
+	t.Run("Only admins manage keys", func(t *testing.T) {
+		userCtx := domain.WithPrincipal(ctx, domain.Principal{OwnerID: "alice"})
+		_, _, err := keys.Issue(userCtx, "alice", "", []string{domain.ScopeAdmin})
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+
+		adminCtx := domain.WithPrincipal(ctx, domain.Principal{OwnerID: "root", Scopes: []string{domain.ScopeAdmin}})
+		_, _, err = keys.Issue(adminCtx, "carol", "", []string{domain.ScopeAdmin})
+		assert.NoError(t, err)
+
+		_, _, err = keys.Issue(adminCtx, "carol", "", []string{"superuser"})
+		assert.ErrorIs(t, err, domain.ErrValidation)
+	})
+}
+
+func TestLinkOwnership(t *testing.T) {
+	ctx := context.Background()
+	service := services.NewLinkService(mock.NewMockLinkRepo(), mock.NewMockRedisCache())
+
+	alice := domain.WithPrincipal(ctx, domain.Principal{OwnerID: "alice"})
+	bob := domain.WithPrincipal(ctx, domain.Principal{OwnerID: "bob"})
+	admin := domain.WithPrincipal(ctx, domain.Principal{OwnerID: "root", Scopes: []string{domain.ScopeAdmin}})
+
+	link := domain.Link{Id: "owned1", OriginalURL: "https://example.com/alice", CreatedAt: time.Now()}
+	require.NoError(t, service.Create(alice, link))
+
+	t.Run("Owners only see their links", func(t *testing.T) {
+		links, err := service.GetAll(alice)
+		require.NoError(t, err)
+		require.Len(t, links, 1)
+		assert.Equal(t, "owned1", links[0].Id)
+
+		links, err = service.GetAll(bob)
+		require.NoError(t, err)
+		assert.Empty(t, links)
+
+		links, err = service.GetAll(admin)
+		require.NoError(t, err)
+		assert.Greater(t, len(links), 1)
+	})
+
+	t.Run("Other owners cannot read or delete", func(t *testing.T) {
+		_, err := service.Get(bob, "owned1")
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+		assert.ErrorIs(t, service.Delete(bob, "owned1"), domain.ErrNotFound)
+
+		_, err = service.Get(alice, "owned1")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Admins can delete any link", func(t *testing.T) {
+		assert.NoError(t, service.Delete(admin, "owned1"))
+	})
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	keys := services.NewAPIKeyService(mock.NewMockAPIKeyRepo())
+	userKey, _, err := keys.Issue(context.Background(), "alice", "", nil)
+	require.NoError(t, err)
+	adminKey, _, err := keys.Issue(context.Background(), "root", "", []string{domain.ScopeAdmin})
+	require.NoError(t, err)
+	acmeAdminKey, _, err := keys.Issue(domain.WithWorkspace(context.Background(), "acme"), "bob", "", []string{domain.ScopeAdmin})
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	api := router.Group("", auth.Middleware(keys))
+	api.GET("/links", func(c *gin.Context) {
+		p, _ := domain.PrincipalFrom(c.Request.Context())
+		c.String(http.StatusOK, p.OwnerID)
+	})
+	api.GET("/admin/api-keys", auth.RequireScope(domain.ScopeAdmin), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	api.PUT("/debug/log-level", auth.RequireOperator(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	do := func(path string, header, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Missing key", func(t *testing.T) {
+		w := do("/links", "", "")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+	})
+
+	t.Run("Bearer token", func(t *testing.T) {
+		w := do("/links", "Authorization", "Bearer "+userKey)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "alice", w.Body.String())
+	})
+
+	t.Run("X-API-Key header", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, do("/links", auth.APIKeyHeader, userKey).Code)
+	})
+
+	t.Run("Admin scope", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, do("/admin/api-keys", auth.APIKeyHeader, userKey).Code)
+		assert.Equal(t, http.StatusOK, do("/admin/api-keys", auth.APIKeyHeader, adminKey).Code)
+	})
+
+	t.Run("Operators only", func(t *testing.T) {
+		put := func(key string) int {
+			req := httptest.NewRequest(http.MethodPut, "/debug/log-level", nil)
+			if key != "" {
+				req.Header.Set(auth.APIKeyHeader, key)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			return w.Code
+		}
+		assert.Equal(t, http.StatusUnauthorized, put(""))
+		assert.Equal(t, http.StatusForbidden, put(userKey))
+		assert.Equal(t, http.StatusForbidden, put(acmeAdminKey), "workspace admins cannot change the process")
+		assert.Equal(t, http.StatusOK, put(adminKey))
+	})
+}
@@ -0,0 +1,179 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/itsbaivab/url-shortener/internal/core/services"
+	"github.com/itsbaivab/url-shortener/internal/logging"
+	"github.com/itsbaivab/url-shortener/internal/tests/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditLog(t *testing.T) {
+	ctx := context.Background()
+	repo := mock.NewMockAuditRepo()
+	audit := services.NewAuditService(repo)
+
+	operator := domain.WithPrincipal(ctx, domain.Principal{OwnerID: "root", KeyID: "k1", Scopes: []string{domain.ScopeAdmin}})
+	acme := domain.WithWorkspace(ctx, "acme")
+	acmeAdmin := domain.WithPrincipal(acme, domain.Principal{WorkspaceID: "acme", OwnerID: "boss", Scopes: []string{domain.ScopeAdmin}})
+	alice := domain.WithPrincipal(acme, domain.Principal{WorkspaceID: "acme", OwnerID: "alice", Scopes: domain.DefaultAPIKeyScopes})
+	alice = domain.WithOrigin(alice, domain.Origin{RequestID: "req-1", SourceIP: "203.0.113.7"})
+
+	links := services.NewLinkService(mock.NewMockLinkRepo(), mock.NewMockRedisCache()).WithAudit(audit)
+	require.NoError(t, links.Create(alice, domain.Link{Id: "promo", OriginalURL: "https://example.com/", CreatedAt: time.Now()}))
+	require.NoError(t, links.Delete(alice, "promo"))
+
+	t.Run("Link changes are recorded with who and where", func(t *testing.T) {
+		entries, err := audit.Find(operator, domain.AuditFilter{ResourceID: "promo"})
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+
+		deleted, created := entries[0], entries[1]
+		assert.Equal(t, domain.AuditLinkDeleted, deleted.Action)
+		assert.Equal(t, domain.AuditLinkCreated, created.Action)
+		for _, entry := range entries {
+			assert.Equal(t, "alice", entry.Actor)
+			assert.Equal(t, "acme", entry.WorkspaceID)
+			assert.Equal(t, "req-1", entry.RequestID)
+			assert.Equal(t, "203.0.113.7", entry.SourceIP)
+		}
+		assert.Empty(t, created.Before)
+		assert.Contains(t, string(created.After), `"original_url":"https://example.com/"`)
+		assert.Contains(t, string(deleted.Before), `"owner_id":"alice"`)
+		assert.Empty(t, deleted.After)
+	})
+
+	t.Run("Admin changes are recorded", func(t *testing.T) {
+		keys := services.NewAPIKeyService(mock.NewMockAPIKeyRepo()).WithAudit(audit)
+		_, key, err := keys.Issue(acmeAdmin, "carol", "ci", nil)
+		require.NoError(t, err)
+		require.NoError(t, keys.Revoke(acmeAdmin, key.Id))
+
+		workspaces := services.NewWorkspaceService(mock.NewMockWorkspaceRepo()).WithAudit(audit)
+		_, err = workspaces.Create(operator, domain.Workspace{Id: "globex"})
+		require.NoError(t, err)
+		_, err = workspaces.Update(operator, domain.Workspace{Id: "globex", Name: "Globex Corp"})
+		require.NoError(t, err)
+
+		blocklist := services.NewBlocklistService(mock.NewMockBlocklistRepo()).WithAudit(audit)
+		rule, err := blocklist.Add(operator, domain.BlockRule{Kind: domain.BlockDomain, Pattern: "evil.example"})
+		require.NoError(t, err)
+		require.NoError(t, blocklist.Remove(operator, rule.Id))
+
+		revoked, err := audit.Find(operator, domain.AuditFilter{Action: domain.AuditAPIKeyRevoked})
+		require.NoError(t, err)
+		require.Len(t, revoked, 1)
+		assert.Equal(t, "boss", revoked[0].Actor)
+		assert.NotContains(t, string(revoked[0].Before), "revoked_at")
+		assert.Contains(t, string(revoked[0].After), "revoked_at")
+		assert.NotContains(t, string(revoked[0].After), key.Hash, "key hashes are not recorded")
+
+		updated, err := audit.Find(operator, domain.AuditFilter{Action: domain.AuditWorkspaceUpdated})
+		require.NoError(t, err)
+		require.Len(t, updated, 1)
+		assert.Contains(t, string(updated[0].Before), `"name":"globex"`)
+		assert.Contains(t, string(updated[0].After), `"name":"Globex Corp"`)
+
+		rules, err := audit.Find(operator, domain.AuditFilter{Action: "block_rule."})
+		require.NoError(t, err)
+		require.Len(t, rules, 2)
+		assert.Equal(t, domain.AuditBlockRuleRemoved, rules[0].Action)
+		assert.Contains(t, string(rules[0].Before), `"pattern":"evil.example"`)
+	})
+
+	t.Run("Workspace admins only read their own workspace", func(t *testing.T) {
+		entries, err := audit.Find(acmeAdmin, domain.AuditFilter{})
+		require.NoError(t, err)
+		require.NotEmpty(t, entries)
+		for _, entry := range entries {
+			assert.Equal(t, "acme", entry.WorkspaceID)
+		}
+
+		_, err = audit.Find(acmeAdmin, domain.AuditFilter{WorkspaceID: "globex"})
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+		_, err = audit.Find(alice, domain.AuditFilter{})
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+		_, err = audit.Find(operator, domain.AuditFilter{Limit: domain.MaxAuditPageSize + 1})
+		assert.ErrorIs(t, err, domain.ErrValidation)
+	})
+
+	t.Run("Pages go back in time", func(t *testing.T) {
+		first, err := audit.Find(operator, domain.AuditFilter{Limit: 2})
+		require.NoError(t, err)
+		require.Len(t, first, 2)
+		second, err := audit.Find(operator, domain.AuditFilter{Limit: 2, Before: first[1].Seq})
+		require.NoError(t, err)
+		require.NotEmpty(t, second)
+		assert.Less(t, second[0].Seq, first[1].Seq)
+	})
+}
+
+func TestAuditHashChain(t *testing.T) {
+	ctx := context.Background()
+	repo := mock.NewMockAuditRepo()
+	plain := services.NewAuditService(repo)
+	chained := services.NewAuditService(repo).WithHashChain(true)
+	record := func(a *services.AuditService, id string) {
+		a.Record(ctx, domain.AuditEntry{Action: domain.AuditLinkCreated, WorkspaceID: "acme", ResourceID: id}, nil, domain.Link{Id: id})
+	}
+
+	record(plain, "before-the-chain")
+	record(chained, "a")
+	record(chained, "b")
+	record(chained, "c")
+
+	result, err := chained.Verify(ctx)
+	require.NoError(t, err)
+	assert.True(t, result.Intact())
+	assert.Equal(t, 4, result.Checked)
+	assert.Equal(t, 3, result.Chained)
+	assert.Empty(t, repo.Entries[0].Hash)
+	assert.Equal(t, repo.Entries[1].Hash, repo.Entries[2].PrevHash)
+
+	t.Run("Only operators verify the log", func(t *testing.T) {
+		tenantAdmin := domain.WithPrincipal(ctx, domain.Principal{WorkspaceID: "acme", OwnerID: "boss", Scopes: []string{domain.ScopeAdmin}})
+		_, err := chained.Verify(tenantAdmin)
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+	})
+
+	t.Run("Edited entries break the chain", func(t *testing.T) {
+		repo.Entries[2].After = json.RawMessage(`{"id":"b","original_url":"https://evil.example/"}`)
+		result, err := chained.Verify(ctx)
+		require.NoError(t, err)
+		assert.False(t, result.Intact())
+		assert.Equal(t, repo.Entries[2].Seq, result.BrokenAt)
+	})
+
+	t.Run("Removed entries break the chain", func(t *testing.T) {
+		repo.Entries = append(repo.Entries[:2], repo.Entries[3:]...)
+		result, err := chained.Verify(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, repo.Entries[2].Seq, result.BrokenAt)
+	})
+}
+
+func TestRequestOrigin(t *testing.T) {
+	router := gin.New()
+	router.Use(logging.RequestID())
+	router.GET("/", func(c *gin.Context) {
+		origin := domain.OriginOf(c.Request.Context())
+		c.JSON(http.StatusOK, gin.H{"request_id": origin.RequestID, "source_ip": origin.SourceIP})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(logging.RequestIDHeader, "req-42")
+	req.RemoteAddr = "198.51.100.9:4711"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.JSONEq(t, `{"request_id":"req-42","source_ip":"198.51.100.9"}`, w.Body.String())
+}
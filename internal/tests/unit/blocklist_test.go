@@ -0,0 +1,235 @@
+package unit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/itsbaivab/url-shortener/internal/adapters/handlers"
+	"github.com/itsbaivab/url-shortener/internal/adapters/takedown"
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/itsbaivab/url-shortener/internal/core/services"
+	"github.com/itsbaivab/url-shortener/internal/tests/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlocklist(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "phishing.txt")
+	writeBlocklist := func(lines ...string) {
+		require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o600))
+	}
+	writeBlocklist(
+		"# exported feed",
+		"evil.example",
+		"prefix https://files.example.com/Malware/ drive-by downloads",
+		`regex ^https?://[^/]+/wp-admin/.*\.php$ compromised sites`,
+		"bogus line with words",
+		"domain not_a_domain",
+	)
+
+	blocklist := services.NewBlocklistService(mock.NewMockBlocklistRepo()).WithFiles(path)
+	require.NoError(t, blocklist.Reload(ctx))
+
+	t.Run("Rules match", func(t *testing.T) {
+		for _, tt := range []struct {
+			url     string
+			blocked bool
+			reason  string
+		}{
+			{"https://evil.example/login", true, "listed in phishing.txt"},
+			{"https://WWW.Evil.Example:8443/", true, "listed in phishing.txt"},
+			{"https://notevil.example/", false, ""},
+			{"https://files.example.com/malware/payload.exe", true, "drive-by downloads"},
+			{"https://files.example.com/docs/", false, ""},
+			{"http://blog.example.org/wp-admin/shell.php", true, "compromised sites"},
+			{"http://blog.example.org/wp-admin/shell.php.txt", false, ""},
+		} {
+			rule, blocked := blocklist.Check(tt.url)
+			assert.Equal(t, tt.blocked, blocked, tt.url)
+			assert.Equal(t, tt.reason, rule.Reason, tt.url)
+		}
+	})
+
+	t.Run("Invalid lines are skipped", func(t *testing.T) {
+		rules, err := blocklist.All(ctx)
+		require.NoError(t, err)
+		assert.Len(t, rules, 3)
+		for _, rule := range rules {
+			assert.Equal(t, path, rule.Source)
+		}
+	})
+
+	t.Run("Files are reloaded without a restart", func(t *testing.T) {
+		writeBlocklist("scam.example")
+		require.NoError(t, blocklist.Reload(ctx))
+
+		_, blocked := blocklist.Check("https://evil.example/")
+		assert.False(t, blocked)
+		_, blocked = blocklist.Check("https://scam.example/")
+		assert.True(t, blocked)
+	})
+
+	t.Run("Failed reloads keep the active rules", func(t *testing.T) {
+		require.NoError(t, os.Remove(path))
+		assert.Error(t, blocklist.Reload(ctx))
+
+		_, blocked := blocklist.Check("https://scam.example/")
+		assert.True(t, blocked)
+	})
+}
+
+func TestBlocklistManagement(t *testing.T) {
+	ctx := context.Background()
+	blocklist := services.NewBlocklistService(mock.NewMockBlocklistRepo())
+	operator := domain.WithPrincipal(ctx, domain.Principal{OwnerID: "root", Scopes: []string{domain.ScopeAdmin}})
+	tenantAdmin := domain.WithPrincipal(ctx, domain.Principal{WorkspaceID: "acme", OwnerID: "boss", Scopes: []string{domain.ScopeAdmin}})
+
+	t.Run("Only operators manage rules", func(t *testing.T) {
+		_, err := blocklist.Add(tenantAdmin, domain.BlockRule{Kind: domain.BlockDomain, Pattern: "evil.example"})
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+		_, err = blocklist.All(tenantAdmin)
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+	})
+
+	t.Run("Invalid rules are rejected", func(t *testing.T) {
+		for _, bad := range []domain.BlockRule{
+			{Kind: "host", Pattern: "evil.example"},
+			{Kind: domain.BlockDomain, Pattern: "not a domain"},
+			{Kind: domain.BlockPrefix, Pattern: "evil.example/path"},
+			{Kind: domain.BlockRegex, Pattern: "(unclosed"},
+		} {
+			_, err := blocklist.Add(operator, bad)
+			assert.ErrorIs(t, err, domain.ErrValidation, bad.Pattern)
+		}
+	})
+
+	rule, err := blocklist.Add(operator, domain.BlockRule{Kind: domain.BlockDomain, Pattern: "Evil.Example.", Reason: "phishing"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, rule.Id)
+	assert.Equal(t, "evil.example", rule.Pattern)
+
+	t.Run("Added rules apply at once", func(t *testing.T) {
+		matched, blocked := blocklist.Check("https://login.evil.example/")
+		assert.True(t, blocked)
+		assert.Equal(t, rule.Id, matched.Id)
+	})
+
+	t.Run("Removed rules stop applying", func(t *testing.T) {
+		assert.ErrorIs(t, blocklist.Remove(tenantAdmin, rule.Id), domain.ErrForbidden)
+		require.NoError(t, blocklist.Remove(operator, rule.Id))
+		assert.ErrorIs(t, blocklist.Remove(operator, rule.Id), domain.ErrNotFound)
+
+		_, blocked := blocklist.Check("https://login.evil.example/")
+		assert.False(t, blocked)
+	})
+
+	t.Run("Without storage only file rules apply", func(t *testing.T) {
+		_, err := services.NewBlocklistService(nil).Add(operator, domain.BlockRule{Kind: domain.BlockDomain, Pattern: "evil.example"})
+		assert.ErrorIs(t, err, domain.ErrValidation)
+	})
+}
+
+func TestBlockedDestinations(t *testing.T) {
+	ctx := context.Background()
+	operator := domain.WithPrincipal(ctx, domain.Principal{OwnerID: "root", Scopes: []string{domain.ScopeAdmin}})
+	blocklist := services.NewBlocklistService(mock.NewMockBlocklistRepo())
+	_, err := blocklist.Add(operator, domain.BlockRule{Kind: domain.BlockDomain, Pattern: "evil.example", Reason: "phishing"})
+	require.NoError(t, err)
+
+	t.Run("Blocked destinations are refused at create time", func(t *testing.T) {
+		resolver := mock.NewMockIPResolver()
+		resolver.Add("login.evil.example", "93.184.216.34")
+		validator := services.NewDestinationValidator(resolver).WithBlocklist(blocklist)
+
+		_, err := validator.Validate(ctx, "https://login.evil.example/")
+		var validation *domain.ValidationError
+		require.ErrorAs(t, err, &validation)
+		assert.Equal(t, domain.CodeBlockedDestination, validation.Code)
+		assert.Contains(t, validation.Reason, "phishing")
+	})
+
+	repo := mock.NewMockLinkRepo()
+	cache := mock.NewMockRedisCache()
+	links := services.NewLinkService(repo, cache).WithBlocklist(blocklist)
+	acme := domain.WithWorkspace(ctx, "acme")
+	require.NoError(t, links.Create(acme, domain.Link{Id: "safe", OriginalURL: "https://example.com/", CreatedAt: time.Now()}))
+	require.NoError(t, links.Create(acme, domain.Link{Id: "flagged", OriginalURL: "https://scam.example/", CreatedAt: time.Now()}))
+
+	url, err := links.GetOriginalURL(acme, "flagged")
+	require.NoError(t, err)
+	assert.Equal(t, "https://scam.example/", *url)
+
+	_, err = blocklist.Add(operator, domain.BlockRule{Kind: domain.BlockDomain, Pattern: "scam.example", Reason: "advance-fee scam"})
+	require.NoError(t, err)
+
+	t.Run("Flagged links stop redirecting at once", func(t *testing.T) {
+		_, err := links.GetOriginalURL(acme, "flagged")
+		var disabled *domain.DisabledError
+		require.ErrorAs(t, err, &disabled)
+		assert.ErrorIs(t, err, domain.ErrDisabled)
+		assert.Equal(t, "advance-fee scam", disabled.Reason)
+	})
+
+	t.Run("Rechecks disable flagged links", func(t *testing.T) {
+		tenantAdmin := domain.WithPrincipal(acme, domain.Principal{WorkspaceID: "acme", OwnerID: "boss", Scopes: []string{domain.ScopeAdmin}})
+		_, err := links.DisableFlagged(tenantAdmin)
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+
+		n, err := links.DisableFlagged(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+
+		got, err := repo.Get(ctx, "acme", "", "flagged")
+		require.NoError(t, err)
+		assert.True(t, got.Disabled())
+		assert.Equal(t, "advance-fee scam", got.DisabledReason)
+		cached, _ := cache.Get(ctx, "acme:flagged")
+		assert.Empty(t, cached, "disabled links are evicted from the cache")
+
+		_, err = links.GetOriginalURL(acme, "safe")
+		assert.NoError(t, err)
+
+		n, err = links.DisableFlagged(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, n, "disabled links are not disabled again")
+	})
+
+	t.Run("Disabled links stay down when the rule goes", func(t *testing.T) {
+		rules, err := blocklist.All(operator)
+		require.NoError(t, err)
+		for _, rule := range rules {
+			require.NoError(t, blocklist.Remove(operator, rule.Id))
+		}
+
+		_, err = links.GetOriginalURL(acme, "flagged")
+		assert.ErrorIs(t, err, domain.ErrDisabled)
+	})
+
+	t.Run("Browsers get the takedown page", func(t *testing.T) {
+		handler := handlers.NewRedirectFunctionHandler(services.NewLinkService(repo, cache), services.NewStatsService(mock.NewMockStatsRepo(), cache))
+		repo.Links = append(repo.Links, domain.Link{Id: "gone", WorkspaceID: domain.DefaultWorkspaceID, OriginalURL: "https://scam.example/", CreatedAt: time.Now()})
+		require.NoError(t, repo.Disable(ctx, domain.DefaultWorkspaceID, "", "gone", "<script>alert(1)</script>", time.Now()))
+
+		response, err := handler.Redirect(ctx, events.APIGatewayV2HTTPRequest{
+			RawPath: "/gone",
+			Headers: map[string]string{"accept": "text/html,application/xhtml+xml;q=0.9,*/*;q=0.8"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 451, response.StatusCode)
+		assert.Equal(t, takedown.ContentType, response.Headers["Content-Type"])
+		assert.Contains(t, response.Body, "This link has been disabled")
+		assert.NotContains(t, response.Body, "<script>", "reasons are escaped")
+		assert.Empty(t, response.Headers["Location"])
+
+		response, err = handler.Redirect(ctx, events.APIGatewayV2HTTPRequest{RawPath: "/gone"})
+		require.NoError(t, err)
+		assert.Equal(t, 451, response.StatusCode)
+		assert.Contains(t, response.Body, "/problems/disabled")
+	})
+}
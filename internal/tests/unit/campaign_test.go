@@ -0,0 +1,196 @@
+package unit
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/itsbaivab/url-shortener/internal/core/services"
+	"github.com/itsbaivab/url-shortener/internal/tests/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCampaigns(t *testing.T) {
+	ctx := domain.WithWorkspace(context.Background(), "acme")
+	auditRepo := mock.NewMockAuditRepo()
+	audit := services.NewAuditService(auditRepo)
+	campaignRepo := mock.NewMockCampaignRepo()
+	campaigns := services.NewCampaignService(campaignRepo).WithAudit(audit)
+	linkCache := mock.NewMockRedisCache()
+	links := services.NewLinkService(mock.NewMockLinkRepo(), linkCache).WithCampaigns(campaignRepo)
+
+	spring, err := campaigns.Create(ctx, domain.Campaign{
+		Name: "Spring sale",
+		UTM: domain.UTMTemplate{
+			Defaults:  domain.UTM{Source: "newsletter", Medium: "email", Campaign: "spring"},
+			Platforms: map[string]domain.UTM{"instagram": {Source: "instagram", Medium: "social"}},
+		},
+	})
+	require.NoError(t, err)
+
+	t.Run("Campaigns are validated", func(t *testing.T) {
+		for name, campaign := range map[string]domain.Campaign{
+			"no name":          {},
+			"unknown platform": {Name: "x", UTM: domain.UTMTemplate{Platforms: map[string]domain.UTM{"myspace": {Source: "x"}}}},
+			"long parameter":   {Name: "x", UTM: domain.UTMTemplate{Defaults: domain.UTM{Term: string(make([]byte, 300))}}},
+		} {
+			_, err := campaigns.Create(ctx, campaign)
+			assert.ErrorIs(t, err, domain.ErrValidation, name)
+		}
+	})
+
+	t.Run("Campaigns belong to their workspace", func(t *testing.T) {
+		assert.Equal(t, "acme", spring.WorkspaceID)
+		assert.NotEmpty(t, spring.Id)
+
+		all, err := campaigns.All(ctx)
+		require.NoError(t, err)
+		assert.Len(t, all, 1)
+
+		_, err = campaigns.Get(domain.WithWorkspace(context.Background(), "globex"), spring.Id)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("Links only join known campaigns", func(t *testing.T) {
+		err := links.Create(ctx, domain.Link{Id: "lost", OriginalURL: "https://example.com/", CreatedAt: time.Now(), CampaignID: "nope"})
+		assert.ErrorIs(t, err, domain.ErrValidation)
+
+		err = services.NewLinkService(mock.NewMockLinkRepo(), mock.NewMockRedisCache()).
+			Create(ctx, domain.Link{Id: "lost", OriginalURL: "https://example.com/", CreatedAt: time.Now(), CampaignID: spring.Id})
+		assert.ErrorIs(t, err, domain.ErrValidation, "campaigns are not enabled")
+	})
+
+	require.NoError(t, links.Create(ctx, domain.Link{
+		Id: "sale", OriginalURL: "https://shop.example.com/sale?utm_medium=banner", CreatedAt: time.Now(),
+		CampaignID: spring.Id, ForwardQuery: true,
+	}))
+
+	t.Run("The UTM template is merged into the destination", func(t *testing.T) {
+		target, err := links.Resolve(ctx, "sale", nil)
+		require.NoError(t, err)
+		assert.Equal(t, spring.Id, target.CampaignID)
+
+		location, err := target.Location("", nil, domain.PlatformUnknown)
+		require.NoError(t, err)
+		assert.Equal(t, "https://shop.example.com/sale?utm_medium=banner&utm_campaign=spring&utm_source=newsletter", location,
+			"destination parameters win over the template")
+
+		location, err = target.Location("", url.Values{"utm_source": {"friend"}}, domain.PlatformInstagram)
+		require.NoError(t, err)
+		assert.Equal(t, "https://shop.example.com/sale?utm_medium=banner&utm_campaign=spring&utm_source=friend", location,
+			"forwarded parameters win over the template")
+
+		location, err = target.Location("", url.Values{"utm_medium": {"email"}}, domain.PlatformUnknown)
+		require.NoError(t, err)
+		assert.Equal(t, "https://shop.example.com/sale?utm_medium=banner&utm_campaign=spring&utm_source=newsletter", location,
+			"but not over the destination")
+
+		location, err = target.Location("", nil, domain.PlatformInstagram)
+		require.NoError(t, err)
+		assert.Equal(t, "https://shop.example.com/sale?utm_medium=banner&utm_campaign=spring&utm_source=instagram", location)
+	})
+
+	t.Run("Cached targets keep the template", func(t *testing.T) {
+		cached, err := linkCache.Get(ctx, "acme:sale")
+		require.NoError(t, err)
+		target, err := domain.DecodeTarget(cached)
+		require.NoError(t, err)
+		require.NotNil(t, target.UTM)
+		assert.Equal(t, spring.UTM, *target.UTM)
+	})
+
+	t.Run("Links of deleted campaigns redirect without UTM", func(t *testing.T) {
+		autumn, err := campaigns.Create(ctx, domain.Campaign{Name: "Autumn", UTM: domain.UTMTemplate{Defaults: domain.UTM{Campaign: "autumn"}}})
+		require.NoError(t, err)
+		require.NoError(t, links.Create(ctx, domain.Link{Id: "leaves", OriginalURL: "https://example.com/", CreatedAt: time.Now(), CampaignID: autumn.Id}))
+		require.NoError(t, campaigns.Delete(ctx, autumn.Id))
+
+		target, err := links.Resolve(ctx, "leaves", nil)
+		require.NoError(t, err)
+		location, err := target.Location("", nil, domain.PlatformUnknown)
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.com/", location)
+	})
+
+	t.Run("Changes are audited", func(t *testing.T) {
+		spring.Name = "Spring sale 2026"
+		_, err := campaigns.Update(ctx, spring)
+		require.NoError(t, err)
+
+		entries, err := audit.Find(domain.WithPrincipal(ctx, domain.Principal{OwnerID: "root", Scopes: []string{domain.ScopeAdmin}}), domain.AuditFilter{ResourceID: spring.Id})
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		assert.Equal(t, domain.AuditCampaignUpdated, entries[0].Action)
+		assert.Equal(t, domain.AuditCampaignCreated, entries[1].Action)
+	})
+}
+
+func TestCampaignStats(t *testing.T) {
+	ctx := domain.WithWorkspace(context.Background(), "acme")
+	stats := services.NewStatsService(mock.NewMockStatsRepo(), mock.NewMockRedisCache())
+
+	for i, stat := range []domain.Stats{
+		{LinkID: "a", CampaignID: "spring", Platform: domain.PlatformInstagram},
+		{LinkID: "b", CampaignID: "spring", Platform: domain.PlatformInstagram},
+		{LinkID: "b", CampaignID: "spring", Platform: domain.PlatformUnknown},
+		{LinkID: "b", CampaignID: "spring", PasswordAttempt: domain.PasswordRejected},
+		{LinkID: "c", CampaignID: "autumn"},
+	} {
+		stat.Id = string(rune('a' + i))
+		stat.WorkspaceID = "acme"
+		stat.CreatedAt = time.Now()
+		require.NoError(t, stats.Create(ctx, stat))
+	}
+
+	summary, err := stats.CampaignStats(ctx, "spring", nil)
+	require.NoError(t, err)
+	assert.Equal(t, 3, summary.Clicks)
+	assert.Equal(t, []domain.LinkClicks{{LinkID: "b", Clicks: 2}, {LinkID: "a", Clicks: 1}}, summary.Links)
+	assert.Equal(t, map[string]int{"instagram": 2, "unknown": 1}, summary.Platforms)
+
+	t.Run("Owners only count their own links", func(t *testing.T) {
+		links := services.NewLinkService(mock.NewMockLinkRepo(), mock.NewMockRedisCache())
+		alice := domain.WithPrincipal(ctx, domain.Principal{OwnerID: "alice", WorkspaceID: "acme", Scopes: []string{domain.ScopeLinksWrite, domain.ScopeStatsRead}})
+		bob := domain.WithPrincipal(ctx, domain.Principal{OwnerID: "bob", WorkspaceID: "acme", Scopes: []string{domain.ScopeLinksWrite, domain.ScopeStatsRead}})
+		require.NoError(t, links.Create(alice, domain.Link{Id: "a", OriginalURL: "https://example.com/a", CreatedAt: time.Now()}))
+		require.NoError(t, links.Create(bob, domain.Link{Id: "b", OriginalURL: "https://example.com/b", CreatedAt: time.Now()}))
+
+		owned := func(ctx context.Context) func(linkID, linkDomain string) bool {
+			return func(linkID, linkDomain string) bool {
+				_, err := links.Get(domain.WithLinkDomain(ctx, linkDomain), linkID)
+				return err == nil
+			}
+		}
+		summary, err := stats.CampaignStats(alice, "spring", owned(alice))
+		require.NoError(t, err)
+		assert.Equal(t, 1, summary.Clicks)
+		assert.Equal(t, []domain.LinkClicks{{LinkID: "a", Clicks: 1}}, summary.Links)
+		assert.Equal(t, map[string]int{"instagram": 1}, summary.Platforms)
+
+		summary, err = stats.CampaignStats(bob, "spring", owned(bob))
+		require.NoError(t, err)
+		assert.Equal(t, 2, summary.Clicks)
+		assert.Equal(t, []domain.LinkClicks{{LinkID: "b", Clicks: 2}}, summary.Links)
+	})
+}
+
+func TestDetectPlatform(t *testing.T) {
+	tests := []struct {
+		userAgent, referer string
+		want               domain.Platform
+	}{
+		{"Mozilla/5.0 (iPhone) Instagram 300.0.0.0", "", domain.PlatformInstagram},
+		{"Twitterbot/1.0", "", domain.PlatformTwitter},
+		{"Mozilla/5.0", "https://t.co/abc", domain.PlatformTwitter},
+		{"Mozilla/5.0", "https://www.youtube.com/watch?v=x", domain.PlatformYouTube},
+		{"Mozilla/5.0", "https://l.instagram.com/", domain.PlatformInstagram},
+		{"Mozilla/5.0", "https://example.com/", domain.PlatformUnknown},
+		{"", "", domain.PlatformUnknown},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, domain.DetectPlatform(tt.userAgent, tt.referer), "%s %s", tt.userAgent, tt.referer)
+	}
+}
@@ -0,0 +1,217 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/itsbaivab/url-shortener/internal/core/services"
+	"github.com/itsbaivab/url-shortener/internal/tests/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDomainVerification(t *testing.T) {
+	ctx := context.Background()
+	resolver := mock.NewMockTXTResolver()
+	domains := services.NewDomainService(mock.NewMockDomainRepo(), resolver)
+
+	admin := func(workspaceID string) context.Context {
+		p := domain.Principal{WorkspaceID: workspaceID, OwnerID: "boss", Scopes: []string{domain.ScopeAdmin}}
+		return domain.WithWorkspace(domain.WithPrincipal(ctx, p), workspaceID)
+	}
+	acme, brand := admin("acme"), admin("brand")
+
+	t.Run("Only admins manage domains", func(t *testing.T) {
+		member := domain.WithWorkspace(domain.WithPrincipal(ctx, domain.Principal{WorkspaceID: "acme", OwnerID: "bob"}), "acme")
+		_, err := domains.Add(member, "go.acme.com")
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+	})
+
+	t.Run("Invalid names are rejected", func(t *testing.T) {
+		for _, bad := range []string{"", "localhost", "not a domain", "-bad.example.com"} {
+			_, err := domains.Add(acme, bad)
+			assert.ErrorIs(t, err, domain.ErrValidation, bad)
+		}
+	})
+
+	added, err := domains.Add(acme, "Go.Acme.com.")
+	require.NoError(t, err)
+	assert.Equal(t, "go.acme.com", added.Name)
+	assert.NotEmpty(t, added.Token)
+	assert.False(t, added.Verified())
+
+	t.Run("Pending domains do not route", func(t *testing.T) {
+		_, _, ok, err := domains.Resolve(ctx, "go.acme.com")
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("Verification requires the TXT record", func(t *testing.T) {
+		_, err := domains.Verify(acme, "go.acme.com")
+		assert.ErrorIs(t, err, domain.ErrValidation)
+
+		resolver.Publish(added.TXTName(), "url-shortener-verification=wrong")
+		_, err = domains.Verify(acme, "go.acme.com")
+		assert.ErrorIs(t, err, domain.ErrValidation)
+	})
+
+	t.Run("DNS failures are not validation errors", func(t *testing.T) {
+		resolver.Err = errors.Join(errors.New("i/o timeout"), domain.ErrUnavailable)
+		defer func() { resolver.Err = nil }()
+		_, err := domains.Verify(acme, "go.acme.com")
+		assert.ErrorIs(t, err, domain.ErrUnavailable)
+	})
+
+	t.Run("Other workspaces cannot verify the domain", func(t *testing.T) {
+		_, err := domains.Verify(brand, "go.acme.com")
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("Pending claims can be taken over", func(t *testing.T) {
+		claim, err := domains.Add(brand, "link.brand.io")
+		require.NoError(t, err)
+		_, err = domains.Add(brand, "link.brand.io")
+		assert.ErrorIs(t, err, domain.ErrConflict)
+
+		taken, err := domains.Add(acme, "link.brand.io")
+		require.NoError(t, err)
+		assert.NotEqual(t, claim.Token, taken.Token)
+		require.NoError(t, domains.Remove(acme, "link.brand.io"))
+	})
+
+	t.Run("Published records verify the domain", func(t *testing.T) {
+		resolver.Publish(added.TXTName(), added.TXTValue())
+		verified, err := domains.Verify(acme, "go.acme.com")
+		require.NoError(t, err)
+		assert.True(t, verified.Verified())
+
+		workspaceID, name, ok, err := domains.Resolve(ctx, "GO.ACME.COM:443")
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, "acme", workspaceID)
+		assert.Equal(t, "go.acme.com", name)
+	})
+
+	t.Run("Verified domains cannot be taken over", func(t *testing.T) {
+		_, err := domains.Add(brand, "go.acme.com")
+		assert.ErrorIs(t, err, domain.ErrConflict)
+	})
+
+	t.Run("Removed domains stop routing", func(t *testing.T) {
+		assert.ErrorIs(t, domains.Remove(brand, "go.acme.com"), domain.ErrNotFound)
+		require.NoError(t, domains.Remove(acme, "go.acme.com"))
+
+		_, _, ok, err := domains.Resolve(ctx, "go.acme.com")
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+}
+
+func TestDomainLinks(t *testing.T) {
+	ctx := context.Background()
+	repo := mock.NewMockDomainRepo()
+	verifiedAt := time.Now()
+	for _, d := range []domain.CustomDomain{
+		{Name: "go.acme.com", WorkspaceID: "acme", CreatedAt: verifiedAt, VerifiedAt: &verifiedAt},
+		{Name: "link.acme.io", WorkspaceID: "acme", CreatedAt: verifiedAt.Add(time.Second), VerifiedAt: &verifiedAt},
+		{Name: "pending.acme.com", WorkspaceID: "acme", CreatedAt: verifiedAt},
+		{Name: "link.brand.io", WorkspaceID: "brand", CreatedAt: verifiedAt, VerifiedAt: &verifiedAt},
+	} {
+		require.NoError(t, repo.Create(ctx, d))
+	}
+
+	links := services.NewLinkService(mock.NewMockLinkRepo(), mock.NewMockRedisCache()).WithDomains(repo)
+	acme := domain.WithWorkspace(ctx, "acme")
+	newLink := func(host, url string) domain.Link {
+		return domain.Link{Id: "abc", Domain: host, OriginalURL: url, CreatedAt: time.Now()}
+	}
+
+	require.NoError(t, links.Create(acme, newLink("go.acme.com", "https://go.example.com")))
+	require.NoError(t, links.Create(acme, newLink("Link.Acme.io", "https://link.example.com")))
+
+	t.Run("IDs are unique per domain", func(t *testing.T) {
+		assert.ErrorIs(t, links.Create(acme, newLink("go.acme.com", "https://again.example.com")), domain.ErrConflict)
+
+		url, err := links.GetOriginalURL(domain.WithLinkDomain(acme, "go.acme.com"), "abc")
+		require.NoError(t, err)
+		assert.Equal(t, "https://go.example.com", *url)
+
+		url, err = links.GetOriginalURL(domain.WithLinkDomain(acme, "link.acme.io"), "abc")
+		require.NoError(t, err)
+		assert.Equal(t, "https://link.example.com", *url)
+	})
+
+	t.Run("Only verified domains of the workspace are accepted", func(t *testing.T) {
+		for _, host := range []string{"pending.acme.com", "link.brand.io", "unknown.example.com"} {
+			assert.ErrorIs(t, links.Create(acme, newLink(host, "https://example.com")), domain.ErrValidation, host)
+		}
+	})
+
+	t.Run("Workspaces default to their first verified domain", func(t *testing.T) {
+		link := newLink("", "https://first.example.com")
+		link.Id = "first"
+		require.NoError(t, links.Create(acme, link))
+
+		got, err := links.Get(domain.WithLinkDomain(acme, "go.acme.com"), "first")
+		require.NoError(t, err)
+		assert.Equal(t, "go.acme.com", got.Domain)
+
+		_, err = links.Get(acme, "first")
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("Workspaces without a verified domain need one", func(t *testing.T) {
+		err := links.Create(domain.WithWorkspace(ctx, "empty"), newLink("", "https://example.com"))
+		assert.ErrorIs(t, err, domain.ErrValidation)
+	})
+
+	t.Run("The default workspace keeps the shared domain", func(t *testing.T) {
+		require.NoError(t, links.Create(ctx, newLink("", "https://shared.example.com")))
+		url, err := links.GetOriginalURL(ctx, "abc")
+		require.NoError(t, err)
+		assert.Equal(t, "https://shared.example.com", *url)
+	})
+
+	t.Run("Deleting is scoped to the domain", func(t *testing.T) {
+		require.NoError(t, links.Delete(domain.WithLinkDomain(acme, "go.acme.com"), "abc"))
+		_, err := links.GetOriginalURL(domain.WithLinkDomain(acme, "go.acme.com"), "abc")
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+
+		_, err = links.GetOriginalURL(domain.WithLinkDomain(acme, "link.acme.io"), "abc")
+		assert.NoError(t, err)
+	})
+}
+
+// countingDomains counts the lookups of Resolve.
+type countingDomains struct {
+	*mock.MockDomainRepo
+	gets int
+}
+
+func (c *countingDomains) Get(ctx context.Context, name string) (domain.CustomDomain, error) {
+	c.gets++
+	return c.MockDomainRepo.Get(ctx, name)
+}
+
+func TestDomainResolveCache(t *testing.T) {
+	ctx := context.Background()
+	repo := &countingDomains{MockDomainRepo: mock.NewMockDomainRepo()}
+	domains := services.NewDomainService(repo, mock.NewMockTXTResolver())
+
+	for _, host := range []string{"", "localhost:8080", "10.0.0.1", "[::1]:443", "bad_host.example.com", "no spaces.example.com"} {
+		_, _, ok, err := domains.Resolve(ctx, host)
+		require.NoError(t, err)
+		assert.False(t, ok, host)
+	}
+	assert.Zero(t, repo.gets, "hosts that cannot be domains are not looked up")
+
+	for range 3 {
+		_, _, ok, err := domains.Resolve(ctx, "unknown.example.com")
+		require.NoError(t, err)
+		assert.False(t, ok)
+	}
+	assert.Equal(t, 1, repo.gets, "misses are cached")
+}
@@ -2,11 +2,13 @@ package unit
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/itsbaivab/url-shortener/internal/adapters/cache"
 	"github.com/itsbaivab/url-shortener/internal/adapters/handlers"
+	"github.com/itsbaivab/url-shortener/internal/adapters/problem"
 	"github.com/itsbaivab/url-shortener/internal/core/services"
 	"github.com/itsbaivab/url-shortener/internal/tests/mock"
 	"github.com/stretchr/testify/assert"
@@ -19,27 +21,35 @@ func TestGenerateLinkUnit(t *testing.T) {
 	FillCache(cache, mockLinkRepo.Links)
 	linkService := services.NewLinkService(mockLinkRepo, cache)
 	statsService := services.NewStatsService(mockStats, cache)
-	apiHandler := handlers.NewGenerateLinkFunctionHandler(linkService, statsService)
+	resolver := mock.NewMockIPResolver()
+	resolver.Add("example.com", "93.184.215.14")
+	destinations := services.NewDestinationValidator(resolver)
+	apiHandler := handlers.NewGenerateLinkFunctionHandler(linkService, statsService, destinations)
 
 	tests := []struct {
 		longURL            string
 		expectedStatusCode int
-		expectedBody       string
+		expectedDetail     string
 	}{
 		{
 			longURL:            "https://example.com/link1",
 			expectedStatusCode: 200,
-			expectedBody:       "",
+			expectedDetail:     "",
 		},
 		{
 			longURL:            "",
 			expectedStatusCode: 400,
-			expectedBody:       `{"error": "URL cannot be empty"}`,
+			expectedDetail:     "URL cannot be empty",
+		},
+		{
+			longURL:            "http://169.254.169.254/latest/meta-data/",
+			expectedStatusCode: 400,
+			expectedDetail:     `host "169.254.169.254" resolves to a non-public address`,
 		},
 		{
 			longURL:            "invalid",
 			expectedStatusCode: 400,
-			expectedBody:       `{"error": "URL must be at least 15 characters long"}`,
+			expectedDetail:     "URL must be an absolute http or https URL",
 		},
 	}
 
@@ -53,7 +63,10 @@ func TestGenerateLinkUnit(t *testing.T) {
 			assert.Equal(t, tt.expectedStatusCode, response.StatusCode)
 
 			if tt.expectedStatusCode != 200 {
-				assert.Equal(t, tt.expectedBody, response.Body)
+				var body problem.Problem
+				assert.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+				assert.Equal(t, tt.expectedDetail, body.Detail)
+				assert.Equal(t, problem.ContentType, response.Headers["Content-Type"])
 			}
 		})
 	}
@@ -0,0 +1,160 @@
+package unit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/itsbaivab/url-shortener/internal/adapters/geoip"
+	"github.com/itsbaivab/url-shortener/internal/adapters/handlers"
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/itsbaivab/url-shortener/internal/core/services"
+	"github.com/itsbaivab/url-shortener/internal/tests/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// geoDatabase holds ranges in the DB-IP format and, for 198.51.100.0/24,
+// in the IP2Location one.
+const geoDatabase = `203.0.113.0,203.0.113.127,DE
+203.0.113.128,203.0.113.255,FR
+2001:db8::,2001:db8::ffff,JP
+"3325256704","3325256959","US","United States of America"
+192.0.2.0,192.0.2.255,-
+`
+
+func TestGeoIPDatabase(t *testing.T) {
+	db, err := geoip.Read(strings.NewReader(geoDatabase))
+	require.NoError(t, err)
+	assert.Equal(t, 4, db.Len(), "unassigned ranges are skipped")
+
+	for ip, want := range map[string]string{
+		"203.0.113.7":        "DE",
+		"203.0.113.127":      "DE",
+		"203.0.113.128":      "FR",
+		"::ffff:203.0.113.9": "DE",
+		"2001:db8::42":       "JP",
+		"198.51.100.23":      "US",
+		"192.0.2.1":          "",
+		"10.0.0.1":           "",
+		"not an ip":          "",
+	} {
+		assert.Equal(t, want, db.Country(ip), ip)
+	}
+	assert.Empty(t, (&geoip.Database{}).Country("203.0.113.7"))
+
+	_, err = geoip.Read(strings.NewReader("203.0.113.9,203.0.113.1,DE\n"))
+	assert.ErrorContains(t, err, "line 1")
+	_, err = geoip.Read(strings.NewReader("bogus,203.0.113.1,DE\n"))
+	assert.ErrorContains(t, err, "invalid address")
+
+	path := filepath.Join(t.TempDir(), "country.csv")
+	require.NoError(t, os.WriteFile(path, []byte(geoDatabase), 0o600))
+	opened, err := geoip.Open(path)
+	require.NoError(t, err)
+	assert.Equal(t, "FR", opened.Country("203.0.113.200"))
+	_, err = geoip.Open(filepath.Join(t.TempDir(), "missing.csv"))
+	assert.Error(t, err)
+}
+
+func TestGeoRules(t *testing.T) {
+	ctx := domain.WithWorkspace(context.Background(), "acme")
+	geo, err := geoip.Read(strings.NewReader(geoDatabase))
+	require.NoError(t, err)
+	rules := []domain.GeoRule{
+		{Countries: []string{"DE", "AT", "CH"}, URL: "https://shop.example.com/de"},
+		{Regions: []string{"europe"}, URL: "https://shop.example.com/eu"},
+		{Countries: []string{"US"}, Regions: []string{"asia"}, URL: "https://shop.example.com/intl"},
+	}
+
+	t.Run("The first matching rule wins", func(t *testing.T) {
+		target := domain.Target{URL: "https://shop.example.com/", GeoRules: rules}
+		for _, tt := range []struct {
+			country string
+			url     string
+			rule    int
+		}{
+			{"DE", "https://shop.example.com/de", 0},
+			{"FR", "https://shop.example.com/eu", 1},
+			{"JP", "https://shop.example.com/intl", 2},
+			{"BR", "https://shop.example.com/", -1},
+			{"", "https://shop.example.com/", -1},
+		} {
+			located, rule := target.Locate(tt.country)
+			assert.Equal(t, tt.url, located.URL, tt.country)
+			assert.Equal(t, tt.rule, rule, tt.country)
+			assert.Empty(t, located.GeoRules)
+		}
+	})
+
+	t.Run("Device rules come before geo rules and variants after", func(t *testing.T) {
+		target := domain.Target{
+			URL:      "https://shop.example.com/",
+			Rules:    []domain.RoutingRule{{Device: domain.MatchIOS, URL: "https://apps.apple.com/app/id1"}},
+			GeoRules: rules,
+			Variants: []domain.Variant{{ID: "a", URL: "https://shop.example.com/a", Weight: 1}, {ID: "b", URL: "https://shop.example.com/b", Weight: 1}},
+		}
+		choice := target.Choose(domain.NewVisitor("shop", "203.0.113.7", "DE", iPhoneUA, ""))
+		assert.Equal(t, "https://apps.apple.com/app/id1", choice.Target.URL)
+		assert.Equal(t, domain.MatchIOS, choice.Rule)
+		assert.Equal(t, -1, choice.GeoRule)
+
+		choice = target.Choose(domain.NewVisitor("shop", "203.0.113.7", "DE", windowsUA, ""))
+		assert.Equal(t, "https://shop.example.com/de", choice.Target.URL)
+		assert.Equal(t, 0, choice.GeoRule)
+		assert.Empty(t, choice.Variant)
+
+		choice = target.Choose(domain.NewVisitor("shop", "198.51.100.1", "BR", windowsUA, ""))
+		assert.Equal(t, -1, choice.GeoRule)
+		assert.NotEmpty(t, choice.Variant)
+		assert.Equal(t, "https://shop.example.com/"+choice.Variant, choice.Target.URL)
+	})
+
+	t.Run("Rules are validated", func(t *testing.T) {
+		links := services.NewLinkService(mock.NewMockLinkRepo(), mock.NewMockRedisCache())
+		for name, rules := range map[string][]domain.GeoRule{
+			"no countries":   {{URL: "https://example.com/"}},
+			"lowercase code": {{Countries: []string{"de"}, URL: "https://example.com/"}},
+			"unknown region": {{Regions: []string{"atlantis"}, URL: "https://example.com/"}},
+			"no URL":         {{Countries: []string{"DE"}}},
+		} {
+			err := links.Create(ctx, domain.Link{Id: "shop", OriginalURL: "https://example.com/", CreatedAt: time.Now(), GeoRules: rules})
+			assert.ErrorIs(t, err, domain.ErrValidation, name)
+		}
+	})
+
+	links := services.NewLinkService(mock.NewMockLinkRepo(), mock.NewMockRedisCache())
+	require.NoError(t, links.Create(context.Background(), domain.Link{Id: "shop", OriginalURL: "https://shop.example.com/", CreatedAt: time.Now(), GeoRules: rules}))
+
+	t.Run("Redirects locate visitors", func(t *testing.T) {
+		handler := handlers.NewRedirectFunctionHandler(links, services.NewStatsService(mock.NewMockStatsRepo(), mock.NewMockRedisCache())).WithGeoIP(geo)
+		for ip, want := range map[string]string{
+			"203.0.113.7":   "https://shop.example.com/de",
+			"203.0.113.200": "https://shop.example.com/eu",
+			"198.51.100.23": "https://shop.example.com/intl",
+			"10.0.0.1":      "https://shop.example.com/",
+		} {
+			request := events.APIGatewayV2HTTPRequest{RawPath: "/shop"}
+			request.RequestContext.HTTP.SourceIP = ip
+			response, err := handler.Redirect(context.Background(), request)
+			require.NoError(t, err)
+			assert.Equal(t, want, response.Headers["Location"], ip)
+			assert.Equal(t, "private", response.Headers["Cache-Control"])
+		}
+	})
+
+	t.Run("Dry runs report the choice", func(t *testing.T) {
+		visitor := domain.NewVisitor("shop", "203.0.113.200", geo.Country("203.0.113.200"), windowsUA, "")
+		choice, location, err := links.DryRun(context.Background(), "shop", visitor)
+		require.NoError(t, err)
+		assert.Equal(t, "https://shop.example.com/eu", location)
+		assert.Equal(t, 1, choice.GeoRule)
+
+		_, _, err = links.DryRun(context.Background(), "missing", visitor)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+}
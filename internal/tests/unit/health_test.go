@@ -0,0 +1,54 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/itsbaivab/url-shortener/internal/health"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthRegistry(t *testing.T) {
+	t.Run("Reports each dependency", func(t *testing.T) {
+		registry := health.NewRegistry(time.Second, 0)
+		registry.Register("postgres", health.CheckerFunc(func(context.Context) error { return nil }))
+		registry.Register("redis", health.CheckerFunc(func(context.Context) error { return errors.New("connection refused") }))
+
+		report := registry.Run(context.Background())
+
+		assert.False(t, report.Healthy())
+		assert.Equal(t, health.StatusOK, report.Checks["postgres"].Status)
+		assert.Equal(t, health.StatusFail, report.Checks["redis"].Status)
+		assert.Equal(t, "connection refused", report.Checks["redis"].Error)
+	})
+
+	t.Run("Times out slow checks", func(t *testing.T) {
+		registry := health.NewRegistry(10*time.Millisecond, 0)
+		registry.Register("stuck", health.CheckerFunc(func(context.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		}))
+
+		start := time.Now()
+		report := registry.Run(context.Background())
+
+		assert.Less(t, time.Since(start), 500*time.Millisecond)
+		assert.Equal(t, health.StatusFail, report.Checks["stuck"].Status)
+	})
+
+	t.Run("Caches results", func(t *testing.T) {
+		var calls atomic.Int32
+		registry := health.NewRegistry(time.Second, time.Minute)
+		registry.Register("postgres", health.CheckerFunc(func(context.Context) error {
+			calls.Add(1)
+			return nil
+		}))
+
+		assert.True(t, registry.Run(context.Background()).Healthy())
+		assert.True(t, registry.Run(context.Background()).Healthy())
+		assert.Equal(t, int32(1), calls.Load())
+	})
+}
@@ -0,0 +1,284 @@
+package unit
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/itsbaivab/url-shortener/internal/auth"
+	"github.com/itsbaivab/url-shortener/internal/config"
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type signingKey struct {
+	kid string
+	key *ecdsa.PrivateKey
+}
+
+func newSigningKey(t *testing.T, kid string) signingKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return signingKey{kid: kid, key: key}
+}
+
+func (k signingKey) sign(t *testing.T, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	token.Header["kid"] = k.kid
+	signed, err := token.SignedString(k.key)
+	require.NoError(t, err)
+	return signed
+}
+
+func writeJWKS(t *testing.T, path string, keys ...signingKey) {
+	encode := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+	var doc struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	for _, k := range keys {
+		doc.Keys = append(doc.Keys, map[string]string{
+			"kid": k.kid,
+			"kty": "EC",
+			"use": "sig",
+			"crv": "P-256",
+			"x":   encode(k.key.X.FillBytes(make([]byte, 32))),
+			"y":   encode(k.key.Y.FillBytes(make([]byte, 32))),
+		})
+	}
+	data, err := json.Marshal(doc)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+}
+
+func TestJWTVerifier(t *testing.T) {
+	ctx := context.Background()
+	file := filepath.Join(t.TempDir(), "jwks.json")
+	current := newSigningKey(t, "2024-01")
+	writeJWKS(t, file, current)
+
+	cfg := config.JWTConfig{
+		Issuer:         "https://sso.example.com",
+		Audience:       "url-shortener",
+		JWKSFile:       file,
+		JWKSRefresh:    time.Hour,
+		OwnerClaims:    []string{"team", "sub"},
+		WorkspaceClaim: "workspace",
+	}
+	keys := auth.NewJWKS("", file, cfg.JWKSRefresh)
+	verifier := auth.NewJWTVerifierWithKeys(cfg, keys)
+
+	claims := func(overrides jwt.MapClaims) jwt.MapClaims {
+		c := jwt.MapClaims{
+			"iss":   cfg.Issuer,
+			"aud":   cfg.Audience,
+			"sub":   "alice",
+			"team":  "growth",
+			"scope": "links:read links:write",
+			"iat":   time.Now().Unix(),
+			"exp":   time.Now().Add(time.Hour).Unix(),
+		}
+		for k, v := range overrides {
+			if v == nil {
+				delete(c, k)
+			} else {
+				c[k] = v
+			}
+		}
+		return c
+	}
+
+	t.Run("Valid tokens map to a principal", func(t *testing.T) {
+		token := current.sign(t, claims(nil))
+		require.True(t, verifier.Accepts(token))
+
+		principal, err := verifier.Authenticate(ctx, token)
+		require.NoError(t, err)
+		assert.Equal(t, "growth", principal.OwnerID)
+		assert.Equal(t, "alice", principal.Subject)
+		assert.True(t, principal.HasScope(domain.ScopeLinksWrite))
+		assert.False(t, principal.HasScope(domain.ScopeStatsRead))
+	})
+
+	t.Run("Owner falls back to later claims", func(t *testing.T) {
+		principal, err := verifier.Authenticate(ctx, current.sign(t, claims(jwt.MapClaims{"team": nil})))
+		require.NoError(t, err)
+		assert.Equal(t, "alice", principal.OwnerID)
+	})
+
+	t.Run("Workspace claim", func(t *testing.T) {
+		principal, err := verifier.Authenticate(ctx, current.sign(t, claims(jwt.MapClaims{"workspace": "acme"})))
+		require.NoError(t, err)
+		assert.Equal(t, "acme", principal.WorkspaceID)
+
+		principal, err = verifier.Authenticate(ctx, current.sign(t, claims(nil)))
+		require.NoError(t, err)
+		assert.Equal(t, domain.DefaultWorkspaceID, principal.WorkspaceID)
+	})
+
+	t.Run("scp array claim", func(t *testing.T) {
+		token := current.sign(t, claims(jwt.MapClaims{"scope": nil, "scp": []string{"stats:read"}}))
+		principal, err := verifier.Authenticate(ctx, token)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"stats:read"}, principal.Scopes)
+	})
+
+	rejected := map[string]string{
+		"Expired":        current.sign(t, claims(jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()})),
+		"No expiry":      current.sign(t, claims(jwt.MapClaims{"exp": nil})),
+		"Wrong issuer":   current.sign(t, claims(jwt.MapClaims{"iss": "https://evil.example.com"})),
+		"Wrong audience": current.sign(t, claims(jwt.MapClaims{"aud": "another-app"})),
+		"No owner":       current.sign(t, claims(jwt.MapClaims{"sub": nil, "team": nil})),
+		"Unknown key":    newSigningKey(t, "stolen").sign(t, claims(nil)),
+		"Forged key":     signingKey{kid: current.kid, key: newSigningKey(t, "").key}.sign(t, claims(nil)),
+	}
+	for name, token := range rejected {
+		t.Run(name+" tokens are rejected", func(t *testing.T) {
+			_, err := verifier.Authenticate(ctx, token)
+			assert.ErrorIs(t, err, domain.ErrUnauthorized)
+		})
+	}
+
+	t.Run("Unsigned tokens are rejected", func(t *testing.T) {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodNone, claims(nil)).SignedString(jwt.UnsafeAllowNoneSignatureType)
+		require.NoError(t, err)
+		_, err = verifier.Authenticate(ctx, token)
+		assert.ErrorIs(t, err, domain.ErrUnauthorized)
+	})
+
+	t.Run("Rotated keys are picked up", func(t *testing.T) {
+		next := newSigningKey(t, "2024-02")
+		writeJWKS(t, file, next)
+		require.NoError(t, keys.Refresh(ctx))
+
+		_, err := verifier.Authenticate(ctx, next.sign(t, claims(nil)))
+		assert.NoError(t, err)
+		_, err = verifier.Authenticate(ctx, current.sign(t, claims(nil)))
+		assert.ErrorIs(t, err, domain.ErrUnauthorized)
+	})
+
+	t.Run("Failed refresh keeps cached keys", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(file, []byte("not json"), 0o600))
+		assert.Error(t, keys.Refresh(ctx))
+		_, err := keys.Key(ctx, "2024-02")
+		assert.NoError(t, err)
+	})
+}
+
+func TestJWKSRefresh(t *testing.T) {
+	ctx := context.Background()
+	file := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, file, newSigningKey(t, "k1"))
+	doc, err := os.ReadFile(file)
+	require.NoError(t, err)
+
+	var fetches atomic.Int32
+	var down atomic.Bool
+	release := make(chan struct{})
+	var slow atomic.Pointer[chan struct{}]
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		if wait := slow.Load(); wait != nil {
+			<-*wait
+		}
+		if down.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(doc)
+	}))
+	defer server.Close()
+
+	t.Run("Failed loads are not retried on every request", func(t *testing.T) {
+		down.Store(true)
+		defer down.Store(false)
+		keys := auth.NewJWKS(server.URL, "", time.Hour)
+		for range 5 {
+			_, err := keys.Key(ctx, "k1")
+			assert.Error(t, err)
+		}
+		assert.EqualValues(t, 1, fetches.Load())
+	})
+
+	t.Run("Cached keys are served while a refresh is in flight", func(t *testing.T) {
+		fetches.Store(0)
+		keys := auth.NewJWKS(server.URL, "", 50*time.Millisecond)
+		_, err := keys.Key(ctx, "k1")
+		require.NoError(t, err)
+
+		time.Sleep(60 * time.Millisecond)
+		slow.Store(&release)
+		for range 5 {
+			_, err := keys.Key(ctx, "k1")
+			assert.NoError(t, err)
+		}
+		assert.Eventually(t, func() bool { return fetches.Load() == 2 }, time.Second, 5*time.Millisecond)
+		time.Sleep(20 * time.Millisecond)
+		assert.EqualValues(t, 2, fetches.Load(), "stale keys are refreshed once")
+		close(release)
+	})
+}
+
+func TestJWTMiddlewareScopes(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "jwks.json")
+	key := newSigningKey(t, "k1")
+	writeJWKS(t, file, key)
+
+	cfg := config.JWTConfig{Issuer: "sso", JWKSFile: file, JWKSRefresh: time.Hour, OwnerClaims: []string{"sub"}}
+	verifier := auth.NewJWTVerifier(cfg)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	api := router.Group("", auth.Middleware(verifier))
+	api.PUT("/generate", auth.RequireScope(domain.ScopeLinksWrite), func(c *gin.Context) {
+		p, _ := domain.PrincipalFrom(c.Request.Context())
+		c.String(http.StatusOK, p.OwnerID)
+	})
+	api.GET("/stats", auth.RequireScope(domain.ScopeStatsRead), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	do := func(method, path, scope string) *httptest.ResponseRecorder {
+		token := key.sign(t, jwt.MapClaims{
+			"iss":   "sso",
+			"sub":   "bob",
+			"scope": scope,
+			"exp":   time.Now().Add(time.Minute).Unix(),
+		})
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPut, "/generate", "links:write")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "bob", w.Body.String())
+
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPut, "/generate", "links:read").Code)
+	assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/stats", "links:write").Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/stats", "stats:read").Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/stats", "admin").Code)
+
+	t.Run("API keys are not mistaken for JWTs", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/stats", nil)
+		req.Header.Set(auth.APIKeyHeader, "usk_abc_def")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
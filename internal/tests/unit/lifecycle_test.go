@@ -0,0 +1,108 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/itsbaivab/url-shortener/internal/server"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLifecycle(t *testing.T) {
+	recorder := func(events *[]string, name string, startErr error) server.Component {
+		return server.Component{
+			Name: name,
+			Start: func(context.Context) error {
+				*events = append(*events, "start "+name)
+				return startErr
+			},
+			Stop: func(context.Context) error {
+				*events = append(*events, "stop "+name)
+				return nil
+			},
+		}
+	}
+
+	t.Run("Starts in order and stops in reverse", func(t *testing.T) {
+		var events []string
+		l := server.NewLifecycle()
+		l.Register(recorder(&events, "db", nil))
+		l.Register(recorder(&events, "cache", nil))
+		l.Register(recorder(&events, "worker", nil))
+
+		assert.False(t, l.Ready())
+		assert.NoError(t, l.Start(context.Background()))
+		assert.True(t, l.Ready())
+
+		assert.NoError(t, l.Stop(context.Background()))
+		assert.False(t, l.Ready())
+		assert.Equal(t, []string{
+			"start db", "start cache", "start worker",
+			"stop worker", "stop cache", "stop db",
+		}, events)
+	})
+
+	t.Run("Failed start stops what already started", func(t *testing.T) {
+		var events []string
+		l := server.NewLifecycle()
+		l.Register(recorder(&events, "db", nil))
+		l.Register(recorder(&events, "broken", errors.New("boom")))
+		l.Register(recorder(&events, "never", nil))
+
+		assert.ErrorContains(t, l.Start(context.Background()), "failed to start broken")
+		assert.False(t, l.Ready())
+		assert.Equal(t, []string{"start db", "start broken", "stop db"}, events)
+	})
+
+	t.Run("Stop waits for background workers", func(t *testing.T) {
+		var finished atomic.Bool
+		l := server.NewLifecycle()
+		l.Register(server.Component{
+			Name: "db",
+			Stop: func(context.Context) error {
+				assert.True(t, finished.Load(), "workers must finish before components stop")
+				return nil
+			},
+		})
+		assert.NoError(t, l.Start(context.Background()))
+
+		l.Go(func(ctx context.Context) {
+			time.Sleep(20 * time.Millisecond)
+			finished.Store(true)
+		})
+
+		assert.NoError(t, l.Stop(context.Background()))
+		assert.True(t, finished.Load())
+	})
+
+	t.Run("Stop cancels workers that outlive the deadline", func(t *testing.T) {
+		l := server.NewLifecycle()
+		assert.NoError(t, l.Start(context.Background()))
+
+		l.Go(func(ctx context.Context) {
+			<-ctx.Done()
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.NoError(t, l.Stop(ctx))
+	})
+	t.Run("Periodic tasks run until stopped", func(t *testing.T) {
+		var runs atomic.Int32
+		l := server.NewLifecycle()
+		l.Register(server.Every("tick", time.Millisecond, func(ctx context.Context) error {
+			runs.Add(1)
+			return errors.New("failures are retried")
+		}))
+		assert.NoError(t, l.Start(context.Background()))
+
+		assert.Eventually(t, func() bool { return runs.Load() >= 3 }, time.Second, time.Millisecond)
+		assert.NoError(t, l.Stop(context.Background()))
+		stopped := runs.Load()
+		time.Sleep(5 * time.Millisecond)
+		assert.Equal(t, stopped, runs.Load())
+	})
+}
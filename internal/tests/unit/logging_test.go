@@ -0,0 +1,103 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/itsbaivab/url-shortener/internal/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogging(t *testing.T) {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(logging.NewLogger(&buf))
+	t.Cleanup(func() {
+		slog.SetDefault(previous)
+		require.NoError(t, logging.SetLevel("info"))
+	})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(logging.RequestID(), logging.AccessLog())
+	router.GET("/redirect/:id", func(c *gin.Context) {
+		logging.Annotate(c, "link_id", c.Param("id"))
+		c.Status(http.StatusMovedPermanently)
+	})
+	router.PUT("/debug/log-level", logging.LevelHandler)
+
+	lines := func() []map[string]any {
+		var out []map[string]any
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			if line == "" {
+				continue
+			}
+			var entry map[string]any
+			require.NoError(t, json.Unmarshal([]byte(line), &entry))
+			out = append(out, entry)
+		}
+		buf.Reset()
+		return out
+	}
+
+	t.Run("Reuses the caller's request ID", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/redirect/abc", nil)
+		req.Header.Set(logging.RequestIDHeader, "req-123")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, "req-123", w.Header().Get(logging.RequestIDHeader))
+		entries := lines()
+		require.Len(t, entries, 1)
+		assert.Equal(t, "req-123", entries[0]["request_id"])
+		assert.Equal(t, "abc", entries[0]["link_id"])
+		assert.Equal(t, "/redirect/:id", entries[0]["route"])
+	})
+
+	t.Run("Creates a request ID when missing", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/redirect/abc", nil))
+
+		id := w.Header().Get(logging.RequestIDHeader)
+		assert.NotEmpty(t, id)
+		assert.Equal(t, id, lines()[0]["request_id"])
+	})
+
+	t.Run("Context attributes reach service logs", func(t *testing.T) {
+		ctx := logging.With(context.Background(), "request_id", "req-1", "link_id", "abc")
+		slog.WarnContext(ctx, "cache lookup failed")
+
+		entry := lines()[0]
+		assert.Equal(t, "req-1", entry["request_id"])
+		assert.Equal(t, "abc", entry["link_id"])
+		assert.Equal(t, "WARN", entry["level"])
+	})
+
+	t.Run("Level changes at runtime", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/debug/log-level", strings.NewReader(`{"level":"error"}`)))
+		require.Equal(t, http.StatusOK, w.Code)
+		buf.Reset()
+
+		slog.Info("hidden")
+		slog.Error("shown")
+
+		entries := lines()
+		require.Len(t, entries, 1)
+		assert.Equal(t, "shown", entries[0]["msg"])
+	})
+
+	t.Run("Rejects unknown levels", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/debug/log-level", strings.NewReader(`{"level":"loud"}`)))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,94 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/itsbaivab/url-shortener/internal/adapters/metrics"
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/itsbaivab/url-shortener/internal/core/services"
+	"github.com/itsbaivab/url-shortener/internal/tests/mock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTopN(t *testing.T) {
+	t.Run("Keeps the most frequent keys", func(t *testing.T) {
+		top := metrics.NewTopN(prometheus.NewDesc("clicks", "", []string{"link_id"}, nil), 2)
+		for i := 0; i < 100; i++ {
+			top.Inc("popular")
+			if i%2 == 0 {
+				top.Inc("steady")
+			}
+		}
+		for i := 0; i < 1000; i++ {
+			top.Inc(fmt.Sprintf("once-%d", i))
+		}
+		for i := 0; i < 100; i++ {
+			top.Inc("popular")
+		}
+
+		got := top.Top()
+
+		require.Len(t, got, 2)
+		assert.Equal(t, "popular", got[0].Key)
+		assert.GreaterOrEqual(t, got[0].Count, uint64(200))
+	})
+
+	t.Run("Exports at most n series", func(t *testing.T) {
+		top := metrics.NewTopN(prometheus.NewDesc("clicks", "", []string{"link_id"}, nil), 3)
+		for i := 0; i < 50; i++ {
+			top.Inc(fmt.Sprintf("link-%d", i))
+		}
+
+		assert.Equal(t, 3, testutil.CollectAndCount(top))
+	})
+}
+
+func TestPrometheusMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := metrics.NewPrometheus(reg, 10)
+
+	linkRepo := mock.NewMockLinkRepo()
+	cache := mock.NewMockRedisCache()
+	service := services.NewLinkService(linkRepo, cache).WithMetrics(m)
+	ctx := context.Background()
+
+	require.NoError(t, service.Create(ctx, domain.Link{Id: "abc", OriginalURL: "https://example.com", CreatedAt: time.Now()}))
+
+	_, err := service.GetOriginalURL(ctx, "abc") // miss, then cached
+	require.NoError(t, err)
+	_, err = service.GetOriginalURL(ctx, "abc") // hit
+	require.NoError(t, err)
+	_, err = service.GetOriginalURL(ctx, "missing")
+	require.ErrorIs(t, err, domain.ErrNotFound)
+
+	expected := `
+# HELP urlshortener_cache_lookups_total Link cache lookups by result (hit, miss). The hit ratio is hit / (hit + miss).
+# TYPE urlshortener_cache_lookups_total counter
+urlshortener_cache_lookups_total{result="hit"} 1
+urlshortener_cache_lookups_total{result="miss"} 2
+# HELP urlshortener_link_clicks Clicks of the most clicked links since the process started.
+# TYPE urlshortener_link_clicks gauge
+urlshortener_link_clicks{link_id="abc"} 2
+# HELP urlshortener_links_created_total Short links created.
+# TYPE urlshortener_links_created_total counter
+urlshortener_links_created_total 1
+# HELP urlshortener_redirects_total Short link resolutions by result (hit, miss, not_found, expired).
+# TYPE urlshortener_redirects_total counter
+urlshortener_redirects_total{result="hit"} 1
+urlshortener_redirects_total{result="miss"} 1
+urlshortener_redirects_total{result="not_found"} 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected),
+		"urlshortener_cache_lookups_total",
+		"urlshortener_link_clicks",
+		"urlshortener_links_created_total",
+		"urlshortener_redirects_total",
+	))
+}
@@ -0,0 +1,50 @@
+package unit
+
+import (
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/itsbaivab/url-shortener/internal/adapters/repository/postgres"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	t.Run("Embedded migrations are complete", func(t *testing.T) {
+		migrations, err := postgres.LoadMigrations(os.DirFS("../../adapters/repository/postgres"))
+		assert.NoError(t, err)
+		assert.NotEmpty(t, migrations)
+		for i, m := range migrations {
+			assert.Equal(t, i+1, m.Version, "migration versions must be contiguous")
+		}
+	})
+
+	t.Run("Orders by version", func(t *testing.T) {
+		migrations, err := postgres.LoadMigrations(fstest.MapFS{
+			"migrations/0002_second.up.sql":   {Data: []byte("SELECT 2")},
+			"migrations/0002_second.down.sql": {Data: []byte("SELECT -2")},
+			"migrations/0001_first.up.sql":    {Data: []byte("SELECT 1")},
+			"migrations/0001_first.down.sql":  {Data: []byte("SELECT -1")},
+		})
+		assert.NoError(t, err)
+		assert.Len(t, migrations, 2)
+		assert.Equal(t, "first", migrations[0].Name)
+		assert.Equal(t, "SELECT 2", migrations[1].Up)
+		assert.Equal(t, "SELECT -2", migrations[1].Down)
+	})
+
+	t.Run("Requires a down script", func(t *testing.T) {
+		_, err := postgres.LoadMigrations(fstest.MapFS{
+			"migrations/0001_first.up.sql": {Data: []byte("SELECT 1")},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("Rejects malformed names", func(t *testing.T) {
+		_, err := postgres.LoadMigrations(fstest.MapFS{
+			"migrations/first.up.sql":   {Data: []byte("SELECT 1")},
+			"migrations/first.down.sql": {Data: []byte("SELECT -1")},
+		})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,127 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/itsbaivab/url-shortener/internal/adapters/cache"
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/itsbaivab/url-shortener/internal/core/services"
+	"github.com/itsbaivab/url-shortener/internal/tests/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordProtectedLinks(t *testing.T) {
+	ctx := domain.WithWorkspace(context.Background(), "acme")
+	secret := []byte("0123456789abcdef0123456789abcdef")
+
+	hash, err := services.HashPassword("open sesame")
+	require.NoError(t, err)
+	repo := mock.NewMockLinkRepo()
+	linkCache := mock.NewMockRedisCache()
+	links := services.NewLinkService(repo, linkCache)
+	require.NoError(t, links.Create(ctx, domain.Link{Id: "docs", OriginalURL: "https://intranet.example.com/docs", CreatedAt: time.Now(), PasswordHash: hash}))
+	require.NoError(t, links.Create(ctx, domain.Link{Id: "wiki", OriginalURL: "https://intranet.example.com/wiki", CreatedAt: time.Now(), PasswordHash: hash}))
+	require.NoError(t, links.Create(ctx, domain.Link{Id: "public", OriginalURL: "https://example.com/", CreatedAt: time.Now()}))
+
+	passwords := services.NewPasswordService(links, secret).
+		WithAttemptLimit(cache.NewMemoryLimiter(), domain.RateLimit{Requests: 3, Window: time.Hour}, domain.RateLimit{Requests: 5, Window: time.Hour})
+
+	t.Run("Passwords are validated and hashed", func(t *testing.T) {
+		_, err := services.HashPassword("short")
+		assert.ErrorIs(t, err, domain.ErrValidation)
+		assert.NotContains(t, hash, "open sesame")
+
+		data, err := json.Marshal(domain.Link{Id: "docs", PasswordHash: hash})
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `"password_protected":true`)
+		assert.NotContains(t, string(data), hash)
+	})
+
+	t.Run("Protected links do not redirect without the password", func(t *testing.T) {
+		_, err := links.GetOriginalURL(ctx, "docs")
+		var locked *domain.PasswordRequiredError
+		require.ErrorAs(t, err, &locked)
+		assert.False(t, locked.Rejected)
+
+		_, public, err := passwords.Resolve(ctx, "docs", "")
+		assert.False(t, public)
+		assert.ErrorIs(t, err, domain.ErrPasswordRequired)
+
+		cached, _ := linkCache.Get(ctx, "acme:docs")
+		assert.Empty(t, cached, "protected links are never cached")
+	})
+
+	t.Run("Wrong passwords are rejected", func(t *testing.T) {
+		_, access, err := passwords.Unlock(ctx, "docs", "letmein", "203.0.113.7")
+		var locked *domain.PasswordRequiredError
+		require.ErrorAs(t, err, &locked)
+		assert.True(t, locked.Rejected)
+		assert.Empty(t, access.Token)
+	})
+
+	t.Run("The password grants access for a while", func(t *testing.T) {
+		target, access, err := passwords.Unlock(ctx, "docs", "open sesame", "203.0.113.7")
+		require.NoError(t, err)
+		assert.Equal(t, "https://intranet.example.com/docs", target.URL)
+		assert.WithinDuration(t, time.Now().Add(domain.DefaultLinkAccessTTL), access.Expires, time.Second)
+
+		target, public, err := passwords.Resolve(ctx, "docs", access.Token)
+		require.NoError(t, err)
+		assert.False(t, public)
+		assert.Equal(t, "https://intranet.example.com/docs", target.URL)
+
+		_, _, err = passwords.Resolve(ctx, "wiki", access.Token)
+		assert.ErrorIs(t, err, domain.ErrPasswordRequired, "grants are only valid for their link")
+		_, _, err = passwords.Resolve(ctx, "docs", access.Token+"x")
+		assert.ErrorIs(t, err, domain.ErrPasswordRequired)
+		_, _, err = services.NewPasswordService(links, []byte("another secret of at least 32 bytes")).Resolve(ctx, "docs", access.Token)
+		assert.ErrorIs(t, err, domain.ErrPasswordRequired, "grants are only valid with the secret that signed them")
+	})
+
+	t.Run("Grants expire", func(t *testing.T) {
+		expired := services.NewPasswordService(links, secret).WithAccessTTL(-time.Minute)
+		_, access, err := expired.Unlock(ctx, "wiki", "open sesame", "203.0.113.7")
+		require.NoError(t, err)
+		_, _, err = passwords.Resolve(ctx, "wiki", access.Token)
+		assert.ErrorIs(t, err, domain.ErrPasswordRequired)
+	})
+
+	t.Run("Attempts are limited per link and IP", func(t *testing.T) {
+		for range 3 {
+			_, _, err := passwords.Unlock(ctx, "wiki", "guess", "198.51.100.1")
+			assert.ErrorIs(t, err, domain.ErrPasswordRequired)
+		}
+		_, _, err := passwords.Unlock(ctx, "wiki", "open sesame", "198.51.100.1")
+		assert.ErrorIs(t, err, domain.ErrRateLimited)
+
+		_, _, err = passwords.Unlock(ctx, "wiki", "open sesame", "198.51.100.2")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Attempts are limited per link across IPs", func(t *testing.T) {
+		// The wiki link has had 4 attempts counted from 198.51.100.1 and .2;
+		// the per-link budget of 5 leaves one.
+		_, _, err := passwords.Unlock(ctx, "wiki", "guess", "198.51.100.3")
+		assert.ErrorIs(t, err, domain.ErrPasswordRequired)
+		_, _, err = passwords.Unlock(ctx, "wiki", "open sesame", "198.51.100.4")
+		assert.ErrorIs(t, err, domain.ErrRateLimited)
+
+		_, _, err = passwords.Unlock(ctx, "docs", "open sesame", "198.51.100.4")
+		assert.NoError(t, err, "other links have their own budget")
+	})
+
+	t.Run("Public links are unaffected", func(t *testing.T) {
+		target, public, err := passwords.Resolve(ctx, "public", "")
+		require.NoError(t, err)
+		assert.True(t, public)
+		assert.Equal(t, "https://example.com/", target.URL)
+
+		_, access, err := passwords.Unlock(ctx, "public", "", "203.0.113.7")
+		require.NoError(t, err)
+		assert.Empty(t, access.Token)
+	})
+}
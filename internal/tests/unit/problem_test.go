@@ -0,0 +1,73 @@
+package unit
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/itsbaivab/url-shortener/internal/adapters/problem"
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProblemFromError(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		expectStatus int
+		expectDetail string
+	}{
+		{
+			name:         "not found",
+			err:          fmt.Errorf("failed to get link: %w", domain.ErrNotFound),
+			expectStatus: 404,
+			expectDetail: "failed to get link: not found",
+		},
+		{
+			name:         "conflict",
+			err:          fmt.Errorf("link %q: %w", "abc", domain.ErrConflict),
+			expectStatus: 409,
+			expectDetail: `link "abc": conflict`,
+		},
+		{
+			name:         "expired",
+			err:          domain.ErrExpired,
+			expectStatus: 410,
+			expectDetail: "expired",
+		},
+		{
+			name:         "password required",
+			err:          &domain.PasswordRequiredError{LinkID: "abc", Rejected: true},
+			expectStatus: 401,
+			expectDetail: `incorrect password for link "abc"`,
+		},
+		{
+			name:         "validation",
+			err:          fmt.Errorf("create: %w", &domain.ValidationError{Field: "long", Reason: "URL cannot be empty"}),
+			expectStatus: 400,
+			expectDetail: "URL cannot be empty",
+		},
+		{
+			name:         "unavailable hides backend detail",
+			err:          fmt.Errorf("failed to query links: %w: %w", domain.ErrUnavailable, errors.New("dial tcp: connection refused")),
+			expectStatus: 503,
+			expectDetail: "",
+		},
+		{
+			name:         "unknown",
+			err:          errors.New("boom"),
+			expectStatus: 500,
+			expectDetail: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := problem.FromError(tt.err)
+			assert.Equal(t, tt.expectStatus, p.Status)
+			assert.Equal(t, tt.expectDetail, p.Detail)
+			assert.NotEmpty(t, p.Type)
+			assert.NotEmpty(t, p.Title)
+		})
+	}
+}
@@ -0,0 +1,284 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/gin-gonic/gin"
+	"github.com/itsbaivab/url-shortener/internal/adapters/cache"
+	"github.com/itsbaivab/url-shortener/internal/adapters/handlers"
+	"github.com/itsbaivab/url-shortener/internal/auth"
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/itsbaivab/url-shortener/internal/core/services"
+	"github.com/itsbaivab/url-shortener/internal/ratelimit"
+	"github.com/itsbaivab/url-shortener/internal/server"
+	"github.com/itsbaivab/url-shortener/internal/tests/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryLimiter(t *testing.T) {
+	ctx := context.Background()
+	limiter := cache.NewMemoryLimiter()
+
+	t.Run("Buckets hold Requests tokens", func(t *testing.T) {
+		limit := domain.RateLimit{Requests: 3, Window: time.Hour}
+		for remaining := 2; remaining >= 0; remaining-- {
+			d, err := limiter.Take(ctx, "burst", limit)
+			require.NoError(t, err)
+			assert.True(t, d.Allowed)
+			assert.Equal(t, remaining, d.Remaining)
+		}
+
+		d, err := limiter.Take(ctx, "burst", limit)
+		require.NoError(t, err)
+		assert.False(t, d.Allowed)
+		assert.InDelta(t, 20*time.Minute, d.RetryAfter, float64(time.Second))
+		assert.InDelta(t, time.Hour, d.Reset, float64(time.Second))
+
+		d, err = limiter.Take(ctx, "other", limit)
+		require.NoError(t, err)
+		assert.True(t, d.Allowed, "buckets are per key")
+	})
+
+	t.Run("Buckets refill over the window", func(t *testing.T) {
+		limit := domain.RateLimit{Requests: 1, Window: 50 * time.Millisecond}
+		d, _ := limiter.Take(ctx, "refill", limit)
+		require.True(t, d.Allowed)
+		d, _ = limiter.Take(ctx, "refill", limit)
+		require.False(t, d.Allowed)
+
+		time.Sleep(60 * time.Millisecond)
+		d, _ = limiter.Take(ctx, "refill", limit)
+		assert.True(t, d.Allowed)
+	})
+
+	t.Run("Counters restart after they expire", func(t *testing.T) {
+		expireAt := time.Now().Add(50 * time.Millisecond)
+		for want := int64(1); want <= 2; want++ {
+			n, err := limiter.Increment(ctx, "daily", expireAt)
+			require.NoError(t, err)
+			assert.Equal(t, want, n)
+		}
+
+		time.Sleep(60 * time.Millisecond)
+		n, err := limiter.Increment(ctx, "daily", time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+	})
+}
+
+func TestRateLimitService(t *testing.T) {
+	ctx := context.Background()
+	perIP := domain.RateLimit{Requests: 2, Window: time.Hour}
+	perKey := domain.RateLimit{Requests: 5, Window: time.Hour}
+
+	t.Run("Keys and IPs have separate buckets", func(t *testing.T) {
+		limits := services.NewRateLimitService(cache.NewMemoryLimiter(), cache.NewMemoryLimiter()).WithLimits(perIP, perKey)
+		keyed := domain.WithPrincipal(ctx, domain.Principal{KeyID: "k1", OwnerID: "alice"})
+
+		d, ok, err := limits.Check(keyed, "10.0.0.1")
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, 1, d.Remaining, "the emptiest bucket is reported")
+
+		_, _, err = limits.Check(keyed, "10.0.0.1")
+		require.NoError(t, err)
+		d, _, err = limits.Check(keyed, "10.0.0.1")
+		assert.ErrorIs(t, err, domain.ErrRateLimited)
+		assert.False(t, d.Allowed)
+
+		_, _, err = limits.Check(keyed, "10.0.0.2")
+		assert.NoError(t, err, "the key has tokens left")
+	})
+
+	t.Run("Disabled limits are not checked", func(t *testing.T) {
+		limits := services.NewRateLimitService(cache.NewMemoryLimiter(), cache.NewMemoryLimiter())
+		_, ok, err := limits.Check(ctx, "10.0.0.1")
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("Local buckets are used while the shared limiter is down", func(t *testing.T) {
+		shared := &failingLimiter{MemoryLimiter: cache.NewMemoryLimiter(), down: true}
+		limits := services.NewRateLimitService(shared, cache.NewMemoryLimiter()).WithLimits(perIP, perKey)
+
+		for range 2 {
+			_, _, err := limits.Check(ctx, "10.0.0.1")
+			require.NoError(t, err)
+		}
+		_, _, err := limits.Check(ctx, "10.0.0.1")
+		assert.ErrorIs(t, err, domain.ErrRateLimited)
+
+		shared.down = false
+		_, _, err = limits.Check(ctx, "10.0.0.1")
+		assert.NoError(t, err, "the shared bucket is used again once it recovers")
+	})
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	limits := services.NewRateLimitService(cache.NewMemoryLimiter(), cache.NewMemoryLimiter()).
+		WithLimits(domain.RateLimit{Requests: 1, Window: time.Minute}, domain.RateLimit{})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/redirect", ratelimit.PerIP(limits), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	do := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/redirect", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "60", w.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, "1;w=60", w.Header().Get("RateLimit-Policy"))
+	assert.Empty(t, w.Header().Get("Retry-After"))
+
+	w = do()
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), "/problems/rate-limited")
+}
+
+func TestRateLimitAroundAuth(t *testing.T) {
+	keys := services.NewAPIKeyService(mock.NewMockAPIKeyRepo())
+	token, _, err := keys.Issue(context.Background(), "alice", "", nil)
+	require.NoError(t, err)
+	limits := services.NewRateLimitService(cache.NewMemoryLimiter(), cache.NewMemoryLimiter()).
+		WithLimits(domain.RateLimit{Requests: 3, Window: time.Minute}, domain.RateLimit{Requests: 1, Window: time.Minute})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/links", ratelimit.PerIP(limits), auth.Middleware(keys), ratelimit.PerKey(limits), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	do := func(peer, key string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/links", nil)
+		req.RemoteAddr = peer + ":1234"
+		req.Header.Set(auth.APIKeyHeader, key)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Wrong credentials count against the IP", func(t *testing.T) {
+		for range 3 {
+			assert.Equal(t, http.StatusUnauthorized, do("192.0.2.1", "usk_guess_guess").Code)
+		}
+		assert.Equal(t, http.StatusTooManyRequests, do("192.0.2.1", "usk_guess_guess").Code)
+		assert.Equal(t, http.StatusTooManyRequests, do("192.0.2.1", token).Code)
+	})
+
+	t.Run("Keys are limited after authentication", func(t *testing.T) {
+		w := do("192.0.2.2", token)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"), "the emptier bucket is reported")
+		assert.Equal(t, "1", w.Header().Get("RateLimit-Limit"))
+
+		assert.Equal(t, http.StatusTooManyRequests, do("192.0.2.3", token).Code)
+	})
+}
+
+func TestRateLimitClientIP(t *testing.T) {
+	limits := services.NewRateLimitService(cache.NewMemoryLimiter(), cache.NewMemoryLimiter()).
+		WithLimits(domain.RateLimit{Requests: 1, Window: time.Minute}, domain.RateLimit{})
+
+	gin.SetMode(gin.TestMode)
+	router, err := server.NewRouter([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+	router.GET("/redirect", ratelimit.PerIP(limits), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	do := func(peer, forwardedFor string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/redirect", nil)
+		req.RemoteAddr = peer + ":1234"
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	t.Run("Untrusted peers cannot pick their IP", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, do("203.0.113.5", "9.9.9.9"))
+		assert.Equal(t, http.StatusTooManyRequests, do("203.0.113.5", "9.9.9.10"))
+		assert.Equal(t, http.StatusTooManyRequests, do("203.0.113.5", "9.9.9.9, 10.0.0.5"))
+	})
+
+	t.Run("Trusted proxies forward the client IP", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, do("10.0.0.5", "198.51.100.1"))
+		assert.Equal(t, http.StatusOK, do("10.0.0.5", "198.51.100.2"))
+		assert.Equal(t, http.StatusTooManyRequests, do("10.0.0.6", "198.51.100.1"))
+	})
+
+	_, err = server.NewRouter([]string{"not a proxy"})
+	assert.Error(t, err)
+}
+
+func TestLambdaRateLimit(t *testing.T) {
+	limits := services.NewRateLimitService(cache.NewMemoryLimiter(), cache.NewMemoryLimiter()).
+		WithLimits(domain.RateLimit{Requests: 1, Window: time.Minute}, domain.RateLimit{})
+	handler := handlers.RateLimited(limits, func(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusMovedPermanently, Headers: map[string]string{"Location": "https://example.com"}}, nil
+	})
+
+	var req events.APIGatewayV2HTTPRequest
+	req.RequestContext.HTTP.SourceIP = "192.0.2.1"
+
+	resp, err := handler(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusMovedPermanently, resp.StatusCode)
+	assert.Equal(t, "https://example.com", resp.Headers["Location"])
+	assert.Equal(t, "0", resp.Headers["RateLimit-Remaining"])
+
+	resp, err = handler(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "60", resp.Headers["Retry-After"])
+}
+
+func TestDailyLinkQuota(t *testing.T) {
+	ctx := context.Background()
+	links := services.NewLinkService(mock.NewMockLinkRepo(), mock.NewMockRedisCache()).
+		WithDailyQuota(cache.NewMemoryLimiter(), 2)
+	alice := domain.WithPrincipal(ctx, domain.Principal{OwnerID: "alice", Scopes: domain.DefaultAPIKeyScopes})
+	bob := domain.WithPrincipal(ctx, domain.Principal{OwnerID: "bob", Scopes: domain.DefaultAPIKeyScopes})
+
+	create := func(ctx context.Context, id string) error {
+		return links.Create(ctx, domain.Link{Id: id, OriginalURL: "https://example.com", CreatedAt: time.Now()})
+	}
+
+	require.NoError(t, create(alice, "a1"))
+	require.NoError(t, create(alice, "a2"))
+	assert.ErrorIs(t, create(alice, "a3"), domain.ErrQuotaExceeded)
+
+	assert.NoError(t, create(bob, "b1"), "quotas are per owner")
+	assert.NoError(t, create(ctx, "trusted"), "links without an owner are not limited")
+}
+
+// failingLimiter fails like cache.RedisCache does while redis is down.
+type failingLimiter struct {
+	*cache.MemoryLimiter
+	down bool
+}
+
+func (f *failingLimiter) Take(ctx context.Context, key string, limit domain.RateLimit) (domain.RateDecision, error) {
+	if f.down {
+		return domain.RateDecision{}, errors.Join(errors.New("connection refused"), domain.ErrUnavailable)
+	}
+	return f.MemoryLimiter.Take(ctx, key, limit)
+}
@@ -2,11 +2,13 @@ package unit
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/itsbaivab/url-shortener/internal/adapters/cache"
 	"github.com/itsbaivab/url-shortener/internal/adapters/handlers"
+	"github.com/itsbaivab/url-shortener/internal/adapters/problem"
 	"github.com/itsbaivab/url-shortener/internal/core/services"
 	"github.com/itsbaivab/url-shortener/internal/tests/mock"
 	"github.com/stretchr/testify/assert"
@@ -24,31 +26,31 @@ func TestRedirectLinkUnit(t *testing.T) {
 		shortLink        string
 		expectStatusCode int
 		expectLocation   string
-		expectBody       string
+		expectType       string
 	}{
 		{
 			shortLink:        "testid1",
 			expectStatusCode: 301,
 			expectLocation:   "https://example.com/link1",
-			expectBody:       "",
+			expectType:       "",
 		},
 		{
 			shortLink:        "testid2",
 			expectStatusCode: 301,
 			expectLocation:   "https://example.com/link2",
-			expectBody:       "",
+			expectType:       "",
 		},
 		{
 			shortLink:        "testid3",
 			expectStatusCode: 301,
 			expectLocation:   "https://example.com/link3",
-			expectBody:       "",
+			expectType:       "",
 		},
 		{
 			shortLink:        "nonexistentid",
 			expectStatusCode: 404,
 			expectLocation:   "",
-			expectBody:       `{"error": "Link not found"}`,
+			expectType:       "/problems/not-found",
 		},
 	}
 
@@ -67,7 +69,10 @@ func TestRedirectLinkUnit(t *testing.T) {
 			assert.Equal(t, tt.expectLocation, location)
 
 			if tt.expectStatusCode == 404 {
-				assert.Equal(t, tt.expectBody, response.Body)
+				var body problem.Problem
+				assert.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+				assert.Equal(t, tt.expectType, body.Type)
+				assert.Equal(t, 404, body.Status)
 			}
 		})
 	}
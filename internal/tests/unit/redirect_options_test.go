@@ -0,0 +1,159 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/gin-gonic/gin"
+	"github.com/itsbaivab/url-shortener/internal/adapters/handlers"
+	"github.com/itsbaivab/url-shortener/internal/adapters/redirectpage"
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/itsbaivab/url-shortener/internal/core/services"
+	"github.com/itsbaivab/url-shortener/internal/tests/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedirectTargets(t *testing.T) {
+	query := url.Values{"x": {"1"}, "utm_source": {"newsletter"}, "sig": {"abc"}, "exp": {"123"}}
+
+	tests := []struct {
+		name     string
+		target   domain.Target
+		suffix   string
+		expected string
+		notFound bool
+	}{
+		{name: "No forwarding", target: domain.Target{URL: "https://example.com/docs?lang=en"}, expected: "https://example.com/docs?lang=en"},
+		{name: "Query is appended", target: domain.Target{URL: "https://example.com/docs?lang=en&utm_source=site", ForwardQuery: true},
+			expected: "https://example.com/docs?lang=en&utm_source=site&x=1"},
+		{name: "Destination query is kept as it is", target: domain.Target{URL: "https://cdn.example.com/f?X-Sig=a%2Fb&b=2&a=1&a=0", ForwardQuery: true},
+			expected: "https://cdn.example.com/f?X-Sig=a%2Fb&b=2&a=1&a=0&utm_source=newsletter&x=1"},
+		{name: "Path is appended", target: domain.Target{URL: "https://example.com/docs/", ForwardPath: true}, suffix: "/guide/intro",
+			expected: "https://example.com/docs/guide/intro"},
+		{name: "Path and query", target: domain.Target{URL: "https://example.com/docs?lang=en", ForwardPath: true, ForwardQuery: true}, suffix: "/extra",
+			expected: "https://example.com/docs/extra?lang=en&utm_source=newsletter&x=1"},
+		{name: "Paths of links that do not forward them do not exist", target: domain.Target{URL: "https://example.com/docs", ForwardQuery: true}, suffix: "/extra",
+			notFound: true},
+		{name: "Paths cannot climb out of the destination", target: domain.Target{URL: "https://example.com/public/guides", ForwardPath: true}, suffix: "/../../admin/secret",
+			notFound: true},
+		{name: "Encoded dot segments are refused too", target: domain.Target{URL: "https://example.com/public/guides", ForwardPath: true}, suffix: "/%2e%2E/admin",
+			notFound: true},
+		{name: "Single dots are refused", target: domain.Target{URL: "https://example.com/public/guides", ForwardPath: true}, suffix: "/./intro",
+			notFound: true},
+		{name: "Dots within names are fine", target: domain.Target{URL: "https://example.com/public/", ForwardPath: true}, suffix: "/v1..2/notes.txt",
+			expected: "https://example.com/public/v1..2/notes.txt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			location, err := tt.target.Location(tt.suffix, query, domain.PlatformUnknown)
+			if tt.notFound {
+				assert.ErrorIs(t, err, domain.ErrNotFound)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, location)
+		})
+	}
+
+	t.Run("Statuses", func(t *testing.T) {
+		assert.Equal(t, http.StatusMovedPermanently, domain.RedirectType("").Status(true))
+		assert.Equal(t, http.StatusFound, domain.RedirectPermanent.Status(false), "uncacheable links never redirect permanently")
+		assert.Equal(t, http.StatusTemporaryRedirect, domain.RedirectPermanentSameVerb.Status(false))
+		assert.Equal(t, http.StatusPermanentRedirect, domain.RedirectPermanentSameVerb.Status(true))
+		assert.Equal(t, http.StatusTemporaryRedirect, domain.RedirectTemporary.Status(true))
+		assert.Equal(t, http.StatusOK, domain.RedirectJavaScript.Status(true))
+		assert.False(t, domain.RedirectType("303").Valid())
+	})
+
+	t.Run("Cache entries keep the options", func(t *testing.T) {
+		target := domain.Target{URL: "https://example.com/", Type: domain.RedirectFound, ForwardQuery: true}
+		decoded, err := domain.DecodeTarget(domain.EncodeTarget(target))
+		require.NoError(t, err)
+		assert.Equal(t, target, decoded)
+
+		assert.Equal(t, "https://example.com/", domain.EncodeTarget(domain.Target{URL: "https://example.com/"}))
+		decoded, err = domain.DecodeTarget("https://example.com/")
+		require.NoError(t, err)
+		assert.Equal(t, domain.Target{URL: "https://example.com/"}, decoded)
+	})
+}
+
+func TestRedirectOptions(t *testing.T) {
+	ctx := context.Background()
+	linkCache := mock.NewMockRedisCache()
+	links := services.NewLinkService(mock.NewMockLinkRepo(), linkCache)
+	require.NoError(t, links.Create(ctx, domain.Link{Id: "temp", OriginalURL: "https://example.com/sale?ref=short", CreatedAt: time.Now(),
+		RedirectType: domain.RedirectFound, ForwardQuery: true}))
+	require.NoError(t, links.Create(ctx, domain.Link{Id: "page", OriginalURL: "https://example.com/", CreatedAt: time.Now(),
+		RedirectType: domain.RedirectJavaScript}))
+	require.NoError(t, links.Create(ctx, domain.Link{Id: "docs", OriginalURL: "https://example.com/docs/", CreatedAt: time.Now(),
+		ForwardPath: true}))
+	handler := handlers.NewRedirectFunctionHandler(links, services.NewStatsService(mock.NewMockStatsRepo(), linkCache))
+
+	t.Run("Unknown redirect types are rejected", func(t *testing.T) {
+		err := links.Create(ctx, domain.Link{Id: "bad", OriginalURL: "https://example.com/", CreatedAt: time.Now(), RedirectType: "303"})
+		assert.ErrorIs(t, err, domain.ErrValidation)
+	})
+
+	t.Run("Links redirect the way they chose, from the cache too", func(t *testing.T) {
+		for range 2 {
+			response, err := handler.Redirect(ctx, events.APIGatewayV2HTTPRequest{RawPath: "/temp", RawQueryString: "x=1"})
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusFound, response.StatusCode)
+			assert.Equal(t, "https://example.com/sale?ref=short&x=1", response.Headers["Location"])
+		}
+		cached, _ := linkCache.Get(ctx, "temp")
+		assert.Contains(t, cached, `"forward_query":true`)
+	})
+
+	t.Run("Paths after the link ID are forwarded", func(t *testing.T) {
+		for path, expected := range map[string]string{
+			"/docs":                 "https://example.com/docs/",
+			"/docs/guide/intro":     "https://example.com/docs/guide/intro",
+			"/r/docs/guide/intro":   "https://example.com/docs/guide/intro",
+			"/docs/release%20notes": "https://example.com/docs/release%20notes",
+		} {
+			response, err := handler.Redirect(ctx, events.APIGatewayV2HTTPRequest{RawPath: path})
+			require.NoError(t, err)
+			assert.Equal(t, expected, response.Headers["Location"], path)
+		}
+
+		response, err := handler.Redirect(ctx, events.APIGatewayV2HTTPRequest{RawPath: "/temp/extra"})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, response.StatusCode, "links that do not forward paths have none")
+
+		for _, path := range []string{"/docs/../admin", "/docs/%2e%2e/admin", "/docs/%zz"} {
+			response, err := handler.Redirect(ctx, events.APIGatewayV2HTTPRequest{RawPath: path})
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusNotFound, response.StatusCode, path)
+		}
+	})
+
+	t.Run("Pages redirect once loaded", func(t *testing.T) {
+		response, err := handler.Redirect(ctx, events.APIGatewayV2HTTPRequest{RawPath: "/page"})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Equal(t, redirectpage.ContentType, response.Headers["Content-Type"])
+		assert.Contains(t, response.Body, `window.location.replace("https://example.com/")`)
+	})
+
+	t.Run("Meta refresh pages escape the destination", func(t *testing.T) {
+		router := gin.New()
+		router.GET("/", func(c *gin.Context) {
+			redirectpage.Redirect(c, domain.RedirectMetaRefresh, `https://example.com/?q="><script>`, false)
+		})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+		assert.Contains(t, w.Body.String(), `http-equiv="refresh"`)
+		assert.NotContains(t, w.Body.String(), `"><script>`)
+	})
+}
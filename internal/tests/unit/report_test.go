@@ -0,0 +1,164 @@
+package unit
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/itsbaivab/url-shortener/internal/core/services"
+	"github.com/itsbaivab/url-shortener/internal/tests/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAbuseReports(t *testing.T) {
+	ctx := context.Background()
+	auditRepo := mock.NewMockAuditRepo()
+	audit := services.NewAuditService(auditRepo)
+	repo := mock.NewMockLinkRepo()
+	links := services.NewLinkService(repo, mock.NewMockRedisCache()).WithAudit(audit)
+	reports := mock.NewMockAbuseReportRepo()
+	abuse := services.NewAbuseService(reports, links).WithAudit(audit)
+	recorded := func(action string) []domain.AuditEntry {
+		entries, err := audit.Find(ctx, domain.AuditFilter{Action: action})
+		require.NoError(t, err)
+		return entries
+	}
+
+	acme := domain.WithWorkspace(ctx, "acme")
+	require.NoError(t, links.Create(acme, domain.Link{Id: "promo", OriginalURL: "https://promo.example.com/", CreatedAt: time.Now()}))
+
+	operator := domain.WithPrincipal(ctx, domain.Principal{OwnerID: "root", KeyID: "k1", Scopes: []string{domain.ScopeAdmin}})
+	tenantAdmin := domain.WithPrincipal(acme, domain.Principal{WorkspaceID: "acme", OwnerID: "boss", Scopes: []string{domain.ScopeAdmin}})
+
+	file := func(reason string) domain.AbuseReport {
+		t.Helper()
+		report, err := abuse.Report(acme, domain.AbuseReport{LinkID: "promo", Reason: reason, ReporterIP: "203.0.113.7"})
+		require.NoError(t, err)
+		return report
+	}
+
+	t.Run("Reports are validated", func(t *testing.T) {
+		for _, bad := range []domain.AbuseReport{
+			{LinkID: "promo", Reason: "boring"},
+			{LinkID: "promo", Reason: domain.ReportSpam, ReporterEmail: "not an email"},
+			{LinkID: "promo", Reason: domain.ReportSpam, Details: strings.Repeat("x", domain.MaxReportDetails+1)},
+		} {
+			_, err := abuse.Report(acme, bad)
+			assert.ErrorIs(t, err, domain.ErrValidation)
+		}
+
+		_, err := abuse.Report(ctx, domain.AbuseReport{LinkID: "promo", Reason: domain.ReportSpam})
+		assert.ErrorIs(t, err, domain.ErrNotFound, "links are looked up in the workspace of the host")
+	})
+
+	first := file("Phishing")
+	assert.Equal(t, domain.ReportPhishing, first.Reason)
+	assert.Equal(t, domain.ReportOpen, first.Status)
+	assert.Equal(t, "acme", first.WorkspaceID)
+	second := file(domain.ReportMalware)
+
+	t.Run("Only operators review reports", func(t *testing.T) {
+		_, err := abuse.Queue(tenantAdmin, domain.ReportOpen)
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+		_, err = abuse.Resolve(tenantAdmin, first.Id, domain.ReportDisabled, "phishing")
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+		assert.ErrorIs(t, abuse.DisableLink(tenantAdmin, "acme", "", "promo", "phishing"), domain.ErrForbidden)
+
+		queue, err := abuse.Queue(operator, domain.ReportOpen)
+		require.NoError(t, err)
+		assert.Len(t, queue, 2)
+	})
+
+	t.Run("Decisions need a note", func(t *testing.T) {
+		_, err := abuse.Resolve(operator, first.Id, domain.ReportDisabled, " ")
+		assert.ErrorIs(t, err, domain.ErrValidation)
+		_, err = abuse.Resolve(operator, first.Id, "deleted", "gone")
+		assert.ErrorIs(t, err, domain.ErrValidation)
+	})
+
+	t.Run("Disabling takes the link down and closes its reports", func(t *testing.T) {
+		resolved, err := abuse.Resolve(operator, first.Id, domain.ReportDisabled, "confirmed credential phishing")
+		require.NoError(t, err)
+		assert.Equal(t, domain.ReportDisabled, resolved.Status)
+		assert.Equal(t, "root (key k1)", resolved.Resolution.ResolvedBy)
+
+		_, err = links.GetOriginalURL(acme, "promo")
+		var disabled *domain.DisabledError
+		require.ErrorAs(t, err, &disabled)
+		assert.Equal(t, "confirmed credential phishing", disabled.Reason)
+
+		other, err := reports.Get(ctx, second.Id)
+		require.NoError(t, err)
+		assert.Equal(t, domain.ReportDisabled, other.Status)
+
+		queue, err := abuse.Queue(operator, domain.ReportOpen)
+		require.NoError(t, err)
+		assert.Empty(t, queue)
+
+		decisions := recorded(domain.AuditAbuseReportResolved)
+		require.Len(t, decisions, 2)
+		assert.Equal(t, []string{second.Id, first.Id}, []string{decisions[0].ResourceID, decisions[1].ResourceID})
+		assert.Equal(t, "root (key k1)", decisions[1].Actor)
+		assert.Equal(t, "confirmed credential phishing", decisions[1].Note)
+		assert.Contains(t, string(decisions[1].Before), `"status":"open"`)
+		assert.Contains(t, string(decisions[1].After), `"status":"disabled"`)
+
+		takedowns := recorded(domain.AuditLinkDisabled)
+		require.Len(t, takedowns, 1)
+		assert.Equal(t, "promo", takedowns[0].ResourceID)
+		assert.Equal(t, "acme", takedowns[0].WorkspaceID)
+
+		_, err = abuse.Resolve(operator, first.Id, domain.ReportDismissed, "changed my mind")
+		assert.ErrorIs(t, err, domain.ErrConflict)
+	})
+
+	t.Run("Restoring lets the link redirect again", func(t *testing.T) {
+		appeal := file(domain.ReportOther)
+		resolved, err := abuse.Resolve(operator, appeal.Id, domain.ReportRestored, "owner removed the phishing page")
+		require.NoError(t, err)
+		assert.Equal(t, domain.ReportRestored, resolved.Status)
+
+		url, err := links.GetOriginalURL(acme, "promo")
+		require.NoError(t, err)
+		assert.Equal(t, "https://promo.example.com/", *url)
+	})
+
+	t.Run("Dismissing leaves the link alone", func(t *testing.T) {
+		spam := file(domain.ReportSpam)
+		_, err := abuse.Resolve(operator, spam.Id, domain.ReportDismissed, "legitimate promotion")
+		require.NoError(t, err)
+
+		_, err = links.GetOriginalURL(acme, "promo")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Links are moderated without a report", func(t *testing.T) {
+		require.NoError(t, abuse.DisableLink(operator, "acme", "", "promo", "court order"))
+		_, err := links.GetOriginalURL(acme, "promo")
+		assert.ErrorIs(t, err, domain.ErrDisabled)
+
+		require.NoError(t, abuse.RestoreLink(operator, "acme", "", "promo", "order lifted"))
+		_, err = links.GetOriginalURL(acme, "promo")
+		assert.NoError(t, err)
+
+		assert.ErrorIs(t, abuse.DisableLink(operator, "acme", "", "missing", "court order"), domain.ErrNotFound)
+		restored := recorded(domain.AuditLinkRestored)
+		require.NotEmpty(t, restored)
+		assert.Equal(t, "order lifted", restored[0].Note)
+		assert.Contains(t, string(restored[0].Before), `"disabled_reason":"court order"`)
+		assert.NotContains(t, string(restored[0].After), "disabled_reason")
+	})
+
+	t.Run("Blocklisted destinations cannot be restored", func(t *testing.T) {
+		blocklist := services.NewBlocklistService(mock.NewMockBlocklistRepo())
+		_, err := blocklist.Add(operator, domain.BlockRule{Kind: domain.BlockDomain, Pattern: "promo.example.com"})
+		require.NoError(t, err)
+		guarded := services.NewAbuseService(reports, services.NewLinkService(repo, mock.NewMockRedisCache()).WithBlocklist(blocklist))
+
+		require.NoError(t, guarded.DisableLink(operator, "acme", "", "promo", "blocklisted"))
+		assert.ErrorIs(t, guarded.RestoreLink(operator, "acme", "", "promo", "appeal"), domain.ErrValidation)
+	})
+}
@@ -0,0 +1,125 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/itsbaivab/url-shortener/internal/adapters/handlers"
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/itsbaivab/url-shortener/internal/core/services"
+	"github.com/itsbaivab/url-shortener/internal/tests/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	iPhoneUA  = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1"
+	androidUA = "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Mobile Safari/537.36"
+	windowsUA = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36"
+	macUA     = "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_4) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Safari/605.1.15"
+)
+
+func TestParseUserAgent(t *testing.T) {
+	tests := []struct {
+		userAgent string
+		want      domain.Device
+	}{
+		{iPhoneUA, domain.Device{OS: domain.OSiOS, Mobile: true}},
+		{"Mozilla/5.0 (iPad; CPU OS 17_4 like Mac OS X)", domain.Device{OS: domain.OSiOS, Mobile: true}},
+		{androidUA, domain.Device{OS: domain.OSAndroid, Mobile: true}},
+		{windowsUA, domain.Device{OS: domain.OSWindows}},
+		{macUA, domain.Device{OS: domain.OSMacOS}},
+		{"Mozilla/5.0 (X11; Linux x86_64) Gecko/20100101 Firefox/125.0", domain.Device{OS: domain.OSLinux}},
+		{"Mozilla/5.0 (X11; CrOS x86_64 14541.0.0)", domain.Device{OS: domain.OSChromeOS}},
+		{"curl/8.5.0", domain.Device{}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, domain.ParseUserAgent(tt.userAgent), tt.userAgent)
+	}
+
+	assert.True(t, domain.MatchDesktop.Matches(domain.ParseUserAgent(macUA)))
+	assert.False(t, domain.MatchDesktop.Matches(domain.ParseUserAgent("curl/8.5.0")), "tools are no desktop")
+	assert.True(t, domain.MatchMobile.Matches(domain.ParseUserAgent(androidUA)))
+	assert.False(t, domain.MatchIOS.Matches(domain.Device{}))
+}
+
+func TestRoutingRules(t *testing.T) {
+	ctx := domain.WithWorkspace(context.Background(), "acme")
+	appStore := "https://apps.apple.com/app/id123456789"
+	intent := "intent://open/#Intent;scheme=example;package=com.example.app;S.browser_fallback_url=https%3A%2F%2Fexample.com%2F;end"
+	rules := []domain.RoutingRule{
+		{Device: domain.MatchIOS, URL: appStore},
+		{Device: domain.MatchAndroid, URL: intent},
+		{Device: domain.MatchMobile, URL: "https://m.example.com/"},
+	}
+
+	t.Run("The first matching rule wins", func(t *testing.T) {
+		target := domain.Target{URL: "https://example.com/", Rules: rules,
+			Variants: []domain.Variant{{ID: "a", URL: "https://example.com/a", Weight: 1}, {ID: "b", URL: "https://example.com/b", Weight: 1}}}
+		for _, tt := range []struct {
+			userAgent string
+			url       string
+			rule      domain.DeviceMatch
+		}{
+			{iPhoneUA, appStore, domain.MatchIOS},
+			{androidUA, intent, domain.MatchAndroid},
+			{"Mozilla/5.0 (Linux; U; Tizen 2.0) Mobile", "https://m.example.com/", domain.MatchMobile},
+			{windowsUA, "https://example.com/", ""},
+		} {
+			routed, rule := target.Route(domain.ParseUserAgent(tt.userAgent))
+			assert.Equal(t, tt.url, routed.URL, tt.userAgent)
+			assert.Equal(t, tt.rule, rule, tt.userAgent)
+			assert.Empty(t, routed.Rules)
+			assert.Equal(t, rule == "", len(routed.Variants) > 0, "only the fallback splits between variants")
+		}
+	})
+
+	t.Run("Rules are validated", func(t *testing.T) {
+		links := services.NewLinkService(mock.NewMockLinkRepo(), mock.NewMockRedisCache())
+		for name, rules := range map[string][]domain.RoutingRule{
+			"unknown device": {{Device: "blackberry", URL: "https://example.com/"}},
+			"duplicate":      {{Device: domain.MatchIOS, URL: appStore}, {Device: domain.MatchIOS, URL: appStore}},
+			"no URL":         {{Device: domain.MatchDesktop}},
+			"intent on iOS":  {{Device: domain.MatchIOS, URL: intent}},
+			"bad intent":     {{Device: domain.MatchAndroid, URL: "intent://open/"}},
+		} {
+			err := links.Create(ctx, domain.Link{Id: "app", OriginalURL: "https://example.com/", CreatedAt: time.Now(), Rules: rules})
+			assert.ErrorIs(t, err, domain.ErrValidation, name)
+		}
+	})
+
+	t.Run("Redirects follow the rules and count per rule", func(t *testing.T) {
+		links := services.NewLinkService(mock.NewMockLinkRepo(), mock.NewMockRedisCache())
+		stats := services.NewStatsService(mock.NewMockStatsRepo(), mock.NewMockRedisCache())
+		link := domain.Link{Id: "app", OriginalURL: "https://example.com/", CreatedAt: time.Now(), Rules: rules[:2]}
+		require.NoError(t, links.Create(context.Background(), link))
+		handler := handlers.NewRedirectFunctionHandler(links, stats)
+
+		for _, tt := range []struct {
+			userAgent string
+			location  string
+		}{
+			{iPhoneUA, appStore},
+			{androidUA, intent},
+			{androidUA, intent},
+			{macUA, "https://example.com/"},
+		} {
+			response, err := handler.Redirect(context.Background(), events.APIGatewayV2HTTPRequest{RawPath: "/app", Headers: map[string]string{"user-agent": tt.userAgent}})
+			require.NoError(t, err)
+			assert.Equal(t, tt.location, response.Headers["Location"], tt.userAgent)
+			assert.Equal(t, "User-Agent", response.Headers["Vary"])
+		}
+
+		summary, err := stats.RoutingStats(context.Background(), link)
+		require.NoError(t, err)
+		assert.Equal(t, domain.RoutingStats{
+			Rules: []domain.RuleClicks{
+				{Device: domain.MatchIOS, URL: appStore, Clicks: 1},
+				{Device: domain.MatchAndroid, URL: intent, Clicks: 2},
+			},
+			Fallback: 1,
+		}, summary)
+	})
+}
@@ -0,0 +1,99 @@
+package unit
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/itsbaivab/url-shortener/internal/adapters/problem"
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/itsbaivab/url-shortener/internal/core/services"
+	"github.com/itsbaivab/url-shortener/internal/tests/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignedLinks(t *testing.T) {
+	ctx := domain.WithWorkspace(context.Background(), "acme")
+	oldKey := []byte("the key that signed last month's URLs")
+	newKey := []byte("the key that signs URLs from now on")
+
+	linkCache := mock.NewMockRedisCache()
+	signing := services.NewSigningService(newKey, oldKey).WithTTL(time.Hour, 24*time.Hour)
+	links := services.NewLinkService(mock.NewMockLinkRepo(), linkCache).WithSigning(signing)
+	require.NoError(t, links.Create(ctx, domain.Link{Id: "report", OriginalURL: "https://example.com/report.pdf", CreatedAt: time.Now(), RequireSignature: true}))
+	require.NoError(t, links.Create(ctx, domain.Link{Id: "public", OriginalURL: "https://example.com/", CreatedAt: time.Now()}))
+
+	follow := func(id string, s domain.URLSignature) (*string, error) {
+		return links.GetOriginalURL(domain.WithURLSignature(ctx, s), id)
+	}
+
+	t.Run("Signed URLs redirect until they expire", func(t *testing.T) {
+		link, s, err := links.SignURL(ctx, "report", 0)
+		require.NoError(t, err)
+		assert.Equal(t, "report", link.Id)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), s.Expires, time.Second)
+
+		query, err := url.ParseQuery(s.Query())
+		require.NoError(t, err)
+		parsed := domain.ParseURLSignature(query.Get("exp"), query.Get("sig"))
+		destination, err := follow("report", parsed)
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.com/report.pdf", *destination)
+
+		cached, _ := linkCache.Get(ctx, "acme:report")
+		assert.Empty(t, cached, "links that require a signature are never cached")
+
+		err = signing.Verify(link, s, s.Expires)
+		assert.ErrorIs(t, err, domain.ErrExpired)
+		assert.Equal(t, 410, problem.FromError(err).Status)
+	})
+
+	t.Run("Missing or tampered signatures are forbidden", func(t *testing.T) {
+		_, err := follow("report", domain.URLSignature{})
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+		assert.Equal(t, 403, problem.FromError(err).Status)
+
+		_, s, err := links.SignURL(ctx, "report", time.Minute)
+		require.NoError(t, err)
+		later := s
+		later.Expires = later.Expires.Add(time.Hour)
+		_, err = follow("report", later)
+		assert.ErrorIs(t, err, domain.ErrForbidden, "the expiry is part of the signature")
+
+		_, err = follow("report", domain.ParseURLSignature("soon", s.Signature))
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+	})
+
+	t.Run("Old keys keep verifying while they rotate out", func(t *testing.T) {
+		link, err := links.Get(ctx, "report")
+		require.NoError(t, err)
+		s, err := services.NewSigningService(oldKey).Sign(link, time.Hour)
+		require.NoError(t, err)
+		_, err = follow("report", s)
+		assert.NoError(t, err)
+
+		s, err = services.NewSigningService([]byte("a key that was never configured")).Sign(link, time.Hour)
+		require.NoError(t, err)
+		_, err = follow("report", s)
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+	})
+
+	t.Run("Signing is validated", func(t *testing.T) {
+		_, _, err := links.SignURL(ctx, "public", 0)
+		assert.ErrorIs(t, err, domain.ErrValidation)
+		_, _, err = links.SignURL(ctx, "report", 48*time.Hour)
+		assert.ErrorIs(t, err, domain.ErrValidation)
+
+		unsigned := services.NewLinkService(mock.NewMockLinkRepo(), mock.NewMockRedisCache())
+		err = unsigned.Create(ctx, domain.Link{Id: "report", OriginalURL: "https://example.com/report.pdf", CreatedAt: time.Now(), RequireSignature: true})
+		assert.ErrorIs(t, err, domain.ErrValidation)
+	})
+
+	t.Run("Public links ignore signatures", func(t *testing.T) {
+		destination, err := follow("public", domain.URLSignature{})
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.com/", *destination)
+	})
+}
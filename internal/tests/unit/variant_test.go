@@ -0,0 +1,120 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/itsbaivab/url-shortener/internal/adapters/handlers"
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/itsbaivab/url-shortener/internal/core/services"
+	"github.com/itsbaivab/url-shortener/internal/tests/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinkVariants(t *testing.T) {
+	ctx := domain.WithWorkspace(context.Background(), "acme")
+	variants := []domain.Variant{
+		{ID: "a", URL: "https://example.com/landing-a", Weight: 3},
+		{ID: "b", URL: "https://example.com/landing-b", Weight: 1},
+	}
+	target := domain.Target{URL: variants[0].URL, Variants: variants}
+
+	t.Run("Visitors are split by weight", func(t *testing.T) {
+		served := map[string]int{}
+		for i := range 4000 {
+			picked, variant := target.Pick("", domain.VisitorKey("promo", fmt.Sprintf("198.51.100.%d", i%250), fmt.Sprint(i)))
+			assert.Empty(t, picked.Variants)
+			served[variant]++
+			assert.Equal(t, "https://example.com/landing-"+variant, picked.URL)
+		}
+		assert.InDelta(t, 3000, served["a"], 200)
+		assert.InDelta(t, 1000, served["b"], 200)
+	})
+
+	t.Run("Assignment is sticky", func(t *testing.T) {
+		key := domain.VisitorKey("promo", "203.0.113.7", "Mozilla/5.0")
+		_, first := target.Pick("", key)
+		for range 10 {
+			_, again := target.Pick("", key)
+			assert.Equal(t, first, again)
+		}
+
+		_, cookie := target.Pick("b", key)
+		assert.Equal(t, "b", cookie, "the cookie wins over the hash")
+		_, stale := target.Pick("removed", key)
+		assert.Equal(t, first, stale, "unknown cookies fall back to the hash")
+
+		plain, variant := domain.Target{URL: "https://example.com/"}.Pick("a", key)
+		assert.Empty(t, variant)
+		assert.Equal(t, "https://example.com/", plain.URL)
+	})
+
+	t.Run("Variants survive the link cache", func(t *testing.T) {
+		decoded, err := domain.DecodeTarget(domain.EncodeTarget(target))
+		require.NoError(t, err)
+		assert.Equal(t, target, decoded)
+	})
+
+	t.Run("Variants are validated", func(t *testing.T) {
+		links := services.NewLinkService(mock.NewMockLinkRepo(), mock.NewMockRedisCache())
+		for name, variants := range map[string][]domain.Variant{
+			"single":       {{ID: "a", URL: "https://example.com/", Weight: 1}},
+			"duplicate ID": {{ID: "a", URL: "https://example.com/", Weight: 1}, {ID: "a", URL: "https://example.org/", Weight: 1}},
+			"invalid ID":   {{ID: "a b", URL: "https://example.com/", Weight: 1}, {ID: "c", URL: "https://example.org/", Weight: 1}},
+			"zero weight":  {{ID: "a", URL: "https://example.com/", Weight: 0}, {ID: "b", URL: "https://example.org/", Weight: 1}},
+			"no URL":       {{ID: "a", URL: "https://example.com/", Weight: 1}, {ID: "b", Weight: 1}},
+		} {
+			err := links.Create(ctx, domain.Link{Id: "split", OriginalURL: "https://example.com/", CreatedAt: time.Now(), Variants: variants})
+			assert.ErrorIs(t, err, domain.ErrValidation, name)
+		}
+	})
+
+	t.Run("Blocked variants disable the link", func(t *testing.T) {
+		blocklist := services.NewBlocklistService(mock.NewMockBlocklistRepo())
+		links := services.NewLinkService(mock.NewMockLinkRepo(), mock.NewMockRedisCache()).WithBlocklist(blocklist)
+		require.NoError(t, links.Create(ctx, domain.Link{Id: "split", OriginalURL: variants[0].URL, CreatedAt: time.Now(),
+			Variants: []domain.Variant{variants[0], {ID: "b", URL: "https://evil.example/", Weight: 1}}}))
+
+		operator := domain.WithPrincipal(ctx, domain.Principal{OwnerID: "root", Scopes: []string{domain.ScopeAdmin}})
+		_, err := blocklist.Add(operator, domain.BlockRule{Kind: domain.BlockDomain, Pattern: "evil.example", Reason: "phishing"})
+		require.NoError(t, err)
+
+		_, err = links.Resolve(ctx, "split", nil)
+		var disabled *domain.DisabledError
+		assert.ErrorAs(t, err, &disabled)
+	})
+
+	t.Run("The variant served is recorded and counted", func(t *testing.T) {
+		linkRepo, statsRepo := mock.NewMockLinkRepo(), mock.NewMockStatsRepo()
+		links := services.NewLinkService(linkRepo, mock.NewMockRedisCache())
+		stats := services.NewStatsService(statsRepo, mock.NewMockRedisCache())
+		link := domain.Link{Id: "split", OriginalURL: variants[0].URL, CreatedAt: time.Now(), Variants: variants}
+		require.NoError(t, links.Create(context.Background(), link))
+
+		handler := handlers.NewRedirectFunctionHandler(links, stats)
+		request := events.APIGatewayV2HTTPRequest{RawPath: "/split", Headers: map[string]string{"user-agent": "Mozilla/5.0"}}
+		request.RequestContext.HTTP.SourceIP = "203.0.113.7"
+		_, want := target.Pick("", domain.VisitorKey("split", "203.0.113.7", "Mozilla/5.0"))
+
+		for range 3 {
+			response, err := handler.Redirect(context.Background(), request)
+			require.NoError(t, err)
+			assert.Equal(t, "https://example.com/landing-"+want, response.Headers["Location"])
+		}
+
+		clicks, err := stats.VariantClicks(context.Background(), link)
+		require.NoError(t, err)
+		require.Len(t, clicks, 2)
+		for _, c := range clicks {
+			if c.Variant == want {
+				assert.Equal(t, 3, c.Clicks)
+			} else {
+				assert.Zero(t, c.Clicks)
+			}
+		}
+	})
+}
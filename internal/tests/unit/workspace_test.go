@@ -0,0 +1,221 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/itsbaivab/url-shortener/internal/auth"
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/itsbaivab/url-shortener/internal/core/services"
+	"github.com/itsbaivab/url-shortener/internal/tenant"
+	"github.com/itsbaivab/url-shortener/internal/tests/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkspaceIsolation(t *testing.T) {
+	ctx := context.Background()
+	workspaces := mock.NewMockWorkspaceRepo()
+	require.NoError(t, workspaces.Create(ctx, domain.Workspace{
+		Id:       "acme",
+		Quotas:   domain.WorkspaceQuotas{MaxLinks: 2},
+		Settings: domain.WorkspaceSettings{ShortIDLength: 5},
+	}))
+
+	cache := mock.NewMockRedisCache()
+	links := services.NewLinkService(mock.NewMockLinkRepo(), cache).WithWorkspaces(workspaces)
+	acme := domain.WithWorkspace(ctx, "acme")
+
+	link := domain.Link{Id: "shared", OriginalURL: "https://acme.example.com", CreatedAt: time.Now()}
+	require.NoError(t, links.Create(acme, link))
+	link.OriginalURL = "https://default.example.com"
+	require.NoError(t, links.Create(ctx, link))
+
+	t.Run("Same ID resolves per workspace", func(t *testing.T) {
+		url, err := links.GetOriginalURL(acme, "shared")
+		require.NoError(t, err)
+		assert.Equal(t, "https://acme.example.com", *url)
+
+		url, err = links.GetOriginalURL(ctx, "shared")
+		require.NoError(t, err)
+		assert.Equal(t, "https://default.example.com", *url)
+	})
+
+	t.Run("Workspaces only list their own links", func(t *testing.T) {
+		all, err := links.GetAll(acme)
+		require.NoError(t, err)
+		require.Len(t, all, 1)
+		assert.Equal(t, "acme", all[0].WorkspaceID)
+	})
+
+	t.Run("Deleting only affects the workspace", func(t *testing.T) {
+		require.NoError(t, links.Create(acme, domain.Link{Id: "gone", OriginalURL: "https://acme.example.com", CreatedAt: time.Now()}))
+		assert.ErrorIs(t, links.Delete(ctx, "gone"), domain.ErrNotFound)
+		require.NoError(t, links.Delete(acme, "gone"))
+	})
+
+	t.Run("Link quota", func(t *testing.T) {
+		require.NoError(t, links.Create(acme, domain.Link{Id: "second", OriginalURL: "https://acme.example.com", CreatedAt: time.Now()}))
+		err := links.Create(acme, domain.Link{Id: "third", OriginalURL: "https://acme.example.com", CreatedAt: time.Now()})
+		assert.ErrorIs(t, err, domain.ErrQuotaExceeded)
+	})
+
+	t.Run("Short ID length setting", func(t *testing.T) {
+		id, err := links.NewLinkID(acme)
+		require.NoError(t, err)
+		assert.Len(t, id, 5)
+
+		id, err = links.NewLinkID(ctx)
+		require.NoError(t, err)
+		assert.Len(t, id, domain.DefaultShortIDLength)
+	})
+}
+
+func TestWorkspaceManagement(t *testing.T) {
+	ctx := context.Background()
+	service := services.NewWorkspaceService(mock.NewMockWorkspaceRepo())
+
+	operator := domain.WithPrincipal(ctx, domain.Principal{OwnerID: "root", Scopes: []string{domain.ScopeAdmin}})
+	tenantAdmin := domain.WithPrincipal(ctx, domain.Principal{WorkspaceID: "acme", OwnerID: "boss", Scopes: []string{domain.ScopeAdmin}})
+
+	t.Run("Operators create workspaces", func(t *testing.T) {
+		created, err := service.Create(operator, domain.Workspace{Id: "acme"})
+		require.NoError(t, err)
+		assert.Equal(t, "acme", created.Name)
+	})
+
+	t.Run("Workspace admins are not operators", func(t *testing.T) {
+		_, err := service.Create(tenantAdmin, domain.Workspace{Id: "evil"})
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+		_, err = service.All(tenantAdmin)
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+
+		current, err := service.Current(domain.WithWorkspace(tenantAdmin, "acme"))
+		require.NoError(t, err)
+		assert.Equal(t, "acme", current.Id)
+	})
+
+	t.Run("Invalid workspaces are rejected", func(t *testing.T) {
+		for _, bad := range []domain.Workspace{
+			{Id: "Not Valid"},
+			{Id: "ok", Settings: domain.WorkspaceSettings{ShortIDLength: 2}},
+			{Id: "ok", Quotas: domain.WorkspaceQuotas{MaxLinks: -1}},
+		} {
+			_, err := service.Create(operator, bad)
+			assert.ErrorIs(t, err, domain.ErrValidation, bad.Id)
+		}
+	})
+}
+
+func TestTenantMiddleware(t *testing.T) {
+	ctx := context.Background()
+	repo := mock.NewMockDomainRepo()
+	verifiedAt := time.Now()
+	require.NoError(t, repo.Create(ctx, domain.CustomDomain{Name: "go.acme.com", WorkspaceID: "acme", VerifiedAt: &verifiedAt}))
+	domains := services.NewDomainService(repo, mock.NewMockTXTResolver())
+
+	keys := services.NewAPIKeyService(mock.NewMockAPIKeyRepo())
+	defaultKey, _, err := keys.Issue(ctx, "alice", "", nil)
+	require.NoError(t, err)
+	acmeKey, _, err := keys.Issue(domain.WithWorkspace(ctx, "acme"), "bob", "", nil)
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/redirect", tenant.Middleware(domains), func(c *gin.Context) {
+		c.String(http.StatusOK, domain.WorkspaceOf(c.Request.Context()))
+	})
+	router.GET("/links", tenant.Middleware(domains), auth.Middleware(keys), func(c *gin.Context) {
+		c.String(http.StatusOK, domain.WorkspaceOf(c.Request.Context()))
+	})
+
+	do := func(path, host, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Host = host
+		if key != "" {
+			req.Header.Set(auth.APIKeyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Custom domains select the workspace", func(t *testing.T) {
+		assert.Equal(t, "acme", do("/redirect", "go.acme.com", "").Body.String())
+		assert.Equal(t, domain.DefaultWorkspaceID, do("/redirect", "localhost", "").Body.String())
+	})
+
+	t.Run("API keys select the workspace", func(t *testing.T) {
+		assert.Equal(t, "acme", do("/links", "localhost", acmeKey).Body.String())
+		assert.Equal(t, domain.DefaultWorkspaceID, do("/links", "localhost", defaultKey).Body.String())
+	})
+
+	t.Run("Keys of another workspace are refused on a custom domain", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, do("/links", "go.acme.com", acmeKey).Code)
+		assert.Equal(t, http.StatusForbidden, do("/links", "go.acme.com", defaultKey).Code)
+	})
+
+	t.Run("Failed lookups are not served", func(t *testing.T) {
+		failing := services.NewDomainService(failingDomainRepo{repo}, mock.NewMockTXTResolver())
+		router := gin.New()
+		router.GET("/redirect", tenant.Middleware(failing), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+		req := httptest.NewRequest(http.MethodGet, "/redirect", nil)
+		req.Host = "go.acme.com"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+}
+
+func TestAPIKeyWorkspaces(t *testing.T) {
+	ctx := context.Background()
+	keys := services.NewAPIKeyService(mock.NewMockAPIKeyRepo())
+
+	operator := domain.WithPrincipal(ctx, domain.Principal{OwnerID: "root", Scopes: []string{domain.ScopeAdmin}})
+	acmeAdmin := domain.WithWorkspace(domain.WithPrincipal(ctx, domain.Principal{WorkspaceID: "acme", OwnerID: "boss", Scopes: []string{domain.ScopeAdmin}}), "acme")
+
+	_, defaultKey, err := keys.Issue(operator, "alice", "", nil)
+	require.NoError(t, err)
+	_, acmeKey, err := keys.Issue(acmeAdmin, "bob", "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "acme", acmeKey.WorkspaceID)
+
+	t.Run("Workspace admins only manage their own keys", func(t *testing.T) {
+		listed, err := keys.All(acmeAdmin)
+		require.NoError(t, err)
+		require.Len(t, listed, 1)
+		assert.Equal(t, acmeKey.Id, listed[0].Id)
+
+		assert.ErrorIs(t, keys.Revoke(acmeAdmin, defaultKey.Id), domain.ErrNotFound)
+
+		_, _, err = keys.Issue(domain.WithWorkspace(acmeAdmin, domain.DefaultWorkspaceID), "mallory", "", nil)
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+	})
+
+	t.Run("Operators manage every workspace", func(t *testing.T) {
+		listed, err := keys.All(operator)
+		require.NoError(t, err)
+		assert.Len(t, listed, 2)
+
+		_, _, err = keys.Issue(domain.WithWorkspace(operator, "acme"), "carol", "", nil)
+		assert.NoError(t, err)
+		assert.NoError(t, keys.Revoke(operator, acmeKey.Id))
+	})
+}
+
+// failingDomainRepo fails domain lookups as an unreachable database
+// would.
+type failingDomainRepo struct {
+	*mock.MockDomainRepo
+}
+
+func (failingDomainRepo) Get(ctx context.Context, name string) (domain.CustomDomain, error) {
+	return domain.CustomDomain{}, errors.Join(errors.New("connection refused"), domain.ErrUnavailable)
+}
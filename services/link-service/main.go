@@ -2,157 +2,380 @@ package main
 
 import (
 	"context"
-	"crypto/rand"
-	"database/sql"
-	"fmt"
-	"log"
-	"math/big"
+	"errors"
+	"io"
 	"net/http"
-	"os"
-	"os/signal"
-	"syscall"
+	"net/netip"
+	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/itsbaivab/url-shortener/internal/adapters/cache"
+	"github.com/itsbaivab/url-shortener/internal/adapters/dns"
+	"github.com/itsbaivab/url-shortener/internal/adapters/problem"
 	"github.com/itsbaivab/url-shortener/internal/adapters/repository/postgres"
+	"github.com/itsbaivab/url-shortener/internal/auth"
 	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/itsbaivab/url-shortener/internal/core/ports"
 	"github.com/itsbaivab/url-shortener/internal/core/services"
-	_ "github.com/lib/pq"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/itsbaivab/url-shortener/internal/logging"
+	"github.com/itsbaivab/url-shortener/internal/server"
 )
 
 type LinkServiceHandler struct {
-	linkService *services.LinkService
+	linkService  *services.LinkService
+	destinations *services.DestinationValidator
+	apiKeys      *services.APIKeyService
+	workspaces   *services.WorkspaceService
+	domains      *services.DomainService
+	blocklist    *services.BlocklistService
+	abuse        *services.AbuseService
+	audit        *services.AuditService
+	campaigns    *services.CampaignService
+	geo          ports.GeoIP
 }
 
 type CreateLinkRequest struct {
-	Long string `json:"long" binding:"required"`
+	// Long is the destination. It may be left out for links with variants
+	// and then defaults to the URL of the first one.
+	Long string `json:"long"`
+	// Domain is the custom domain to serve the link on. It defaults to the
+	// domain the request was made on.
+	Domain string `json:"domain"`
+	// Password, when set, must be entered by visitors before they are
+	// redirected. Only its hash is stored.
+	Password string `json:"password"`
+	// RequireSignature makes the link redirect only through URLs signed
+	// with /links/:id/sign.
+	RequireSignature bool `json:"require_signature"`
+	// RedirectType is 301 (the default), 302, 307, 308, meta_refresh or
+	// javascript.
+	RedirectType domain.RedirectType `json:"redirect_type"`
+	// ForwardQuery and ForwardPath pass the query string and the path
+	// after the link ID on to the destination.
+	ForwardQuery bool `json:"forward_query"`
+	ForwardPath  bool `json:"forward_path"`
+	// CampaignID adds the link to a campaign of the workspace, whose UTM
+	// template is merged into the destination on every redirect.
+	CampaignID string `json:"campaign_id"`
+	// Variants split visitors between several destinations by weight,
+	// instead of redirecting them all to Long.
+	Variants []domain.Variant `json:"variants"`
+	// Rules send visitors on some devices, such as iOS or Android, to
+	// another URL, typically an app or app store. Visitors matching no
+	// rule go to Long.
+	Rules []domain.RoutingRule `json:"rules"`
+	// GeoRules send visitors from some countries or regions to another
+	// URL, in order. Visitors matching no rule go to Long.
+	GeoRules []domain.GeoRule `json:"geo_rules"`
 }
 
-type DeleteLinkRequest struct {
-	ID string `json:"id" binding:"required"`
+// CampaignRequest creates or replaces a campaign.
+type CampaignRequest struct {
+	Name string             `json:"name" binding:"required"`
+	UTM  domain.UTMTemplate `json:"utm"`
 }
 
-func main() {
-	// Database connection
-	dbHost := getEnv("DB_HOST", "localhost")
-	dbPort := getEnv("DB_PORT", "5432")
-	dbUser := getEnv("DB_USER", "postgres")
-	dbPassword := getEnv("DB_PASSWORD", "postgres")
-	dbName := getEnv("DB_NAME", "urlshortener")
-
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		dbHost, dbPort, dbUser, dbPassword, dbName)
-
-	db, err := sql.Open("postgres", dsn)
-	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
-	}
-	defer db.Close()
-
-	// Test database connection
-	if err := db.Ping(); err != nil {
-		log.Fatal("Failed to ping database:", err)
-	}
+// DryRunRequest describes a visitor of a link, for asking where the link
+// would send them.
+type DryRunRequest struct {
+	IP        string `json:"ip" binding:"required"`
+	UserAgent string `json:"user_agent"`
+	Referer   string `json:"referer"`
+	Domain    string `json:"domain"`
+}
 
-	// Redis connection
-	redisHost := getEnv("REDIS_HOST", "localhost")
-	redisPort := getEnv("REDIS_PORT", "6379")
-	redisCache := cache.NewRedisCache(redisHost+":"+redisPort, "", 0)
+// DryRunResponse is where a link would send a visitor: the URL, the
+// visitor's country, and the routing rule, geo rule and variant chosen.
+// GeoRule is the index of the geo rule and omitted when none matched.
+type DryRunResponse struct {
+	URL     string             `json:"url"`
+	Country string             `json:"country,omitempty"`
+	Rule    domain.DeviceMatch `json:"rule,omitempty"`
+	GeoRule *int               `json:"geo_rule,omitempty"`
+	Variant string             `json:"variant,omitempty"`
+}
 
-	// Initialize repository and service
-	linkRepo := postgres.NewPostgresLinkRepository(db)
-	linkService := services.NewLinkService(linkRepo, redisCache)
+// SignLinkRequest asks for a signed URL of a link that requires one.
+// ExpiresIn is in seconds; zero asks for the deployment default.
+type SignLinkRequest struct {
+	Domain    string `json:"domain"`
+	ExpiresIn int    `json:"expires_in"`
+}
 
-	// Initialize handler
-	handler := &LinkServiceHandler{
-		linkService: linkService,
-	}
+// SignedLinkResponse is a signed short URL, with its exp and sig query
+// parameters and when it expires.
+type SignedLinkResponse struct {
+	URL       string    `json:"url"`
+	Exp       string    `json:"exp"`
+	Signature string    `json:"sig"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
 
-	// Setup router
-	router := gin.Default()
+type DeleteLinkRequest struct {
+	ID     string `json:"id" binding:"required"`
+	Domain string `json:"domain"`
+}
 
-	// Health check
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "healthy"})
-	})
+type AddDomainRequest struct {
+	Name string `json:"name" binding:"required"`
+}
 
-	// Metrics endpoint
-	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+// DomainResponse is a custom domain with the TXT record that verifies it.
+type DomainResponse struct {
+	domain.CustomDomain
+	TXTName  string `json:"txt_name"`
+	TXTValue string `json:"txt_value"`
+}
 
-	// Link endpoints
-	router.PUT("/generate", handler.CreateLink)
-	router.GET("/links", handler.GetAllLinks)
-	router.DELETE("/delete", handler.DeleteLink)
+type AddBlockRuleRequest struct {
+	Kind    string `json:"kind" binding:"required"`
+	Pattern string `json:"pattern" binding:"required"`
+	Reason  string `json:"reason"`
+}
 
-	// Start server
-	port := getEnv("SERVICE_PORT", "8001")
-	srv := &http.Server{
-		Addr:    ":" + port,
-		Handler: router,
-	}
+// ResolveReportRequest closes an abuse report. Decision is disabled,
+// restored or dismissed.
+type ResolveReportRequest struct {
+	Decision string `json:"decision" binding:"required"`
+	Note     string `json:"note" binding:"required"`
+}
 
-	// Graceful shutdown
-	go func() {
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Failed to start server: %v", err)
-		}
-	}()
+// ModerateLinkRequest disables or restores a link without a report.
+type ModerateLinkRequest struct {
+	WorkspaceID string `json:"workspace_id"`
+	Domain      string `json:"domain"`
+	Note        string `json:"note" binding:"required"`
+}
 
-	log.Printf("Link Service started on port %s", port)
+// AuditQuery filters the audit log. Since and Until are RFC 3339
+// timestamps; Before is the Next cursor of the previous page.
+type AuditQuery struct {
+	WorkspaceID string    `form:"workspace_id"`
+	Actor       string    `form:"actor"`
+	Action      string    `form:"action"`
+	ResourceID  string    `form:"resource_id"`
+	Since       time.Time `form:"since" time_format:"2006-01-02T15:04:05Z07:00"`
+	Until       time.Time `form:"until" time_format:"2006-01-02T15:04:05Z07:00"`
+	Before      int64     `form:"before"`
+	Limit       int       `form:"limit"`
+}
 
-	// Wait for interrupt signal
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+// AuditPage is a page of audit entries, newest first. Next is passed as
+// before to fetch the following page; it is omitted on the last one.
+type AuditPage struct {
+	Entries []domain.AuditEntry `json:"entries"`
+	Next    int64               `json:"next,omitempty"`
+}
 
-	log.Println("Shutting down Link Service...")
+type IssueAPIKeyRequest struct {
+	OwnerID string   `json:"owner_id" binding:"required"`
+	Name    string   `json:"name"`
+	Scopes  []string `json:"scopes"`
+	// WorkspaceID defaults to the caller's workspace. Only operators may
+	// issue keys for another workspace.
+	WorkspaceID string `json:"workspace_id"`
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+type IssueAPIKeyResponse struct {
+	domain.APIKey
+	// Key is the full API key. It is only returned here.
+	Key string `json:"key"`
+}
 
-	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatal("Server forced to shutdown:", err)
-	}
+func main() {
+	server.Run(server.Options{Name: "Link Service", DefaultPort: "8001"}, func(s *server.Server) error {
+		linkRepo := postgres.NewPostgresLinkRepository(s.DB)
+		domainRepo := postgres.NewPostgresDomainRepository(s.DB)
+		campaignRepo := postgres.NewPostgresCampaignRepository(s.DB)
+		geo, err := s.GeoIP()
+		if err != nil {
+			return err
+		}
+		linkService := services.NewLinkService(linkRepo, s.Cache).
+			WithMetrics(s.Metrics).
+			WithWorkspaces(postgres.NewPostgresWorkspaceRepository(s.DB)).
+			WithDomains(domainRepo).
+			WithDailyQuota(s.RateLimits, s.Config.RateLimit.DailyLinksPerOwner).
+			WithBlocklist(s.Blocklist()).
+			WithAudit(s.Audit).
+			WithSigning(s.Signing()).
+			WithCampaigns(campaignRepo)
+		destinations := services.NewDestinationValidator(dns.NewResolver(s.Config.DNS.Server, s.Config.DNS.Timeout)).
+			WithMaxLength(s.Config.Destinations.MaxLength).
+			WithOwnHosts(s.Config.Destinations.OwnHosts...).
+			WithDomains(domainRepo).
+			WithBlocklist(s.Blocklist())
+		s.Register(server.Every("link recheck", s.Config.Blocklist.RecheckInterval, func(ctx context.Context) error {
+			_, err := linkService.DisableFlagged(ctx)
+			return err
+		}))
+
+		handler := &LinkServiceHandler{
+			linkService:  linkService,
+			destinations: destinations,
+			apiKeys:      s.APIKeys,
+			workspaces:   s.Workspaces,
+			domains:      s.Domains,
+			blocklist:    s.Blocklist(),
+			abuse:        services.NewAbuseService(postgres.NewPostgresAbuseReportRepository(s.DB), linkService).WithAudit(s.Audit),
+			audit:        s.Audit,
+			campaigns:    services.NewCampaignService(campaignRepo).WithAudit(s.Audit),
+			geo:          geo,
+		}
 
-	log.Println("Link Service stopped")
+		api := s.Router.Group("", s.RateLimit, s.Tenant, s.Auth, s.KeyLimit)
+
+		// Link endpoints
+		api.PUT("/generate", auth.RequireScope(domain.ScopeLinksWrite), handler.CreateLink)
+		api.GET("/links", auth.RequireScope(domain.ScopeLinksRead), handler.GetAllLinks)
+		api.DELETE("/delete", auth.RequireScope(domain.ScopeLinksWrite), handler.DeleteLink)
+		api.POST("/links/:id/sign", auth.RequireScope(domain.ScopeLinksWrite), handler.SignLink)
+		api.POST("/links/:id/dry-run", auth.RequireScope(domain.ScopeLinksRead), handler.DryRun)
+		api.GET("/campaigns", auth.RequireScope(domain.ScopeLinksRead), handler.GetAllCampaigns)
+		api.GET("/campaigns/:id", auth.RequireScope(domain.ScopeLinksRead), handler.GetCampaign)
+		api.POST("/campaigns", auth.RequireScope(domain.ScopeLinksWrite), handler.CreateCampaign)
+		api.PUT("/campaigns/:id", auth.RequireScope(domain.ScopeLinksWrite), handler.UpdateCampaign)
+		api.DELETE("/campaigns/:id", auth.RequireScope(domain.ScopeLinksWrite), handler.DeleteCampaign)
+		api.GET("/workspace", handler.GetWorkspace)
+		api.GET("/domains", handler.GetAllDomains)
+
+		// Audit log, of the caller's workspace or, for operators, of all
+		api.GET("/audit", auth.RequireScope(domain.ScopeAdmin), handler.GetAudit)
+		api.GET("/audit/verify", auth.RequireScope(domain.ScopeAdmin), handler.VerifyAudit)
+
+		// API key management
+		admin := api.Group("/admin", auth.RequireScope(domain.ScopeAdmin))
+		admin.POST("/api-keys", handler.IssueAPIKey)
+		admin.GET("/api-keys", handler.GetAllAPIKeys)
+		admin.DELETE("/api-keys/:id", handler.RevokeAPIKey)
+
+		// Custom domain management
+		admin.POST("/domains", handler.AddDomain)
+		admin.POST("/domains/:name/verify", handler.VerifyDomain)
+		admin.DELETE("/domains/:name", handler.RemoveDomain)
+
+		// Destination blocklist, for admins of the default workspace
+		admin.GET("/blocklist", handler.GetBlocklist)
+		admin.POST("/blocklist", handler.AddBlockRule)
+		admin.DELETE("/blocklist/:id", handler.RemoveBlockRule)
+		admin.POST("/blocklist/recheck", handler.RecheckLinks)
+
+		// Abuse report review and link takedowns, for admins of the default
+		// workspace
+		admin.GET("/reports", handler.GetReports)
+		admin.POST("/reports/:id/resolve", handler.ResolveReport)
+		admin.POST("/links/:id/disable", handler.DisableLink)
+		admin.POST("/links/:id/restore", handler.RestoreLink)
+
+		// Workspace management, for admins of the default workspace
+		admin.POST("/workspaces", handler.CreateWorkspace)
+		admin.GET("/workspaces", handler.GetAllWorkspaces)
+		admin.PUT("/workspaces/:id", handler.UpdateWorkspace)
+		return nil
+	})
 }
 
 func (h *LinkServiceHandler) CreateLink(c *gin.Context) {
 	var req CreateLinkRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem.Abort(c, &domain.ValidationError{Field: "long", Reason: err.Error()})
 		return
 	}
 
-	// Validate URL
-	if len(req.Long) < 15 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "URL must be at least 15 characters long"})
+	if req.Long == "" && len(req.Variants) > 0 {
+		req.Long = req.Variants[0].URL
+	}
+	long, err := h.destinations.Validate(c.Request.Context(), req.Long)
+	if err != nil {
+		problem.Abort(c, err)
+		return
+	}
+	for i, v := range req.Variants {
+		if req.Variants[i].URL, err = h.validateURL(c.Request.Context(), "variants", "variant "+strconv.Quote(v.ID), v.URL); err != nil {
+			problem.Abort(c, err)
+			return
+		}
+	}
+	for i, rule := range req.Rules {
+		if rule.AppURL() {
+			continue
+		}
+		if req.Rules[i].URL, err = h.validateURL(c.Request.Context(), "rules", "rule for "+strconv.Quote(string(rule.Device)), rule.URL); err != nil {
+			problem.Abort(c, err)
+			return
+		}
+	}
+	for i, rule := range req.GeoRules {
+		if req.GeoRules[i].URL, err = h.validateURL(c.Request.Context(), "geo_rules", "geo rule "+strconv.Itoa(i+1), rule.URL); err != nil {
+			problem.Abort(c, err)
+			return
+		}
+	}
+
+	if req.Domain == "" {
+		req.Domain = domain.LinkDomainOf(c.Request.Context())
+	}
+	host, err := h.linkService.LinkDomain(c.Request.Context(), req.Domain)
+	if err != nil {
+		problem.Abort(c, err)
 		return
 	}
 
 	// Generate short link
+	id, err := h.linkService.NewLinkID(c.Request.Context())
+	if err != nil {
+		problem.Abort(c, err)
+		return
+	}
 	link := domain.Link{
-		Id:          generateShortURLID(8),
-		OriginalURL: req.Long,
-		CreatedAt:   time.Now(),
+		Id:               id,
+		Domain:           host,
+		OriginalURL:      long,
+		CreatedAt:        time.Now(),
+		RequireSignature: req.RequireSignature,
+		RedirectType:     req.RedirectType,
+		ForwardQuery:     req.ForwardQuery,
+		ForwardPath:      req.ForwardPath,
+		CampaignID:       req.CampaignID,
+		Variants:         req.Variants,
+		Rules:            req.Rules,
+		GeoRules:         req.GeoRules,
 	}
+	if req.Password != "" {
+		if link.PasswordHash, err = services.HashPassword(req.Password); err != nil {
+			problem.Abort(c, err)
+			return
+		}
+	}
+	logging.Annotate(c, "link_id", link.Id)
 
 	if err := h.linkService.Create(c.Request.Context(), link); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		problem.Abort(c, err)
 		return
 	}
+	link.WorkspaceID = domain.WorkspaceOf(c.Request.Context())
 
 	// Return the full link object so frontend can display id, original_url, created_at
 	c.JSON(http.StatusOK, link)
 }
 
+// validateURL validates the URL of a variant or routing rule, named
+// name, like the destination of a link, reporting errors against field.
+func (h *LinkServiceHandler) validateURL(ctx context.Context, field, name, raw string) (string, error) {
+	valid, err := h.destinations.Validate(ctx, raw)
+	var invalid *domain.ValidationError
+	if errors.As(err, &invalid) {
+		invalid.Field = field
+		invalid.Reason = name + ": " + invalid.Reason
+	}
+	return valid, err
+}
+
 func (h *LinkServiceHandler) GetAllLinks(c *gin.Context) {
 	links, err := h.linkService.GetAll(c.Request.Context())
 	if err != nil {
-		log.Printf("Error getting all links: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get links"})
+		problem.Abort(c, err)
 		return
 	}
 
@@ -162,31 +385,471 @@ func (h *LinkServiceHandler) GetAllLinks(c *gin.Context) {
 func (h *LinkServiceHandler) DeleteLink(c *gin.Context) {
 	var req DeleteLinkRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem.Abort(c, &domain.ValidationError{Field: "id", Reason: err.Error()})
 		return
 	}
+	logging.Annotate(c, "link_id", req.ID)
 
-	if err := h.linkService.Delete(c.Request.Context(), req.ID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	ctx := c.Request.Context()
+	if req.Domain != "" {
+		ctx = domain.WithLinkDomain(ctx, req.Domain)
+	}
+	if err := h.linkService.Delete(ctx, req.ID); err != nil {
+		problem.Abort(c, err)
 		return
 	}
 
 	c.JSON(http.StatusNoContent, nil)
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// SignLink returns a signed, expiring URL of a link that requires one.
+func (h *LinkServiceHandler) SignLink(c *gin.Context) {
+	var req SignLinkRequest
+	// An empty body asks for a URL of the shared domain with the default
+	// lifetime.
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		problem.Abort(c, &domain.ValidationError{Field: "expires_in", Reason: err.Error()})
+		return
 	}
-	return defaultValue
+	logging.Annotate(c, "link_id", c.Param("id"))
+
+	ctx := c.Request.Context()
+	if req.Domain != "" {
+		ctx = domain.WithLinkDomain(ctx, req.Domain)
+	}
+	link, signature, err := h.linkService.SignURL(ctx, c.Param("id"), time.Duration(req.ExpiresIn)*time.Second)
+	if err != nil {
+		problem.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SignedLinkResponse{
+		URL:       signedURL(c, link, signature),
+		Exp:       signature.Exp(),
+		Signature: signature.Signature,
+		ExpiresAt: signature.Expires,
+	})
 }
 
-func generateShortURLID(length int) string {
-	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	result := make([]byte, length)
-	for i := range result {
-		charIndex, _ := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
-		result[i] = charset[charIndex.Int64()]
+// DryRun reports where a link would send a visitor with the given IP
+// address and user agent, without redirecting anyone or counting a
+// click. Visitors are assigned the variant their IP and user agent hash
+// to, as they would be on their first visit.
+func (h *LinkServiceHandler) DryRun(c *gin.Context) {
+	var req DryRunRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Abort(c, &domain.ValidationError{Field: "ip", Reason: err.Error()})
+		return
+	}
+	if _, err := netip.ParseAddr(req.IP); err != nil {
+		problem.Abort(c, &domain.ValidationError{Field: "ip", Reason: err.Error()})
+		return
 	}
-	return string(result)
+	id := c.Param("id")
+	logging.Annotate(c, "link_id", id)
+
+	ctx := c.Request.Context()
+	if req.Domain != "" {
+		ctx = domain.WithLinkDomain(ctx, req.Domain)
+	}
+	visitor := domain.NewVisitor(id, req.IP, h.geo.Country(req.IP), req.UserAgent, req.Referer)
+	choice, location, err := h.linkService.DryRun(ctx, id, visitor)
+	if err != nil {
+		problem.Abort(c, err)
+		return
+	}
+
+	response := DryRunResponse{URL: location, Country: visitor.Country, Rule: choice.Rule, Variant: choice.Variant}
+	if choice.GeoRule >= 0 {
+		response.GeoRule = &choice.GeoRule
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// signedURL is the short URL of link carrying signature: on the link's
+// custom domain, or under /r/ on the host the request was made to.
+func signedURL(c *gin.Context, link domain.Link, signature domain.URLSignature) string {
+	u := url.URL{Scheme: "http", Host: c.Request.Host, Path: "/r/" + link.Id, RawQuery: signature.Query()}
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		u.Scheme = "https"
+	}
+	if link.Domain != "" {
+		u.Host, u.Path = link.Domain, "/"+link.Id
+	}
+	return u.String()
+}
+
+func (h *LinkServiceHandler) IssueAPIKey(c *gin.Context) {
+	var req IssueAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Abort(c, &domain.ValidationError{Field: "owner_id", Reason: err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if req.WorkspaceID != "" {
+		ctx = domain.WithWorkspace(ctx, req.WorkspaceID)
+		// Others are refused by Issue without learning whether the
+		// workspace exists.
+		if p, _ := domain.PrincipalFrom(ctx); p.IsOperator() {
+			if _, err := h.workspaces.Current(ctx); err != nil {
+				problem.Abort(c, err)
+				return
+			}
+		}
+	}
+
+	token, key, err := h.apiKeys.Issue(ctx, req.OwnerID, req.Name, req.Scopes)
+	if err != nil {
+		problem.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, IssueAPIKeyResponse{APIKey: key, Key: token})
+}
+
+func (h *LinkServiceHandler) GetAllAPIKeys(c *gin.Context) {
+	keys, err := h.apiKeys.All(c.Request.Context())
+	if err != nil {
+		problem.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+func (h *LinkServiceHandler) RevokeAPIKey(c *gin.Context) {
+	if err := h.apiKeys.Revoke(c.Request.Context(), c.Param("id")); err != nil {
+		problem.Abort(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *LinkServiceHandler) GetAllCampaigns(c *gin.Context) {
+	campaigns, err := h.campaigns.All(c.Request.Context())
+	if err != nil {
+		problem.Abort(c, err)
+		return
+	}
+	if campaigns == nil {
+		campaigns = []domain.Campaign{}
+	}
+
+	c.JSON(http.StatusOK, campaigns)
+}
+
+func (h *LinkServiceHandler) GetCampaign(c *gin.Context) {
+	campaign, err := h.campaigns.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		problem.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, campaign)
+}
+
+func (h *LinkServiceHandler) CreateCampaign(c *gin.Context) {
+	var req CampaignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Abort(c, &domain.ValidationError{Field: "name", Reason: err.Error()})
+		return
+	}
+
+	campaign, err := h.campaigns.Create(c.Request.Context(), domain.Campaign{Name: req.Name, UTM: req.UTM})
+	if err != nil {
+		problem.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, campaign)
+}
+
+func (h *LinkServiceHandler) UpdateCampaign(c *gin.Context) {
+	var req CampaignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Abort(c, &domain.ValidationError{Field: "name", Reason: err.Error()})
+		return
+	}
+
+	campaign, err := h.campaigns.Update(c.Request.Context(), domain.Campaign{Id: c.Param("id"), Name: req.Name, UTM: req.UTM})
+	if err != nil {
+		problem.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, campaign)
+}
+
+func (h *LinkServiceHandler) DeleteCampaign(c *gin.Context) {
+	if err := h.campaigns.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		problem.Abort(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *LinkServiceHandler) GetAllDomains(c *gin.Context) {
+	domains, err := h.domains.All(c.Request.Context())
+	if err != nil {
+		problem.Abort(c, err)
+		return
+	}
+
+	response := make([]DomainResponse, 0, len(domains))
+	for _, d := range domains {
+		response = append(response, newDomainResponse(d))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *LinkServiceHandler) AddDomain(c *gin.Context) {
+	var req AddDomainRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Abort(c, &domain.ValidationError{Field: "name", Reason: err.Error()})
+		return
+	}
+
+	d, err := h.domains.Add(c.Request.Context(), req.Name)
+	if err != nil {
+		problem.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, newDomainResponse(d))
+}
+
+func (h *LinkServiceHandler) VerifyDomain(c *gin.Context) {
+	d, err := h.domains.Verify(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		problem.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newDomainResponse(d))
+}
+
+func (h *LinkServiceHandler) RemoveDomain(c *gin.Context) {
+	if err := h.domains.Remove(c.Request.Context(), c.Param("name")); err != nil {
+		problem.Abort(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func newDomainResponse(d domain.CustomDomain) DomainResponse {
+	return DomainResponse{CustomDomain: d, TXTName: d.TXTName(), TXTValue: d.TXTValue()}
+}
+
+func (h *LinkServiceHandler) GetBlocklist(c *gin.Context) {
+	rules, err := h.blocklist.All(c.Request.Context())
+	if err != nil {
+		problem.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+func (h *LinkServiceHandler) AddBlockRule(c *gin.Context) {
+	var req AddBlockRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Abort(c, &domain.ValidationError{Field: "pattern", Reason: err.Error()})
+		return
+	}
+
+	rule, err := h.blocklist.Add(c.Request.Context(), domain.BlockRule{Kind: req.Kind, Pattern: req.Pattern, Reason: req.Reason})
+	if err != nil {
+		problem.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+func (h *LinkServiceHandler) RemoveBlockRule(c *gin.Context) {
+	if err := h.blocklist.Remove(c.Request.Context(), c.Param("id")); err != nil {
+		problem.Abort(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RecheckLinks reloads the blocklist and disables the links it flags now
+// rather than at the next scheduled recheck.
+func (h *LinkServiceHandler) RecheckLinks(c *gin.Context) {
+	disabled, err := h.linkService.DisableFlagged(c.Request.Context())
+	if err != nil {
+		problem.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"disabled": disabled})
+}
+
+// GetReports returns the abuse report queue. ?status= selects open (the
+// default), disabled, restored, dismissed or all reports.
+func (h *LinkServiceHandler) GetReports(c *gin.Context) {
+	status := c.DefaultQuery("status", domain.ReportOpen)
+	if status == "all" {
+		status = ""
+	}
+
+	reports, err := h.abuse.Queue(c.Request.Context(), status)
+	if err != nil {
+		problem.Abort(c, err)
+		return
+	}
+	if reports == nil {
+		reports = []domain.AbuseReport{}
+	}
+
+	c.JSON(http.StatusOK, reports)
+}
+
+func (h *LinkServiceHandler) ResolveReport(c *gin.Context) {
+	var req ResolveReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Abort(c, &domain.ValidationError{Field: "decision", Reason: err.Error()})
+		return
+	}
+
+	report, err := h.abuse.Resolve(c.Request.Context(), c.Param("id"), req.Decision, req.Note)
+	if err != nil {
+		problem.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+func (h *LinkServiceHandler) DisableLink(c *gin.Context) {
+	h.moderateLink(c, h.abuse.DisableLink)
+}
+
+func (h *LinkServiceHandler) RestoreLink(c *gin.Context) {
+	h.moderateLink(c, h.abuse.RestoreLink)
+}
+
+func (h *LinkServiceHandler) moderateLink(c *gin.Context, decide func(ctx context.Context, workspaceID, host, id, note string) error) {
+	var req ModerateLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Abort(c, &domain.ValidationError{Field: "note", Reason: err.Error()})
+		return
+	}
+	if req.WorkspaceID == "" {
+		req.WorkspaceID = domain.WorkspaceOf(c.Request.Context())
+	}
+	logging.Annotate(c, "link_id", c.Param("id"))
+
+	if err := decide(c.Request.Context(), req.WorkspaceID, req.Domain, c.Param("id"), req.Note); err != nil {
+		problem.Abort(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *LinkServiceHandler) GetWorkspace(c *gin.Context) {
+	workspace, err := h.workspaces.Current(c.Request.Context())
+	if err != nil {
+		problem.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, workspace)
+}
+
+func (h *LinkServiceHandler) CreateWorkspace(c *gin.Context) {
+	var req domain.Workspace
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Abort(c, &domain.ValidationError{Field: "body", Reason: err.Error()})
+		return
+	}
+
+	workspace, err := h.workspaces.Create(c.Request.Context(), req)
+	if err != nil {
+		problem.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, workspace)
+}
+
+func (h *LinkServiceHandler) GetAllWorkspaces(c *gin.Context) {
+	workspaces, err := h.workspaces.All(c.Request.Context())
+	if err != nil {
+		problem.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, workspaces)
+}
+
+func (h *LinkServiceHandler) UpdateWorkspace(c *gin.Context) {
+	var req domain.Workspace
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Abort(c, &domain.ValidationError{Field: "body", Reason: err.Error()})
+		return
+	}
+	req.Id = c.Param("id")
+
+	workspace, err := h.workspaces.Update(c.Request.Context(), req)
+	if err != nil {
+		problem.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, workspace)
+}
+
+// GetAudit returns a page of the audit log matching the AuditQuery.
+func (h *LinkServiceHandler) GetAudit(c *gin.Context) {
+	var req AuditQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		problem.Abort(c, &domain.ValidationError{Field: "query", Reason: err.Error()})
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = domain.DefaultAuditPageSize
+	}
+
+	entries, err := h.audit.Find(c.Request.Context(), domain.AuditFilter{
+		WorkspaceID: req.WorkspaceID,
+		Actor:       req.Actor,
+		Action:      req.Action,
+		ResourceID:  req.ResourceID,
+		Since:       req.Since,
+		Until:       req.Until,
+		Before:      req.Before,
+		Limit:       req.Limit,
+	})
+	if err != nil {
+		problem.Abort(c, err)
+		return
+	}
+
+	page := AuditPage{Entries: entries}
+	if page.Entries == nil {
+		page.Entries = []domain.AuditEntry{}
+	}
+	if len(entries) == req.Limit {
+		page.Next = entries[len(entries)-1].Seq
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// VerifyAudit checks the hash chain of the audit log.
+func (h *LinkServiceHandler) VerifyAudit(c *gin.Context) {
+	result, err := h.audit.Verify(c.Request.Context())
+	if err != nil {
+		problem.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"intact": result.Intact(), "checked": result.Checked, "chained": result.Chained, "broken_at": result.BrokenAt})
 }
@@ -2,154 +2,307 @@ package main
 
 import (
 	"context"
-	"database/sql"
+	"crypto/rand"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
-	"os"
-	"os/signal"
-	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	"github.com/itsbaivab/url-shortener/internal/adapters/cache"
+	"github.com/itsbaivab/url-shortener/internal/adapters/metrics"
+	"github.com/itsbaivab/url-shortener/internal/adapters/passwordpage"
+	"github.com/itsbaivab/url-shortener/internal/adapters/problem"
+	"github.com/itsbaivab/url-shortener/internal/adapters/redirectpage"
 	"github.com/itsbaivab/url-shortener/internal/adapters/repository/postgres"
+	"github.com/itsbaivab/url-shortener/internal/adapters/takedown"
 	"github.com/itsbaivab/url-shortener/internal/core/domain"
+	"github.com/itsbaivab/url-shortener/internal/core/ports"
 	"github.com/itsbaivab/url-shortener/internal/core/services"
-	_ "github.com/lib/pq"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/itsbaivab/url-shortener/internal/logging"
+	"github.com/itsbaivab/url-shortener/internal/server"
+	"github.com/itsbaivab/url-shortener/internal/tracing"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-type RedirectServiceHandler struct {
-	linkService  *services.LinkService
-	statsService *services.StatsService
-}
+// accessCookiePrefix names the cookies holding access grants to password
+// protected links; the link ID follows it.
+const accessCookiePrefix = "link_access_"
 
-func main() {
-	// Database connection
-	dbHost := getEnv("DB_HOST", "localhost")
-	dbPort := getEnv("DB_PORT", "5432")
-	dbUser := getEnv("DB_USER", "postgres")
-	dbPassword := getEnv("DB_PASSWORD", "postgres")
-	dbName := getEnv("DB_NAME", "urlshortener")
+// variantCookiePrefix names the cookies keeping visitors on the variant
+// of a link they were first sent to; the link ID follows it.
+const variantCookiePrefix = "link_variant_"
 
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		dbHost, dbPort, dbUser, dbPassword, dbName)
+// variantCookieMaxAge is how long, in seconds, visitors keep their
+// variant.
+const variantCookieMaxAge = 30 * 24 * 60 * 60
 
-	db, err := sql.Open("postgres", dsn)
-	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
-	}
-	defer db.Close()
-
-	// Test database connection
-	if err := db.Ping(); err != nil {
-		log.Fatal("Failed to ping database:", err)
-	}
+type RedirectServiceHandler struct {
+	passwords         *services.PasswordService
+	abuse             *services.AbuseService
+	statsService      *services.StatsService
+	statsWriteTimeout time.Duration
+	background        *server.Lifecycle
+	statsQueue        prometheus.Gauge
+	geo               ports.GeoIP
+}
 
-	// Redis connection
-	redisHost := getEnv("REDIS_HOST", "localhost")
-	redisPort := getEnv("REDIS_PORT", "6379")
-	redisCache := cache.NewRedisCache(redisHost+":"+redisPort, "", 0)
+// ReportLinkRequest is an abuse report from the public.
+type ReportLinkRequest struct {
+	Reason  string `json:"reason" binding:"required"`
+	Details string `json:"details"`
+	Email   string `json:"email"`
+}
 
-	// Initialize repositories and services
-	linkRepo := postgres.NewPostgresLinkRepository(db)
-	statsRepo := postgres.NewPostgresStatsRepository(db)
-	linkService := services.NewLinkService(linkRepo, redisCache)
-	statsService := services.NewStatsService(statsRepo, redisCache)
+func main() {
+	server.Run(server.Options{Name: "Redirect Service", DefaultPort: "8002"}, func(s *server.Server) error {
+		linkRepo := postgres.NewPostgresLinkRepository(s.DB)
+		statsRepo := postgres.NewPostgresStatsRepository(s.DB)
+		geo, err := s.GeoIP()
+		if err != nil {
+			return err
+		}
+		linkService := services.NewLinkService(linkRepo, s.Cache).
+			WithMetrics(s.Metrics).
+			WithBlocklist(s.Blocklist()).
+			WithSigning(s.Signing()).
+			WithCampaigns(postgres.NewPostgresCampaignRepository(s.DB))
+		statsService := services.NewStatsService(statsRepo, s.Cache).WithMetrics(s.Metrics)
 
-	// Initialize handler
-	handler := &RedirectServiceHandler{
-		linkService:  linkService,
-		statsService: statsService,
-	}
+		secret := []byte(s.Config.Passwords.AccessSecret)
+		if len(secret) == 0 {
+			slog.Warn("no link access secret configured; password protected links must be unlocked again on every replica and after restarts")
+			secret = make([]byte, 32)
+			if _, err := rand.Read(secret); err != nil {
+				return fmt.Errorf("failed to generate link access secret: %w", err)
+			}
+		}
+		passwords := services.NewPasswordService(linkService, secret).
+			WithAccessTTL(s.Config.Passwords.AccessTTL).
+			WithAttemptLimit(s.RateLimits, s.Config.Passwords.AttemptLimit(), s.Config.Passwords.LinkAttemptLimit())
 
-	// Setup router
-	router := gin.Default()
+		handler := &RedirectServiceHandler{
+			passwords:         passwords,
+			abuse:             services.NewAbuseService(postgres.NewPostgresAbuseReportRepository(s.DB), linkService),
+			statsService:      statsService,
+			statsWriteTimeout: s.Config.StatsWriteTimeout,
+			background:        s.Lifecycle,
+			statsQueue: metrics.QueueDepth(prometheus.DefaultRegisterer,
+				"stats_queue_depth", "Click stats waiting to be written."),
+			geo: geo,
+		}
 
-	// Health check
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "healthy"})
+		// Redirect endpoint, scoped to the workspace owning the host
+		s.Router.GET("/redirect/:id", s.RateLimit, s.Tenant, handler.Redirect)
+		// Paths after the ID, for links that forward them
+		s.Router.GET("/redirect/:id/*suffix", s.RateLimit, s.Tenant, handler.Redirect)
+		// Password form submissions of protected links
+		s.Router.POST("/redirect/:id", s.RateLimit, s.Tenant, handler.Unlock)
+		s.Router.POST("/redirect/:id/*suffix", s.RateLimit, s.Tenant, handler.Unlock)
+		// Public abuse reports, on the same host as the link
+		s.Router.POST("/report/:id", s.RateLimit, s.Tenant, handler.Report)
+		return nil
 	})
+}
 
-	// Metrics endpoint
-	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
-
-	// Redirect endpoint
-	router.GET("/redirect/:id", handler.Redirect)
-
-	// Start server
-	port := getEnv("SERVICE_PORT", "8002")
-	srv := &http.Server{
-		Addr:    ":" + port,
-		Handler: router,
+func (h *RedirectServiceHandler) Redirect(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		problem.Abort(c, &domain.ValidationError{Field: "id", Reason: "ID parameter is required"})
+		return
 	}
+	logging.Annotate(c, "link_id", id)
 
-	// Graceful shutdown
-	go func() {
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Failed to start server: %v", err)
-		}
-	}()
-
-	log.Printf("Redirect Service started on port %s", port)
+	// Get original URL; signed URLs carry their signature in the query
+	// and protected links need the access grant cookie
+	ctx := domain.WithURLSignature(c.Request.Context(), domain.ParseURLSignature(c.Query("exp"), c.Query("sig")))
+	token, _ := c.Cookie(accessCookiePrefix + id)
+	target, public, err := h.passwords.Resolve(ctx, id, token)
+	if h.abortPage(c, id, err) {
+		return
+	}
+	if err != nil {
+		problem.Abort(c, err)
+		return
+	}
+	target, click := h.choose(c, id, target)
+	location, ok := h.location(c, target)
+	if !ok {
+		return
+	}
 
-	// Wait for interrupt signal
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	h.recordClick(c, click)
 
-	log.Println("Shutting down Redirect Service...")
+	// Redirect the way the link chose. Browsers cache permanent
+	// redirects, which would let them skip the password of protected
+	// links and the expiry of signed URLs.
+	redirectpage.Redirect(c, target.Type, location, public)
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+// location returns where target sends this request, forwarding its path
+// suffix and query when the link does. It aborts the request and reports
+// false when there is no such short URL.
+func (h *RedirectServiceHandler) location(c *gin.Context, target domain.Target) (string, bool) {
+	if target.URL == "" {
+		problem.AbortWith(c, problem.New(http.StatusNotFound, "Link not found"))
+		return "", false
+	}
+	location, err := target.Location(c.Param("suffix"), c.Request.URL.Query(), platformOf(c))
+	if err != nil {
+		problem.Abort(c, err)
+		return "", false
+	}
+	return location, true
+}
 
-	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatal("Server forced to shutdown:", err)
+// choose sends the request to the routing rule of target matching the
+// device of its user agent, the geo rule matching the country of its
+// client IP or else to a variant, keeping visitors with the cookie of an
+// earlier visit on the same variant. It returns where the request goes
+// and the stats of the click.
+func (h *RedirectServiceHandler) choose(c *gin.Context, id string, target domain.Target) (domain.Target, domain.Stats) {
+	if len(target.Rules) > 0 {
+		c.Header("Vary", "User-Agent")
+	}
+	if len(target.GeoRules) > 0 {
+		// Shared caches cannot tell visitors from different countries apart.
+		c.Header("Cache-Control", "private")
 	}
+	visitor := domain.NewVisitor(id, c.ClientIP(), h.geo.Country(c.ClientIP()), c.Request.UserAgent(), c.Request.Referer())
+	visitor.Variant, _ = c.Cookie(variantCookiePrefix + id)
 
-	log.Println("Redirect Service stopped")
+	choice := target.Choose(visitor)
+	if choice.Variant != "" && choice.Variant != visitor.Variant {
+		c.SetSameSite(http.SameSiteLaxMode)
+		c.SetCookie(variantCookiePrefix+id, choice.Variant, variantCookieMaxAge, "/", "", secure(c), true)
+	}
+	return choice.Target, domain.Stats{LinkID: id, CampaignID: target.CampaignID, Rule: choice.Rule, Variant: choice.Variant}
 }
 
-func (h *RedirectServiceHandler) Redirect(c *gin.Context) {
+// Unlock checks the password submitted through the form of a protected
+// link. The right password sets a cookie granting access to the link for
+// a while and redirects to the destination; wrong ones show the form
+// again. Both are recorded in the link's stats.
+func (h *RedirectServiceHandler) Unlock(c *gin.Context) {
 	id := c.Param("id")
-	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "ID parameter is required"})
+	logging.Annotate(c, "link_id", id)
+
+	ctx := domain.WithURLSignature(c.Request.Context(), domain.ParseURLSignature(c.Query("exp"), c.Query("sig")))
+	target, access, err := h.passwords.Unlock(ctx, id, c.PostForm(passwordpage.Field), c.ClientIP())
+	var locked *domain.PasswordRequiredError
+	if errors.As(err, &locked) && locked.Rejected {
+		h.recordClick(c, domain.Stats{LinkID: id, PasswordAttempt: domain.PasswordRejected})
+	}
+	if h.abortPage(c, id, err) {
 		return
 	}
-
-	// Get original URL
-	originalURL, err := h.linkService.GetOriginalURL(c.Request.Context(), id)
-	if err != nil || originalURL == nil || *originalURL == "" {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Link not found"})
+	if err != nil {
+		problem.Abort(c, err)
+		return
+	}
+	target, click := h.choose(c, id, target)
+	location, ok := h.location(c, target)
+	if !ok {
 		return
 	}
 
-	// Create stats entry asynchronously
-	go func() {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	attempt := ""
+	if access.Token != "" {
+		attempt = domain.PasswordAccepted
+		c.SetSameSite(http.SameSiteLaxMode)
+		c.SetCookie(accessCookiePrefix+id, access.Token, int(time.Until(access.Expires).Seconds()), "/", "", secure(c), true)
+	}
+	click.PasswordAttempt = attempt
+	h.recordClick(c, click)
+
+	c.Header("Cache-Control", "no-store")
+	c.Redirect(http.StatusSeeOther, location)
+}
+
+// abortPage answers browsers with the takedown page for disabled links
+// and the password form for protected ones. It reports whether it did.
+func (h *RedirectServiceHandler) abortPage(c *gin.Context, id string, err error) bool {
+	if err == nil || !takedown.Accepts(c.GetHeader("Accept")) {
+		return false
+	}
+	var disabled *domain.DisabledError
+	var locked *domain.PasswordRequiredError
+	switch {
+	case errors.As(err, &disabled):
+		takedown.Abort(c, disabled)
+	case errors.As(err, &locked) && locked.Rejected:
+		passwordpage.Abort(c, http.StatusUnauthorized, passwordpage.Page{LinkID: id, Message: "Incorrect password, please try again."})
+	case errors.As(err, &locked):
+		passwordpage.Abort(c, http.StatusUnauthorized, passwordpage.Page{LinkID: id})
+	case errors.Is(err, domain.ErrRateLimited):
+		passwordpage.Abort(c, http.StatusTooManyRequests, passwordpage.Page{LinkID: id, Message: "Too many attempts, please try again later."})
+	default:
+		return false
+	}
+	return true
+}
+
+// recordClick writes stats, a redirect or a password attempt, for the
+// request asynchronously; shutdown waits for it. The write is traced and
+// logged as part of the request.
+func (h *RedirectServiceHandler) recordClick(c *gin.Context, stats domain.Stats) {
+	requestCtx := c.Request.Context()
+	stats.Id = uuid.New().String()
+	stats.Platform = platformOf(c)
+	stats.CreatedAt = time.Now()
+	stats.WorkspaceID, stats.Domain = domain.WorkspaceOf(requestCtx), domain.LinkDomainOf(requestCtx)
+
+	h.statsQueue.Inc()
+	h.background.Go(func(ctx context.Context) {
+		defer h.statsQueue.Dec()
+		ctx, cancel := context.WithTimeout(logging.Inherit(tracing.Detach(ctx, requestCtx), requestCtx), h.statsWriteTimeout)
 		defer cancel()
 
-		stats := domain.Stats{
-			Id:        uuid.New().String(),
-			LinkID:    id,
-			Platform:  domain.PlatformUnknown, // TODO: Detect platform from user agent
-			CreatedAt: time.Now(),
-		}
+		ctx, span := tracing.Start(ctx, "stats.record", attribute.String("link.id", stats.LinkID))
 
-		if err := h.statsService.Create(ctx, stats); err != nil {
-			log.Printf("Failed to create stats: %v", err)
+		err := h.statsService.Create(ctx, stats)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to create stats", "error", err)
 		}
-	}()
+		tracing.End(span, err)
+	})
+}
+
+// secure reports whether the request reached the proxy over HTTPS, so
+// cookies set for it may be limited to HTTPS.
+func secure(c *gin.Context) bool {
+	return c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
+}
 
-	// Redirect to original URL
-	c.Redirect(http.StatusMovedPermanently, *originalURL)
+// platformOf detects the platform the request came from.
+func platformOf(c *gin.Context) domain.Platform {
+	return domain.DetectPlatform(c.Request.UserAgent(), c.Request.Referer())
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// Report files an abuse report against a link. Anyone may report a link;
+// requests are rate limited per IP like redirects.
+func (h *RedirectServiceHandler) Report(c *gin.Context) {
+	var req ReportLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Abort(c, &domain.ValidationError{Field: "reason", Reason: err.Error()})
+		return
+	}
+	logging.Annotate(c, "link_id", c.Param("id"))
+
+	report, err := h.abuse.Report(c.Request.Context(), domain.AbuseReport{
+		LinkID:        c.Param("id"),
+		Reason:        req.Reason,
+		Details:       req.Details,
+		ReporterEmail: req.Email,
+		ReporterIP:    c.ClientIP(),
+		UserAgent:     c.Request.UserAgent(),
+	})
+	if err != nil {
+		problem.Abort(c, err)
+		return
 	}
-	return defaultValue
+
+	c.JSON(http.StatusAccepted, gin.H{"id": report.Id, "status": report.Status})
 }
@@ -2,21 +2,18 @@ package main
 
 import (
 	"context"
-	"database/sql"
-	"fmt"
-	"log"
+	"errors"
+	"log/slog"
 	"net/http"
-	"os"
-	"os/signal"
-	"syscall"
-	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/itsbaivab/url-shortener/internal/adapters/cache"
+	"github.com/itsbaivab/url-shortener/internal/adapters/problem"
 	"github.com/itsbaivab/url-shortener/internal/adapters/repository/postgres"
+	"github.com/itsbaivab/url-shortener/internal/auth"
+	"github.com/itsbaivab/url-shortener/internal/core/domain"
 	"github.com/itsbaivab/url-shortener/internal/core/services"
-	_ "github.com/lib/pq"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/itsbaivab/url-shortener/internal/logging"
+	"github.com/itsbaivab/url-shortener/internal/server"
 )
 
 type StatsServiceHandler struct {
@@ -25,105 +22,43 @@ type StatsServiceHandler struct {
 }
 
 func main() {
-	// Database connection
-	dbHost := getEnv("DB_HOST", "localhost")
-	dbPort := getEnv("DB_PORT", "5432")
-	dbUser := getEnv("DB_USER", "postgres")
-	dbPassword := getEnv("DB_PASSWORD", "postgres")
-	dbName := getEnv("DB_NAME", "urlshortener")
-
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		dbHost, dbPort, dbUser, dbPassword, dbName)
-
-	db, err := sql.Open("postgres", dsn)
-	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
-	}
-	defer db.Close()
-
-	// Test database connection
-	if err := db.Ping(); err != nil {
-		log.Fatal("Failed to ping database:", err)
-	}
-
-	// Redis connection
-	redisHost := getEnv("REDIS_HOST", "localhost")
-	redisPort := getEnv("REDIS_PORT", "6379")
-	redisCache := cache.NewRedisCache(redisHost+":"+redisPort, "", 0)
-
-	// Initialize repositories and services
-	linkRepo := postgres.NewPostgresLinkRepository(db)
-	statsRepo := postgres.NewPostgresStatsRepository(db)
-	linkService := services.NewLinkService(linkRepo, redisCache)
-	statsService := services.NewStatsService(statsRepo, redisCache)
-
-	// Initialize handler
-	handler := &StatsServiceHandler{
-		linkService:  linkService,
-		statsService: statsService,
-	}
-
-	// Setup router
-	router := gin.Default()
-
-	// Health check
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "healthy"})
-	})
-
-	// Metrics endpoint
-	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
-
-	// Stats endpoints
-	router.GET("/stats", handler.GetStats)
-	router.GET("/stats/:id", handler.GetStatsByLinkID)
-
-	// Start server
-	port := getEnv("SERVICE_PORT", "8003")
-	srv := &http.Server{
-		Addr:    ":" + port,
-		Handler: router,
-	}
-
-	// Graceful shutdown
-	go func() {
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Failed to start server: %v", err)
+	server.Run(server.Options{Name: "Stats Service", DefaultPort: "8003"}, func(s *server.Server) error {
+		linkRepo := postgres.NewPostgresLinkRepository(s.DB)
+		statsRepo := postgres.NewPostgresStatsRepository(s.DB)
+		linkService := services.NewLinkService(linkRepo, s.Cache).WithMetrics(s.Metrics)
+		statsService := services.NewStatsService(statsRepo, s.Cache).WithMetrics(s.Metrics)
+
+		handler := &StatsServiceHandler{
+			linkService:  linkService,
+			statsService: statsService,
 		}
-	}()
-
-	log.Printf("Stats Service started on port %s", port)
-
-	// Wait for interrupt signal
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-
-	log.Println("Shutting down Stats Service...")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+		api := s.Router.Group("", s.RateLimit, s.Tenant, s.Auth, s.KeyLimit, auth.RequireScope(domain.ScopeStatsRead))
 
-	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatal("Server forced to shutdown:", err)
-	}
-
-	log.Println("Stats Service stopped")
+		// Stats endpoints
+		api.GET("/stats", handler.GetStats)
+		api.GET("/stats/:id", handler.GetStatsByLinkID)
+		api.GET("/stats/:id/variants", handler.GetVariantStats)
+		api.GET("/stats/:id/rules", handler.GetRoutingStats)
+		api.GET("/stats/campaigns/:id", handler.GetCampaignStats)
+		return nil
+	})
 }
 
 func (h *StatsServiceHandler) GetStats(c *gin.Context) {
 	// Get all links with their stats
 	links, err := h.linkService.GetAll(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		problem.Abort(c, err)
 		return
 	}
 
 	// Enhance links with stats
 	for i, link := range links {
-		stats, err := h.statsService.GetStatsByLinkID(c.Request.Context(), link.Id)
+		ctx := domain.WithLinkDomain(c.Request.Context(), link.Domain)
+		stats, err := h.statsService.GetStatsByLinkID(ctx, link.Id)
 		if err != nil {
-			log.Printf("Error getting stats for link '%s': %v", link.Id, err)
+			slog.WarnContext(c.Request.Context(), "failed to get stats", "link_id", link.Id, "error", err)
 			continue
 		}
 		links[i].Stats = stats
@@ -135,22 +70,104 @@ func (h *StatsServiceHandler) GetStats(c *gin.Context) {
 func (h *StatsServiceHandler) GetStatsByLinkID(c *gin.Context) {
 	linkID := c.Param("id")
 	if linkID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Link ID parameter is required"})
+		problem.Abort(c, &domain.ValidationError{Field: "id", Reason: "Link ID parameter is required"})
 		return
 	}
+	logging.Annotate(c, "link_id", linkID)
 
-	stats, err := h.statsService.GetStatsByLinkID(c.Request.Context(), linkID)
+	// Links on a custom domain are selected with ?domain=
+	ctx := c.Request.Context()
+	if host := c.Query("domain"); host != "" {
+		ctx = domain.WithLinkDomain(ctx, host)
+	}
+
+	// Only the link's owner, or an admin, may see its stats
+	if _, err := h.linkService.Get(ctx, linkID); err != nil {
+		problem.Abort(c, err)
+		return
+	}
+
+	stats, err := h.statsService.GetStatsByLinkID(ctx, linkID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		problem.Abort(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, stats)
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// GetVariantStats counts the clicks on each variant of a link.
+func (h *StatsServiceHandler) GetVariantStats(c *gin.Context) {
+	link, ctx, ok := h.link(c)
+	if !ok {
+		return
+	}
+
+	clicks, err := h.statsService.VariantClicks(ctx, link)
+	if err != nil {
+		problem.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, clicks)
+}
+
+// GetRoutingStats counts the clicks sent to each routing rule of a link.
+func (h *StatsServiceHandler) GetRoutingStats(c *gin.Context) {
+	link, ctx, ok := h.link(c)
+	if !ok {
+		return
 	}
-	return defaultValue
+
+	summary, err := h.statsService.RoutingStats(ctx, link)
+	if err != nil {
+		problem.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// link reads the link of the request, on the custom domain selected with
+// ?domain=, and the context to read its stats with. Only the link's
+// owner, or an admin, may read it; otherwise the request is aborted.
+func (h *StatsServiceHandler) link(c *gin.Context) (domain.Link, context.Context, bool) {
+	linkID := c.Param("id")
+	logging.Annotate(c, "link_id", linkID)
+
+	ctx := c.Request.Context()
+	if host := c.Query("domain"); host != "" {
+		ctx = domain.WithLinkDomain(ctx, host)
+	}
+	link, err := h.linkService.Get(ctx, linkID)
+	if err != nil {
+		problem.Abort(c, err)
+		return domain.Link{}, nil, false
+	}
+	return link, ctx, true
+}
+
+// GetCampaignStats adds up the clicks of every link of a campaign. Like
+// the stats of single links, those of other owners' links are only
+// counted for admins.
+func (h *StatsServiceHandler) GetCampaignStats(c *gin.Context) {
+	ctx := c.Request.Context()
+	var visible func(linkID, linkDomain string) bool
+	if p, ok := domain.PrincipalFrom(ctx); ok && !p.HasScope(domain.ScopeAdmin) {
+		visible = func(linkID, linkDomain string) bool {
+			_, err := h.linkService.Get(domain.WithLinkDomain(ctx, linkDomain), linkID)
+			if err != nil && !errors.Is(err, domain.ErrNotFound) {
+				slog.WarnContext(ctx, "failed to check link owner; leaving it out", "link_id", linkID, "error", err)
+			}
+			return err == nil
+		}
+	}
+
+	summary, err := h.statsService.CampaignStats(ctx, c.Param("id"), visible)
+	if err != nil {
+		problem.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
 }